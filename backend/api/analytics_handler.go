@@ -0,0 +1,40 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"cepm-backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AnalyticsHandler struct {
+	service services.AnalyticsService
+}
+
+func NewAnalyticsHandler(service services.AnalyticsService) *AnalyticsHandler {
+	return &AnalyticsHandler{service: service}
+}
+
+// RaterLeniency handles the HTTP request for per-evaluator scoring statistics and outliers.
+// Optional query parameters: period (YYYY-MM) and z (outlier threshold).
+func (h *AnalyticsHandler) RaterLeniency(c *gin.Context) {
+	var zThreshold *float64
+	if zStr := c.Query("z"); zStr != "" {
+		z, err := strconv.ParseFloat(zStr, 64)
+		if err != nil || z <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid z"})
+			return
+		}
+		zThreshold = &z
+	}
+
+	report, err := h.service.RaterLeniency(c.Query("period"), zThreshold)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
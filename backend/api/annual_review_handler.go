@@ -0,0 +1,130 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"cepm-backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AnnualReviewHandler struct {
+	service services.AnnualReviewService
+}
+
+func NewAnnualReviewHandler(service services.AnnualReviewService) *AnnualReviewHandler {
+	return &AnnualReviewHandler{service: service}
+}
+
+// parseYearParam parses the :year path parameter, writing a 400 response if it is invalid.
+func parseYearParam(c *gin.Context) (int, bool) {
+	year, err := strconv.Atoi(c.Param("year"))
+	if err != nil || year < 2000 || year > 9999 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid year"})
+		return 0, false
+	}
+	return year, true
+}
+
+// ComputeYear handles the HTTP request for HR to recompute a year's annual reviews.
+// Optional query parameter: departmentId, to limit the computation to a department subtree.
+func (h *AnnualReviewHandler) ComputeYear(c *gin.Context) {
+	year, ok := parseYearParam(c)
+	if !ok {
+		return
+	}
+
+	var departmentID *uint
+	if departmentIdStr := c.Query("departmentId"); departmentIdStr != "" {
+		id, err := strconv.ParseUint(departmentIdStr, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid departmentId"})
+			return
+		}
+		value := uint(id)
+		departmentID = &value
+	}
+
+	annuals, err := h.service.ComputeYear(year, departmentID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, annuals)
+}
+
+// GetUserAnnualReview handles the HTTP request to get a user's annual review.
+func (h *AnnualReviewHandler) GetUserAnnualReview(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	year, ok := parseYearParam(c)
+	if !ok {
+		return
+	}
+	userID, ok := parseIDParam(c, "userId")
+	if !ok {
+		return
+	}
+
+	annual, err := h.service.GetUserAnnualReview(year, userID, user)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, annual)
+}
+
+// SetEvaluation handles the HTTP request for HR to enter a user's annual evaluation result.
+func (h *AnnualReviewHandler) SetEvaluation(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	year, ok := parseYearParam(c)
+	if !ok {
+		return
+	}
+	userID, ok := parseIDParam(c, "userId")
+	if !ok {
+		return
+	}
+
+	var input services.AnnualEvaluationInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+
+	annual, err := h.service.SetEvaluation(year, userID, user.ID, &input)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, annual)
+}
+
+// GetDepartmentReport handles the HTTP request to get a department's annual reviews and statistics.
+func (h *AnnualReviewHandler) GetDepartmentReport(c *gin.Context) {
+	year, ok := parseYearParam(c)
+	if !ok {
+		return
+	}
+	departmentID, ok := parseIDParam(c, "departmentId")
+	if !ok {
+		return
+	}
+
+	report, err := h.service.GetDepartmentReport(year, departmentID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
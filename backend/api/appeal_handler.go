@@ -0,0 +1,149 @@
+package api
+
+import (
+	"net/http"
+
+	"cepm-backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AppealHandler struct {
+	service services.AppealService
+}
+
+func NewAppealHandler(service services.AppealService) *AppealHandler {
+	return &AppealHandler{service: service}
+}
+
+// FileAppeal handles the HTTP request for an employee to appeal their scored result.
+func (h *AppealHandler) FileAppeal(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	reviewID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var input services.AppealInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+
+	appeal, err := h.service.FileAppeal(reviewID, user.ID, &input)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, appeal)
+}
+
+// ListReviewAppeals handles the HTTP request to list the appeals filed against a review.
+func (h *AppealHandler) ListReviewAppeals(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	reviewID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	appeals, err := h.service.ListReviewAppeals(reviewID, user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, appeals)
+}
+
+// ListMyAppeals handles the HTTP request to list the appeals the current user filed or handles.
+func (h *AppealHandler) ListMyAppeals(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	appeals, err := h.service.ListMyAppeals(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, appeals)
+}
+
+// GetAppeal handles the HTTP request to get a single appeal with its timeline.
+func (h *AppealHandler) GetAppeal(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	appeal, err := h.service.GetAppeal(id, user)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, appeal)
+}
+
+// ResolveAppeal handles the HTTP request for the handler to uphold or revise a disputed result.
+func (h *AppealHandler) ResolveAppeal(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var input services.AppealResolutionInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+
+	if err := h.service.ResolveAppeal(id, user.ID, &input); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "申诉已处理"})
+}
+
+// WithdrawAppeal handles the HTTP request for the appellant to withdraw a pending appeal.
+func (h *AppealHandler) WithdrawAppeal(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	// Optional: Get comment from request body
+	var input struct {
+		Comment string `json:"comment"`
+	}
+	c.ShouldBindJSON(&input) // No error check needed, comment is optional
+
+	if err := h.service.WithdrawAppeal(id, user.ID, input.Comment); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "申诉已撤回"})
+}
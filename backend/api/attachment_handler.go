@@ -0,0 +1,167 @@
+package api
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+
+	"cepm-backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AttachmentHandler struct {
+	service services.AttachmentService
+}
+
+func NewAttachmentHandler(service services.AttachmentService) *AttachmentHandler {
+	return &AttachmentHandler{service: service}
+}
+
+// UploadFile handles the multipart upload of an evidence file for a review item. The file is read from the "file" field.
+func (h *AttachmentHandler) UploadFile(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	itemID, ok := parseIDParam(c, "itemId")
+	if !ok {
+		return
+	}
+
+	// Allow some room over the file size limit for the multipart framing
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.service.MaxUploadBytes()+1<<20)
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid file upload: " + err.Error()})
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid file upload: " + err.Error()})
+		return
+	}
+	defer file.Close()
+
+	attachment, err := h.service.UploadFile(id, itemID, user, fileHeader.Filename, file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, attachment)
+}
+
+// AddLink handles the HTTP request to attach a link as evidence for a review item.
+func (h *AttachmentHandler) AddLink(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	itemID, ok := parseIDParam(c, "itemId")
+	if !ok {
+		return
+	}
+
+	var input services.LinkInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+
+	attachment, err := h.service.AddLink(id, itemID, user, &input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, attachment)
+}
+
+// ListAttachments handles the HTTP request to list the attachments of a review.
+// Optional query parameter: itemId.
+func (h *AttachmentHandler) ListAttachments(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var itemID *uint
+	if itemIdStr := c.Query("itemId"); itemIdStr != "" {
+		value, err := strconv.ParseUint(itemIdStr, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid itemId"})
+			return
+		}
+		parsed := uint(value)
+		itemID = &parsed
+	}
+
+	attachments, err := h.service.ListAttachments(id, itemID, user)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, attachments)
+}
+
+// DownloadAttachment handles the HTTP request to download an attachment. Links are redirected to their URL.
+func (h *AttachmentHandler) DownloadAttachment(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	attachment, reader, err := h.service.OpenAttachment(id, user)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if reader == nil {
+		c.Redirect(http.StatusFound, attachment.URL)
+		return
+	}
+	defer reader.Close()
+
+	disposition := mime.FormatMediaType("attachment", map[string]string{"filename": attachment.FileName})
+	c.DataFromReader(http.StatusOK, attachment.Size, attachment.ContentType, reader, map[string]string{
+		"Content-Disposition":    disposition,
+		"X-Content-Type-Options": "nosniff",
+	})
+}
+
+// DeleteAttachment handles the HTTP request to delete an attachment.
+func (h *AttachmentHandler) DeleteAttachment(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteAttachment(id, user); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "附件已删除"})
+}
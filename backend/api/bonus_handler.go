@@ -0,0 +1,234 @@
+package api
+
+import (
+	"io"
+	"net/http"
+
+	"cepm-backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type BonusHandler struct {
+	service services.BonusService
+}
+
+func NewBonusHandler(service services.BonusService) *BonusHandler {
+	return &BonusHandler{service: service}
+}
+
+// ListBases handles the HTTP request to list the configured bonus base amounts.
+func (h *BonusHandler) ListBases(c *gin.Context) {
+	bases, err := h.service.ListBases()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, bases)
+}
+
+// SetBases handles the HTTP request for HR to create or update base amounts per employee or grade band.
+func (h *BonusHandler) SetBases(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Bases []services.BonusBaseInput `json:"bases"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+
+	bases, err := h.service.SetBases(user.ID, input.Bases)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, bases)
+}
+
+// DeleteBase handles the HTTP request for HR to remove a base amount.
+func (h *BonusHandler) DeleteBase(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteBase(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "奖金基数已删除"})
+}
+
+// ImportBases handles the multipart upload of a csv or xlsx file of base amounts, read from the "file" field.
+// Optional form field: mode (dry_run by default, or commit).
+func (h *BonusHandler) ImportBases(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize+1<<20)
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid file upload: " + err.Error()})
+		return
+	}
+	if fileHeader.Size > maxImportSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "文件大小不能超过10MB"})
+		return
+	}
+	mode := c.DefaultPostForm("mode", "dry_run")
+	if mode != "dry_run" && mode != "commit" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid mode"})
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid file upload: " + err.Error()})
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid file upload: " + err.Error()})
+		return
+	}
+
+	result, err := h.service.ImportBases(user.ID, data, mode == "dry_run")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if mode == "commit" && !result.Committed {
+		c.JSON(http.StatusUnprocessableEntity, result)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// CreateRun handles the HTTP request for HR to calculate the bonuses of a payroll export batch.
+func (h *BonusHandler) CreateRun(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var input services.BonusRunInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+
+	run, err := h.service.CreateRun(user.ID, &input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, run)
+}
+
+// ListRuns handles the HTTP request to list bonus calculation runs, optionally filtered by period.
+func (h *BonusHandler) ListRuns(c *gin.Context) {
+	runs, err := h.service.ListRuns(c.Query("period"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, runs)
+}
+
+// GetRun handles the HTTP request to get a run with its budgets and lines.
+func (h *BonusHandler) GetRun(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	run, err := h.service.GetRun(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, run)
+}
+
+// ApproveRun handles the HTTP request to approve a run and lock its results.
+func (h *BonusHandler) ApproveRun(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	// Optional: Get comment from request body
+	var input struct {
+		Comment string `json:"comment"`
+	}
+	c.ShouldBindJSON(&input) // No error check needed, comment is optional
+
+	if err := h.service.ApproveRun(id, user.ID, input.Comment); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "奖金计算已批准"})
+}
+
+// RejectRun handles the HTTP request to reject a run with a reason.
+func (h *BonusHandler) RejectRun(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Comment string `json:"comment"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+
+	if err := h.service.RejectRun(id, user.ID, input.Comment); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "奖金计算已驳回"})
+}
+
+// ExportRun handles the HTTP request to download the lines of an approved run.
+// Optional query parameter: format (csv by default, or xlsx).
+func (h *BonusHandler) ExportRun(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	file, err := h.service.ExportRun(id, c.Query("format"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sendExportFile(c, file)
+}
@@ -0,0 +1,106 @@
+package api
+
+import (
+	"net/http"
+
+	"cepm-backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CalibrationHandler struct {
+	service services.CalibrationService
+}
+
+func NewCalibrationHandler(service services.CalibrationService) *CalibrationHandler {
+	return &CalibrationHandler{service: service}
+}
+
+// OpenSession handles the HTTP request for HR to open a calibration session.
+func (h *CalibrationHandler) OpenSession(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var input services.CalibrationSessionInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+
+	session, err := h.service.OpenSession(user.ID, &input)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, session)
+}
+
+// ListSessions handles the HTTP request to list calibration sessions, optionally filtered by period.
+func (h *CalibrationHandler) ListSessions(c *gin.Context) {
+	sessions, err := h.service.ListSessions(c.Query("period"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, sessions)
+}
+
+// GetReport handles the HTTP request to get a session's grade distribution against its quotas.
+func (h *CalibrationHandler) GetReport(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	report, err := h.service.GetReport(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// AdjustScore handles the HTTP request for HR to adjust a final score with a justification.
+func (h *CalibrationHandler) AdjustScore(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var input services.CalibrationAdjustmentInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+
+	if err := h.service.AdjustScore(id, user.ID, &input); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "分数已校准"})
+}
+
+// CloseSession handles the HTTP request to close a session and unlock its reviews.
+func (h *CalibrationHandler) CloseSession(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.service.CloseSession(id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "校准会话已结束"})
+}
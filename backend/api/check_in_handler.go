@@ -0,0 +1,107 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"cepm-backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CheckInHandler struct {
+	service services.CheckInService
+}
+
+func NewCheckInHandler(service services.CheckInService) *CheckInHandler {
+	return &CheckInHandler{service: service}
+}
+
+// CreateCheckIn handles the HTTP request for the employee to post progress on a review item.
+func (h *CheckInHandler) CreateCheckIn(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	itemID, ok := parseIDParam(c, "itemId")
+	if !ok {
+		return
+	}
+
+	var input services.CheckInInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+
+	checkIn, err := h.service.CreateCheckIn(id, itemID, user.ID, &input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, checkIn)
+}
+
+// ListCheckIns handles the HTTP request to list the check-ins of a review.
+// Optional query parameter: itemId.
+func (h *CheckInHandler) ListCheckIns(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var itemID *uint
+	if itemIdStr := c.Query("itemId"); itemIdStr != "" {
+		value, err := strconv.ParseUint(itemIdStr, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid itemId"})
+			return
+		}
+		parsed := uint(value)
+		itemID = &parsed
+	}
+
+	checkIns, err := h.service.ListCheckIns(id, itemID, user)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, checkIns)
+}
+
+// ReplyCheckIn handles the HTTP request for the manager to reply to a check-in.
+func (h *CheckInHandler) ReplyCheckIn(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Reply string `json:"reply"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+
+	if err := h.service.ReplyCheckIn(id, user, input.Reply); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "回复成功"})
+}
@@ -0,0 +1,141 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"cepm-backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CommentHandler struct {
+	service services.CommentService
+}
+
+func NewCommentHandler(service services.CommentService) *CommentHandler {
+	return &CommentHandler{service: service}
+}
+
+// CreateComment handles the HTTP request to comment on a review or one of its items.
+func (h *CommentHandler) CreateComment(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var input services.CommentInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+
+	comment, err := h.service.CreateComment(id, user, &input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, comment)
+}
+
+// ListComments handles the HTTP request to list the comment threads of a review.
+// Optional query parameter: itemId.
+func (h *CommentHandler) ListComments(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var itemID *uint
+	if itemIdStr := c.Query("itemId"); itemIdStr != "" {
+		value, err := strconv.ParseUint(itemIdStr, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid itemId"})
+			return
+		}
+		parsed := uint(value)
+		itemID = &parsed
+	}
+
+	threads, err := h.service.ListThreads(id, itemID, user)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, threads)
+}
+
+// UpdateComment handles the HTTP request to edit one of the current user's comments.
+func (h *CommentHandler) UpdateComment(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var input services.CommentUpdateInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+
+	comment, err := h.service.UpdateComment(id, user, &input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, comment)
+}
+
+// DeleteComment handles the HTTP request to delete a comment.
+func (h *CommentHandler) DeleteComment(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteComment(id, user); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "评论已删除"})
+}
+
+// GetEditHistory handles the HTTP request to list the previous versions of a comment.
+func (h *CommentHandler) GetEditHistory(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	edits, err := h.service.GetEditHistory(id, user)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, edits)
+}
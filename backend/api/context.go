@@ -0,0 +1,33 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"cepm-backend/middleware"
+	"cepm-backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// currentUser returns the user set by AuthMiddleware.
+// If no user is available it writes a 401 response and returns false.
+func currentUser(c *gin.Context) (*models.User, bool) {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized: User information not available"})
+		return nil, false
+	}
+	return user, true
+}
+
+// parseIDParam parses a numeric path parameter.
+// If the parameter is invalid it writes a 400 response and returns false.
+func parseIDParam(c *gin.Context, name string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param(name), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + name})
+		return 0, false
+	}
+	return uint(id), true
+}
@@ -0,0 +1,133 @@
+package api
+
+import (
+	"net/http"
+
+	"cepm-backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DefenseHandler struct {
+	service services.DefenseService
+}
+
+func NewDefenseHandler(service services.DefenseService) *DefenseHandler {
+	return &DefenseHandler{service: service}
+}
+
+// ScheduleSession handles the HTTP request for HR to schedule a 述职答辩 session.
+func (h *DefenseHandler) ScheduleSession(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var input services.DefenseSessionInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+
+	session, err := h.service.ScheduleSession(user.ID, &input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, session)
+}
+
+// ListSessions handles the HTTP request for HR to list defense sessions.
+// Optional query parameter: period.
+func (h *DefenseHandler) ListSessions(c *gin.Context) {
+	sessions, err := h.service.ListSessions(c.Query("period"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, sessions)
+}
+
+// ListPanelSessions handles the HTTP request to list the sessions the current user sits on the panel of.
+func (h *DefenseHandler) ListPanelSessions(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	sessions, err := h.service.ListPanelSessions(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, sessions)
+}
+
+// GetSession handles the HTTP request to get a defense session with its scores.
+func (h *DefenseHandler) GetSession(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	session, err := h.service.GetSession(id, user)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, session)
+}
+
+// SubmitScore handles the HTTP request for a panelist to score a candidate on the rubric.
+func (h *DefenseHandler) SubmitScore(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	candidateID, ok := parseIDParam(c, "candidateId")
+	if !ok {
+		return
+	}
+
+	var input services.DefenseScoreInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+
+	score, err := h.service.SubmitScore(id, candidateID, user.ID, &input)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, score)
+}
+
+// CloseSession handles the HTTP request for HR to close a session and publish the results.
+func (h *DefenseHandler) CloseSession(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	session, err := h.service.CloseSession(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, session)
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"cepm-backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DelegationHandler struct {
+	service services.DelegationService
+}
+
+func NewDelegationHandler(service services.DelegationService) *DelegationHandler {
+	return &DelegationHandler{service: service}
+}
+
+// CreateDelegation handles the HTTP request for the current user to delegate their approval rights.
+func (h *DelegationHandler) CreateDelegation(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var input services.DelegationInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+
+	delegation, err := h.service.CreateDelegation(user.ID, &input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, delegation)
+}
+
+// ListDelegations handles the HTTP request to list the delegations the current user granted and received.
+func (h *DelegationHandler) ListDelegations(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	delegations, err := h.service.ListDelegations(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, delegations)
+}
+
+// RevokeDelegation handles the HTTP request to revoke one of the current user's delegations.
+func (h *DelegationHandler) RevokeDelegation(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.service.RevokeDelegation(id, user.ID); err != nil {
+		if errors.Is(err, services.ErrDelegationForbidden) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "委托已撤销"})
+}
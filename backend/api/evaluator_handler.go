@@ -0,0 +1,82 @@
+package api
+
+import (
+	"net/http"
+
+	"cepm-backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type EvaluatorHandler struct {
+	service services.EvaluatorService
+}
+
+func NewEvaluatorHandler(service services.EvaluatorService) *EvaluatorHandler {
+	return &EvaluatorHandler{service: service}
+}
+
+// SetEvaluators handles the HTTP request to replace the evaluators of a review.
+// Evaluators then score through POST /reviews/:id/score as usual.
+func (h *EvaluatorHandler) SetEvaluators(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Evaluators []services.EvaluatorInput `json:"evaluators"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+
+	evaluators, err := h.service.SetEvaluators(id, user, input.Evaluators)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, evaluators)
+}
+
+// ListEvaluators handles the HTTP request to list the evaluators of a review.
+func (h *EvaluatorHandler) ListEvaluators(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	evaluators, err := h.service.ListEvaluators(id, user)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, evaluators)
+}
+
+// ListPendingEvaluations handles the HTTP request to list the reviews awaiting the current user's scores as an evaluator.
+func (h *EvaluatorHandler) ListPendingEvaluations(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	evaluations, err := h.service.ListPendingEvaluations(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, evaluations)
+}
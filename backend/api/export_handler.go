@@ -0,0 +1,101 @@
+package api
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+
+	"cepm-backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ExportHandler struct {
+	service services.ExportService
+}
+
+func NewExportHandler(service services.ExportService) *ExportHandler {
+	return &ExportHandler{service: service}
+}
+
+// ExportReviewWorkbook handles the HTTP request to download a review as a 月度绩效考核表 workbook.
+func (h *ExportHandler) ExportReviewWorkbook(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	file, err := h.service.ExportReviewWorkbook(id, user)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	sendExportFile(c, file)
+}
+
+// ExportDepartmentWorkbook handles the HTTP request for HR to download a department's reviews for a period
+// as one workbook. Required query parameters: departmentId, period.
+func (h *ExportHandler) ExportDepartmentWorkbook(c *gin.Context) {
+	departmentID, err := strconv.ParseUint(c.Query("departmentId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid departmentId"})
+		return
+	}
+
+	file, err := h.service.ExportDepartmentWorkbook(uint(departmentID), c.Query("period"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sendExportFile(c, file)
+}
+
+// ExportReviewPDF handles the HTTP request to download a review as a PDF with its approval trail.
+func (h *ExportHandler) ExportReviewPDF(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	file, err := h.service.ExportReviewPDF(id, user)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	sendExportFile(c, file)
+}
+
+// ExportDepartmentPDFs handles the HTTP request for HR to download a department's reviews for a period
+// as a zip of PDFs. Required query parameters: departmentId, period.
+func (h *ExportHandler) ExportDepartmentPDFs(c *gin.Context) {
+	departmentID, err := strconv.ParseUint(c.Query("departmentId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid departmentId"})
+		return
+	}
+
+	file, err := h.service.ExportDepartmentPDFs(uint(departmentID), c.Query("period"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sendExportFile(c, file)
+}
+
+// sendExportFile writes a generated document as a download.
+func sendExportFile(c *gin.Context, file *services.ExportFile) {
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": file.FileName}))
+	c.Data(http.StatusOK, file.ContentType, file.Data)
+}
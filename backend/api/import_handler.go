@@ -0,0 +1,69 @@
+package api
+
+import (
+	"io"
+	"net/http"
+
+	"cepm-backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxImportSize limits the size of an uploaded workbook.
+const maxImportSize = 10 << 20
+
+type ImportHandler struct {
+	service services.ImportService
+}
+
+func NewImportHandler(service services.ImportService) *ImportHandler {
+	return &ImportHandler{service: service}
+}
+
+// ImportWorkbook handles the multipart upload of a workbook of plans and scores, read from the "file" field.
+// Optional form fields: period (used when the workbook does not give one), mode (dry_run by default, or commit).
+func (h *ImportHandler) ImportWorkbook(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize+1<<20)
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid file upload: " + err.Error()})
+		return
+	}
+	if fileHeader.Size > maxImportSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "文件大小不能超过10MB"})
+		return
+	}
+	mode := c.DefaultPostForm("mode", "dry_run")
+	if mode != "dry_run" && mode != "commit" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid mode"})
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid file upload: " + err.Error()})
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid file upload: " + err.Error()})
+		return
+	}
+
+	result, err := h.service.ImportWorkbook(user.ID, data, c.PostForm("period"), mode == "dry_run")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if mode == "commit" && !result.Committed {
+		c.JSON(http.StatusUnprocessableEntity, result)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,204 @@
+package api
+
+import (
+	"net/http"
+
+	"cepm-backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ImprovementPlanHandler struct {
+	service services.ImprovementPlanService
+}
+
+func NewImprovementPlanHandler(service services.ImprovementPlanService) *ImprovementPlanHandler {
+	return &ImprovementPlanHandler{service: service}
+}
+
+// CreatePlan handles the HTTP request for HR to open an improvement plan manually.
+func (h *ImprovementPlanHandler) CreatePlan(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var input services.ImprovementPlanInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+
+	plan, err := h.service.CreatePlan(user.ID, &input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, plan)
+}
+
+// ListPlans handles the HTTP request to list the improvement plans visible to the current user.
+// Optional query parameter: status.
+func (h *ImprovementPlanHandler) ListPlans(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	plans, err := h.service.ListPlans(user, c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, plans)
+}
+
+// GetPlan handles the HTTP request to get an improvement plan with its goals, check-ins and history.
+func (h *ImprovementPlanHandler) GetPlan(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	plan, err := h.service.GetPlan(id, user)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, plan)
+}
+
+// UpdatePlan handles the HTTP request to edit a draft improvement plan.
+func (h *ImprovementPlanHandler) UpdatePlan(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var input services.ImprovementPlanInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+
+	plan, err := h.service.UpdatePlan(id, user, &input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, plan)
+}
+
+// StartPlan handles the HTTP request to start a draft improvement plan.
+func (h *ImprovementPlanHandler) StartPlan(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.service.StartPlan(id, user); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "改进计划已启动"})
+}
+
+// CompleteCheckIn handles the HTTP request to record the notes of a held check-in.
+func (h *ImprovementPlanHandler) CompleteCheckIn(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	checkInID, ok := parseIDParam(c, "checkInId")
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Notes string `json:"notes"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+
+	if err := h.service.CompleteCheckIn(id, checkInID, user, input.Notes); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "跟进记录已保存"})
+}
+
+// ClosePlan handles the HTTP request to record the outcome of an improvement plan.
+func (h *ImprovementPlanHandler) ClosePlan(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var input services.ImprovementPlanCloseInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+
+	if err := h.service.ClosePlan(id, user, &input); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "改进计划已结束"})
+}
+
+// CancelPlan handles the HTTP request to cancel an improvement plan.
+func (h *ImprovementPlanHandler) CancelPlan(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Comment string `json:"comment"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+
+	if err := h.service.CancelPlan(id, user, input.Comment); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "改进计划已取消"})
+}
@@ -0,0 +1,33 @@
+package api
+
+import (
+	"net/http"
+
+	"cepm-backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type InboxHandler struct {
+	service services.InboxService
+}
+
+func NewInboxHandler(service services.InboxService) *InboxHandler {
+	return &InboxHandler{service: service}
+}
+
+// GetInbox handles the HTTP request to list the current user's pending actions.
+func (h *InboxHandler) GetInbox(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	items, err := h.service.GetInbox(user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, items)
+}
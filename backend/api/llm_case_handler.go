@@ -0,0 +1,229 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"cepm-backend/repositories"
+	"cepm-backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LLMCaseHandler struct {
+	service services.LLMCaseService
+}
+
+func NewLLMCaseHandler(service services.LLMCaseService) *LLMCaseHandler {
+	return &LLMCaseHandler{service: service}
+}
+
+// CreateCase handles the HTTP request for the employee to attach a case to their 大模型 item.
+func (h *LLMCaseHandler) CreateCase(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	itemID, ok := parseIDParam(c, "itemId")
+	if !ok {
+		return
+	}
+
+	var input services.LLMCaseInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+
+	llmCase, err := h.service.CreateCase(id, itemID, user.ID, &input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, llmCase)
+}
+
+// ListReviewCases handles the HTTP request to list the cases attached to a review.
+func (h *LLMCaseHandler) ListReviewCases(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	cases, err := h.service.ListReviewCases(id, user)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, cases)
+}
+
+// SearchCases handles the HTTP request to search the case library.
+// Optional query parameters: q, tool, departmentId, period, sort (recent, likes, time_saved), page, pageSize.
+func (h *LLMCaseHandler) SearchCases(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	filter := repositories.LLMCaseFilter{
+		Keyword: c.Query("q"),
+		Tool:    c.Query("tool"),
+		Period:  c.Query("period"),
+		SortBy:  c.Query("sort"),
+	}
+	if departmentIdStr := c.Query("departmentId"); departmentIdStr != "" {
+		value, err := strconv.ParseUint(departmentIdStr, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid departmentId"})
+			return
+		}
+		departmentID := uint(value)
+		filter.DepartmentID = &departmentID
+	}
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid page"})
+		return
+	}
+	pageSize, err := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid pageSize"})
+		return
+	}
+	filter.Limit = pageSize
+	filter.Offset = (page - 1) * pageSize
+
+	result, err := h.service.SearchCases(&filter, user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetCase handles the HTTP request to get a single case from the library.
+func (h *LLMCaseHandler) GetCase(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	llmCase, err := h.service.GetCase(id, user)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, llmCase)
+}
+
+// UpdateCase handles the HTTP request for the author to update a case.
+func (h *LLMCaseHandler) UpdateCase(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var input services.LLMCaseInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+
+	llmCase, err := h.service.UpdateCase(id, user.ID, &input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, llmCase)
+}
+
+// DeleteCase handles the HTTP request for the author to delete a case.
+func (h *LLMCaseHandler) DeleteCase(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteCase(id, user.ID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "案例已删除"})
+}
+
+// LikeCase handles the HTTP request to like a case.
+func (h *LLMCaseHandler) LikeCase(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.service.LikeCase(id, user.ID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "点赞成功"})
+}
+
+// UnlikeCase handles the HTTP request to remove a like from a case.
+func (h *LLMCaseHandler) UnlikeCase(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.service.UnlikeCase(id, user.ID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "已取消点赞"})
+}
+
+// GetAdoptionReport handles the HTTP request for HR to view LLM adoption per department.
+// Optional query parameter: period.
+func (h *LLMCaseHandler) GetAdoptionReport(c *gin.Context) {
+	report, err := h.service.GetAdoptionReport(c.Query("period"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"net/http"
+
+	"cepm-backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type NormalizationHandler struct {
+	service services.NormalizationService
+}
+
+func NewNormalizationHandler(service services.NormalizationService) *NormalizationHandler {
+	return &NormalizationHandler{service: service}
+}
+
+// Preview handles the HTTP request to preview a period's normalized scores without saving them.
+func (h *NormalizationHandler) Preview(c *gin.Context) {
+	var input services.NormalizationInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+
+	preview, err := h.service.Preview(&input)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, preview)
+}
+
+// Apply handles the HTTP request to apply a normalization to a period.
+func (h *NormalizationHandler) Apply(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var input services.NormalizationInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+
+	run, err := h.service.Apply(&input, user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, run)
+}
+
+// ListRuns handles the HTTP request to list applied normalizations, optionally filtered by period.
+func (h *NormalizationHandler) ListRuns(c *gin.Context) {
+	runs, err := h.service.ListRuns(c.Query("period"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, runs)
+}
@@ -0,0 +1,101 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"cepm-backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PayrollHandler struct {
+	service services.PayrollService
+}
+
+func NewPayrollHandler(service services.PayrollService) *PayrollHandler {
+	return &PayrollHandler{service: service}
+}
+
+// Export handles the HTTP request for HR to export the coefficients of a period to payroll.
+// The file is returned directly; the X-Export-Batch-ID header identifies the recorded batch.
+func (h *PayrollHandler) Export(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var input services.PayrollExportInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+
+	export, err := h.service.Export(user.ID, &input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("X-Export-Batch-ID", strconv.FormatUint(uint64(export.Batch.ID), 10))
+	sendExportFile(c, export.File)
+}
+
+// ListBatches handles the HTTP request to list export batches, optionally filtered by period.
+func (h *PayrollHandler) ListBatches(c *gin.Context) {
+	batches, err := h.service.ListBatches(c.Query("period"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, batches)
+}
+
+// GetBatch handles the HTTP request to get an export batch with its lines.
+func (h *PayrollHandler) GetBatch(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	batch, err := h.service.GetBatch(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, batch)
+}
+
+// DownloadBatch handles the HTTP request to download the file of an export batch again.
+func (h *PayrollHandler) DownloadBatch(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	file, err := h.service.DownloadBatch(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	sendExportFile(c, file)
+}
+
+// ReconcileBatch handles the HTTP request to compare an export batch with the current results.
+func (h *PayrollHandler) ReconcileBatch(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	reconciliation, err := h.service.ReconcileBatch(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, reconciliation)
+}
@@ -0,0 +1,128 @@
+package api
+
+import (
+	"net/http"
+
+	"cepm-backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PeerFeedbackHandler struct {
+	service services.PeerFeedbackService
+}
+
+func NewPeerFeedbackHandler(service services.PeerFeedbackService) *PeerFeedbackHandler {
+	return &PeerFeedbackHandler{service: service}
+}
+
+// NominatePeers handles the HTTP request to invite peers to give feedback on a review.
+func (h *PeerFeedbackHandler) NominatePeers(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var input struct {
+		PeerIDs []uint `json:"peerIds"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+
+	nomination, err := h.service.NominatePeers(id, user, input.PeerIDs)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, nomination)
+}
+
+// GetSummary handles the HTTP request to get the aggregated peer feedback of a review.
+func (h *PeerFeedbackHandler) GetSummary(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	summary, err := h.service.GetSummary(id, user)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, summary)
+}
+
+// ListPendingRequests handles the HTTP request to list the feedback requests awaiting the current user,
+// together with the questions to answer for each 价值观 item.
+func (h *PeerFeedbackHandler) ListPendingRequests(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	requests, err := h.service.ListPendingRequests(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"questions": h.service.GetQuestions(), "requests": requests})
+}
+
+// SubmitFeedback handles the HTTP request for a peer to submit their feedback.
+func (h *PeerFeedbackHandler) SubmitFeedback(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Answers []services.PeerFeedbackAnswerInput `json:"answers"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+
+	if err := h.service.SubmitFeedback(id, user.ID, input.Answers); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "反馈已提交"})
+}
+
+// DeclineRequest handles the HTTP request for a peer to decline a feedback request.
+func (h *PeerFeedbackHandler) DeclineRequest(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeclineRequest(id, user.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "已拒绝反馈邀请"})
+}
@@ -0,0 +1,98 @@
+package api
+
+import (
+	"net/http"
+
+	"cepm-backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReviewCycleHandler struct {
+	service services.ReviewCycleService
+}
+
+func NewReviewCycleHandler(service services.ReviewCycleService) *ReviewCycleHandler {
+	return &ReviewCycleHandler{service: service}
+}
+
+// CreateCycle handles the HTTP request for HR to create a review cycle.
+func (h *ReviewCycleHandler) CreateCycle(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var input services.ReviewCycleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+
+	cycle, err := h.service.CreateCycle(user.ID, &input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, cycle)
+}
+
+// ListCycles handles the HTTP request to list review cycles.
+// Optional query parameter: includeClosed=true.
+func (h *ReviewCycleHandler) ListCycles(c *gin.Context) {
+	cycles, err := h.service.ListCycles(c.Query("includeClosed") == "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, cycles)
+}
+
+// ListMyCycles handles the HTTP request to list the open review cycles assigned to the current user.
+func (h *ReviewCycleHandler) ListMyCycles(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	cycles, err := h.service.ListCyclesForUser(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, cycles)
+}
+
+// GetCycle handles the HTTP request to get a single review cycle.
+func (h *ReviewCycleHandler) GetCycle(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	cycle, err := h.service.GetCycle(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, cycle)
+}
+
+// CloseCycle handles the HTTP request for HR to close a review cycle.
+func (h *ReviewCycleHandler) CloseCycle(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.service.CloseCycle(id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "考核周期已关闭"})
+}
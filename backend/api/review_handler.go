@@ -208,6 +208,11 @@ func (h *PerformanceReviewHandler) RejectPerformanceReview(c *gin.Context) {
 
 // ScorePerformanceReview handles the HTTP request to score a performance review.
 func (h *PerformanceReviewHandler) ScorePerformanceReview(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
 	// 1. Get review ID from URL parameter
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
@@ -224,7 +229,7 @@ func (h *PerformanceReviewHandler) ScorePerformanceReview(c *gin.Context) {
 	}
 
 	// 3. Call the service to perform the scoring
-	if err := h.service.ScorePerformanceReview(uint(id), &input); err != nil {
+	if err := h.service.ScorePerformanceReview(uint(id), user.ID, &input); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -335,4 +340,111 @@ func (h *PerformanceReviewHandler) UpdatePerformanceReview(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, review)
-}
\ No newline at end of file
+}
+
+// BatchApprovePerformanceReviews handles the HTTP request to approve several team reviews at once.
+func (h *PerformanceReviewHandler) BatchApprovePerformanceReviews(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var input services.BatchReviewInput
+	if err := c.ShouldBindJSON(&input); err != nil || len(input.ReviewIDs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "reviewIds is required"})
+		return
+	}
+
+	c.JSON(http.StatusOK, newBatchResponse(h.service.BatchApprovePerformanceReviews(&input, user.ID)))
+}
+
+// BatchRejectPerformanceReviews handles the HTTP request to reject several team reviews at once.
+func (h *PerformanceReviewHandler) BatchRejectPerformanceReviews(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var input services.BatchReviewInput
+	if err := c.ShouldBindJSON(&input); err != nil || len(input.ReviewIDs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "reviewIds is required"})
+		return
+	}
+
+	c.JSON(http.StatusOK, newBatchResponse(h.service.BatchRejectPerformanceReviews(&input, user.ID)))
+}
+
+// BatchScorePerformanceReviews handles the HTTP request to score several team reviews at once.
+func (h *PerformanceReviewHandler) BatchScorePerformanceReviews(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var input services.BatchScoreInput
+	if err := c.ShouldBindJSON(&input); err != nil || len(input.Reviews) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "reviews is required"})
+		return
+	}
+
+	c.JSON(http.StatusOK, newBatchResponse(h.service.BatchScorePerformanceReviews(&input, user.ID)))
+}
+
+// newBatchResponse wraps per-review batch results with success and failure counts.
+func newBatchResponse(results []services.BatchResult) gin.H {
+	successCount := 0
+	for _, result := range results {
+		if result.Success {
+			successCount++
+		}
+	}
+	return gin.H{
+		"results":      results,
+		"successCount": successCount,
+		"failureCount": len(results) - successCount,
+	}
+}
+
+// AcknowledgePerformanceReview handles the HTTP request for an employee to accept their final result.
+func (h *PerformanceReviewHandler) AcknowledgePerformanceReview(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.service.AcknowledgePerformanceReview(id, user.ID, c.ClientIP()); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "已确认考核结果"})
+}
+
+// HRConfirmPerformanceReview handles the HTTP request for HR to confirm and archive a final result.
+func (h *PerformanceReviewHandler) HRConfirmPerformanceReview(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	// Optional: Get comment from request body
+	var input struct {
+		Comment string `json:"comment"`
+	}
+	c.ShouldBindJSON(&input) // No error check needed, comment is optional
+
+	if err := h.service.HRConfirmPerformanceReview(id, user.ID, input.Comment); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "人事已确认考核结果"})
+}
@@ -0,0 +1,107 @@
+package api
+
+import (
+	"net/http"
+
+	"cepm-backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type UpwardFeedbackHandler struct {
+	service services.UpwardFeedbackService
+}
+
+func NewUpwardFeedbackHandler(service services.UpwardFeedbackService) *UpwardFeedbackHandler {
+	return &UpwardFeedbackHandler{service: service}
+}
+
+// requirePeriodQuery reads the required period query parameter, writing a 400 response if it is missing.
+func requirePeriodQuery(c *gin.Context) (string, bool) {
+	period := c.Query("period")
+	if period == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "period query parameter is required"})
+		return "", false
+	}
+	return period, true
+}
+
+// GetQuestionnaire handles the HTTP request to get the questionnaire for rating the current user's manager.
+// Required query parameter: period.
+func (h *UpwardFeedbackHandler) GetQuestionnaire(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	period, ok := requirePeriodQuery(c)
+	if !ok {
+		return
+	}
+
+	questionnaire, err := h.service.GetQuestionnaire(user.ID, period)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, questionnaire)
+}
+
+// SubmitFeedback handles the HTTP request to anonymously rate the current user's manager.
+func (h *UpwardFeedbackHandler) SubmitFeedback(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var input services.UpwardFeedbackInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+
+	if err := h.service.SubmitFeedback(user.ID, &input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "评价已匿名提交"})
+}
+
+// GetMyResult handles the HTTP request for a manager to view their own aggregated feedback.
+// Required query parameter: period.
+func (h *UpwardFeedbackHandler) GetMyResult(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	period, ok := requirePeriodQuery(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.service.GetManagerResult(user.ID, period)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetEffectivenessReport handles the HTTP request for HR to view the manager-effectiveness report.
+// Required query parameter: period.
+func (h *UpwardFeedbackHandler) GetEffectivenessReport(c *gin.Context) {
+	period, ok := requirePeriodQuery(c)
+	if !ok {
+		return
+	}
+
+	report, err := h.service.GetEffectivenessReport(period)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
@@ -7,10 +7,15 @@ import (
 )
 
 type Config struct {
-	Server   ServerConfig   `yaml:"server"`
-	Database DatabaseConfig `yaml:"database"`
-	Wechat   WechatConfig   `yaml:"wechat"`
-	JWT      JWTConfig      `yaml:"jwt"`
+	Server    ServerConfig    `yaml:"server"`
+	Database  DatabaseConfig  `yaml:"database"`
+	Wechat    WechatConfig    `yaml:"wechat"`
+	JWT       JWTConfig       `yaml:"jwt"`
+	Scheduler SchedulerConfig `yaml:"scheduler"`
+	Storage   StorageConfig   `yaml:"storage"`
+	PDF       PDFConfig       `yaml:"pdf"`
+
+	UpwardFeedback UpwardFeedbackConfig `yaml:"upward_feedback"`
 }
 
 type ServerConfig struct {
@@ -38,6 +43,35 @@ type JWTConfig struct {
 	ExpireHours int    `yaml:"expire_hours"`
 }
 
+type SchedulerConfig struct {
+	SLAScanIntervalMinutes int `yaml:"sla_scan_interval_minutes"`
+}
+
+type StorageConfig struct {
+	Driver           string   `yaml:"driver"` // local, s3
+	LocalPath        string   `yaml:"local_path"`
+	MaxUploadMB      int      `yaml:"max_upload_mb"`
+	AllowedMimeTypes []string `yaml:"allowed_mime_types"`
+	S3               S3Config `yaml:"s3"`
+}
+
+type S3Config struct {
+	Endpoint        string `yaml:"endpoint"`
+	Region          string `yaml:"region"`
+	Bucket          string `yaml:"bucket"`
+	AccessKeyID     string `yaml:"access_key_id"`
+	SecretAccessKey string `yaml:"secret_access_key"`
+}
+
+type PDFConfig struct {
+	FontPath string `yaml:"font_path"` // TrueType CJK font embedded into PDF exports
+}
+
+type UpwardFeedbackConfig struct {
+	// TokenSecret is the HMAC key for anonymous submission tokens; CEPM_UPWARD_FEEDBACK_SECRET overrides it
+	TokenSecret string `yaml:"token_secret"`
+}
+
 func LoadConfig(path string) (*Config, error) {
 	file, err := os.ReadFile(path)
 	if err != nil {
@@ -48,6 +82,9 @@ func LoadConfig(path string) (*Config, error) {
 	if err := yaml.Unmarshal(file, &cfg); err != nil {
 		return nil, err
 	}
+	if secret := os.Getenv("CEPM_UPWARD_FEEDBACK_SECRET"); secret != "" {
+		cfg.UpwardFeedback.TokenSecret = secret
+	}
 
 	return &cfg, nil
 }
@@ -2,6 +2,7 @@ package database
 
 import (
 	"log"
+	"time"
 
 	"cepm-backend/models"
 	"gorm.io/gorm"
@@ -50,10 +51,22 @@ func SeedData(db *gorm.DB) {
 
 	// 4. Create a sample performance review for Li Si
 	log.Println("Seeding performance review for Li Si...")
+	cycle := models.ReviewCycle{
+		Name:      "2025-07",
+		Code:      "2025-07",
+		CycleType: "monthly",
+		StartDate: time.Date(2025, 7, 1, 0, 0, 0, 0, time.Local),
+		EndDate:   time.Date(2025, 7, 31, 0, 0, 0, 0, time.Local),
+		ScopeType: "all",
+	}
+	if err := db.Where(models.ReviewCycle{Code: cycle.Code}).FirstOrCreate(&cycle).Error; err != nil {
+		log.Fatalf("failed to seed review cycle: %v", err)
+	}
 	review := models.PerformanceReview{
-		UserID: employeeLisi.ID,
-		Period: "2025-07",
-		Status: "待打分",
+		UserID:  employeeLisi.ID,
+		Period:  cycle.Code,
+		CycleID: &cycle.ID,
+		Status:  "待打分",
 		Items: []models.PerformanceItem{
 			{Category: "工作业绩", Title: "完成V2.0模块开发", Weight: 50, Target: "V2.0版本按时上线"},
 			{Category: "工作业绩", Title: "修复线上BUG", Weight: 30, Target: "BUG数量减少50%"},
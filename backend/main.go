@@ -3,13 +3,17 @@ package main
 import (
 	"fmt"
 	"log"
+	"time"
 
 	"cepm-backend/config"
 	"cepm-backend/database"
 	"cepm-backend/models"
+	"cepm-backend/pdf"
 	"cepm-backend/repositories"
 	"cepm-backend/router"
+	"cepm-backend/scheduler"
 	"cepm-backend/services"
+	"cepm-backend/storage"
 	"cepm-backend/wechat"
 
 	"github.com/gin-gonic/gin"
@@ -49,11 +53,46 @@ func main() {
 	// Initialize Auth Service
 	authService := services.NewAuthService(userRepo, wechatClient, &cfg.JWT)
 
+	// Initialize the notification service
+	notificationService := services.NewNotificationService(wechatClient)
+
+	// Initialize attachment storage
+	attachmentStorage, err := storage.New(&cfg.Storage)
+	if err != nil {
+		log.Fatalf("Failed to initialize storage: %v", err)
+	}
+	attachmentService := services.NewAttachmentService(repositories.NewAttachmentRepository(), repositories.NewPerformanceReviewRepository(), attachmentStorage, &cfg.Storage)
+
+	// Initialize anonymous upward feedback with the token key from the config
+	upwardFeedbackService, err := services.NewUpwardFeedbackService(repositories.NewUpwardFeedbackRepository(), repositories.NewReviewCycleRepository(), systemSettingService, []byte(cfg.UpwardFeedback.TokenSecret))
+	if err != nil {
+		log.Fatalf("Failed to initialize upward feedback: %v", err)
+	}
+
+	// Load the CJK font embedded into PDF exports; without it PDF exports fail, everything else keeps working
+	fontPath := cfg.PDF.FontPath
+	if fontPath == "" {
+		fontPath = "./fonts/NotoSansSC-Regular.ttf"
+	}
+	if err := pdf.UseFont(fontPath); err != nil {
+		log.Printf("Failed to load PDF font %s, PDF exports are disabled: %v", fontPath, err)
+	}
+
+	// Start the SLA scheduler for pending approvals
+	slaService := services.NewSLAService(repositories.NewPerformanceReviewRepository(), repositories.NewReminderRepository(), systemSettingService, notificationService)
+	slaInterval := time.Duration(cfg.Scheduler.SLAScanIntervalMinutes) * time.Minute
+	if slaInterval <= 0 {
+		slaInterval = time.Hour
+	}
+	slaScheduler := scheduler.NewSLAScheduler(slaService, slaInterval)
+	slaScheduler.Start()
+	defer slaScheduler.Stop()
+
 	// Set Gin mode
 	gin.SetMode(cfg.Server.Mode)
 
 	// Setup router
-	r := router.SetupRouter(userService, departmentService, systemSettingService, authService)
+	r := router.SetupRouter(userService, departmentService, systemSettingService, authService, notificationService, attachmentService, upwardFeedbackService)
 
 	// Start server
 	log.Printf("Server starting on port %s", cfg.Server.Port)
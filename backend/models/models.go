@@ -40,6 +40,8 @@ type User struct {
 	ManagerID     *uint
 	Manager       *User `gorm:"foreignKey:ManagerID"`
 	IsActive      bool  `gorm:"default:true"`
+	Position      string     // 职位, shown on the 月度绩效考核表
+	HireDate      *time.Time // 入职日期
 	CreatedAt     time.Time
 	UpdatedAt     time.Time
 }
@@ -47,9 +49,9 @@ type User struct {
 // PerformanceReview 月度绩效评估主表
 type PerformanceReview struct {
 	ID           uint      `gorm:"primaryKey"`
-	UserID       uint      `gorm:"not null;uniqueIndex:idx_user_period,priority:1"`
+	UserID       uint      `gorm:"not null;uniqueIndex:idx_user_cycle,priority:1"`
 	User         User      `gorm:"foreignKey:UserID"`
-	Period       string    `gorm:"not null;uniqueIndex:idx_user_period,priority:2"`
+	Period       string    `gorm:"not null;size:32;index"` // The cycle code, e.g. 2025-07 or 2025-Q3
 	Status       string    `gorm:"not null;default:'Draft'"` // Status: Draft, PendingApproval, Approved, PendingScore, Completed, PendingHRConfirmation, Archived, Rejected
 	TotalScore   *float64  `gorm:"type:numeric(5,2)"`
 	GradePoint   *float64  `gorm:"type:numeric(5,2)"` // New field: Performance Grade Point
@@ -58,6 +60,35 @@ type PerformanceReview struct {
 	Approvals    []ApprovalHistory `gorm:"foreignKey:ReviewID"`
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
+
+	// Result acknowledgement and HR confirmation
+	ScoredAt         *time.Time // When the review was last scored
+	AcknowledgedAt   *time.Time // When the employee accepted the result
+	AcknowledgedIP   string
+	AutoAcknowledged bool // True if HR confirmed after the auto-acknowledge timeout without an employee confirmation
+	HRConfirmedAt    *time.Time
+	HRConfirmedByID  *uint
+	HRConfirmedBy    *User `gorm:"foreignKey:HRConfirmedByID"`
+
+	// Set once an appeal against the result has been decided; a result can only be appealed once
+	AppealResolvedAt *time.Time
+
+	// Set while the review is locked by an open HR calibration session
+	CalibrationSessionID *uint `gorm:"index"`
+	// The HR calibration adjustment relative to the computed score, re-applied whenever the score is recomputed
+	CalibrationDelta *float64 `gorm:"type:numeric(5,2)"`
+
+	// Cross-department normalization; TotalScore keeps the raw score
+	NormalizedScore    *float64 `gorm:"type:numeric(5,2)"`
+	NormalizationRunID *uint
+
+	// The review cycle this review belongs to; Period holds the cycle's code
+	CycleID *uint        `gorm:"not null;index;uniqueIndex:idx_user_cycle,priority:2"`
+	Cycle   *ReviewCycle `gorm:"foreignKey:CycleID"`
+
+	// 被考核人述职答辩成绩, attached when the defense session closes
+	DefenseScore       *float64 `gorm:"type:numeric(5,2)"`
+	DefenseCandidateID *uint
 }
 
 // PerformanceItem 绩效评估项表
@@ -71,19 +102,45 @@ type PerformanceItem struct {
 	Target             string
 	CompletionDetails  string
 	Score              *float64 `gorm:"type:numeric(5,2)"`
+	PeerSuggestedScore *float64 `gorm:"type:numeric(5,2)"` // 价值观 items: aggregated 360 peer feedback, which the manager can accept or override
+	LatestProgress     *int       // Copied from the latest ItemCheckIn for the team view
+	LatestRAGStatus    string     // red, amber, green
+	LatestCheckInAt    *time.Time
 	CreatedAt          time.Time
 	UpdatedAt          time.Time
 }
 
 // ApprovalHistory 审批流转历史表
 type ApprovalHistory struct {
-	ID         uint   `gorm:"primaryKey"`
-	ReviewID   uint   `gorm:"not null"`
-	ApproverID uint   `gorm:"not null"`
-	Approver   User   `gorm:"foreignKey:ApproverID"`
-	Status     string `gorm:"not null"`
-	Comment    string
-	CreatedAt  time.Time
+	ID           uint   `gorm:"primaryKey"`
+	ReviewID     uint   `gorm:"not null"`
+	ApproverID   uint   `gorm:"not null"` // The user who actually performed the action
+	Approver     User   `gorm:"foreignKey:ApproverID"`
+	OnBehalfOfID *uint  // Set when the approver acted as a delegate for another manager
+	OnBehalfOf   *User  `gorm:"foreignKey:OnBehalfOfID"`
+	Status       string `gorm:"not null"`
+	Comment      string
+	CreatedAt    time.Time
+}
+
+// ApprovalDelegation 审批委托表
+// A delegator (usually a 组长 on leave) hands their approval rights to a delegate for a date range.
+type ApprovalDelegation struct {
+	ID           uint        `gorm:"primaryKey"`
+	DelegatorID  uint        `gorm:"not null;index"`
+	Delegator    User        `gorm:"foreignKey:DelegatorID"`
+	DelegateID   uint        `gorm:"not null;index"`
+	Delegate     User        `gorm:"foreignKey:DelegateID"`
+	StartDate    time.Time   `gorm:"not null"`
+	EndDate      time.Time   `gorm:"not null"`
+	ScopeType    string      `gorm:"not null;default:'all'"` // all, department, users
+	DepartmentID *uint       // Only used when ScopeType is "department"
+	Department   *Department `gorm:"foreignKey:DepartmentID"`
+	Reports      []User      `gorm:"many2many:approval_delegation_reports;"` // Only used when ScopeType is "users"
+	Reason       string
+	IsRevoked    bool `gorm:"default:false"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
 }
 
 // SystemSetting 系统设置表
@@ -95,7 +152,552 @@ type SystemSetting struct {
 	UpdatedAt time.Time
 }
 
+// ReviewReminder 绩效超时提醒记录表
+// Records SLA reminders sent for a review so the scheduler does not send them twice.
+type ReviewReminder struct {
+	ID           uint   `gorm:"primaryKey"`
+	ReviewID     uint   `gorm:"not null;index"`
+	ReviewStatus string `gorm:"not null"` // The review status the reminder was sent for, e.g. 待审批
+	Type         string `gorm:"not null"` // reminder, escalation
+	RecipientID  uint   `gorm:"not null"`
+	Recipient    User   `gorm:"foreignKey:RecipientID"`
+	CreatedAt    time.Time
+}
+
+// Appeal 绩效申诉表
+// An employee's formal dispute of a scored result. Its timeline is kept in AppealEvent,
+// separate from the review's ApprovalHistory.
+type Appeal struct {
+	ID          uint              `gorm:"primaryKey"`
+	ReviewID    uint              `gorm:"not null;index"`
+	Review      PerformanceReview `gorm:"foreignKey:ReviewID"`
+	AppellantID uint              `gorm:"not null;index"`
+	Appellant   User              `gorm:"foreignKey:AppellantID"`
+	HandlerID   uint              `gorm:"not null;index"` // HR or the skip-level manager
+	Handler     User              `gorm:"foreignKey:HandlerID"`
+	Reason      string            `gorm:"not null"`
+	Status      string            `gorm:"not null;default:'待处理'"` // 待处理, 维持原分, 已改分, 已撤回
+	Resolution  string
+	ResolvedAt  *time.Time
+	Items       []AppealItem  `gorm:"foreignKey:AppealID"`
+	Events      []AppealEvent `gorm:"foreignKey:AppealID"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// AppealItem 申诉项表
+type AppealItem struct {
+	ID                uint            `gorm:"primaryKey"`
+	AppealID          uint            `gorm:"not null;index"`
+	PerformanceItemID uint            `gorm:"not null"`
+	PerformanceItem   PerformanceItem `gorm:"foreignKey:PerformanceItemID"`
+	Reason            string
+	OriginalScore     *float64 `gorm:"type:numeric(5,2)"`
+	RevisedScore      *float64 `gorm:"type:numeric(5,2)"`
+}
+
+// AppealEvent 申诉处理时间线表
+type AppealEvent struct {
+	ID        uint   `gorm:"primaryKey"`
+	AppealID  uint   `gorm:"not null;index"`
+	ActorID   uint   `gorm:"not null"`
+	Actor     User   `gorm:"foreignKey:ActorID"`
+	Status    string `gorm:"not null"`
+	Comment   string
+	CreatedAt time.Time
+}
+
+// CalibrationSession 绩效校准会话表
+// While a session is open, the reviews it covers are locked against other edits.
+type CalibrationSession struct {
+	ID           uint       `gorm:"primaryKey"`
+	Period       string     `gorm:"not null;index"`
+	DepartmentID uint       `gorm:"not null"` // Root of the department subtree being calibrated
+	Department   Department `gorm:"foreignKey:DepartmentID"`
+	Status       string     `gorm:"not null;default:'进行中'"` // 进行中, 已结束
+	Quotas       string     `gorm:"type:text"`              // JSON-encoded grade band quotas, copied from settings when the session opens
+	CreatedByID  uint       `gorm:"not null"`
+	CreatedBy    User       `gorm:"foreignKey:CreatedByID"`
+	ClosedAt     *time.Time
+	Adjustments  []CalibrationAdjustment `gorm:"foreignKey:SessionID"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// CalibrationAdjustment 校准调整审计表
+type CalibrationAdjustment struct {
+	ID                 uint     `gorm:"primaryKey"`
+	SessionID          uint     `gorm:"not null;index"`
+	ReviewID           uint     `gorm:"not null;index"`
+	AdjusterID         uint     `gorm:"not null"`
+	Adjuster           User     `gorm:"foreignKey:AdjusterID"`
+	OriginalScore      *float64 `gorm:"type:numeric(5,2)"`
+	AdjustedScore      float64  `gorm:"not null;type:numeric(5,2)"`
+	OriginalGradePoint *float64 `gorm:"type:numeric(5,2)"`
+	AdjustedGradePoint float64  `gorm:"not null;type:numeric(5,2)"`
+	ScoreDelta         float64  `gorm:"not null;default:0;type:numeric(5,2)"` // AdjustedScore minus the score computed from the review's items
+	Justification      string   `gorm:"not null"`
+	CreatedAt          time.Time
+}
+
+// NormalizationRun 跨部门分数标准化记录表
+// Each applied normalization of a period is recorded here; reviews point at the run that set their NormalizedScore.
+type NormalizationRun struct {
+	ID          uint   `gorm:"primaryKey"`
+	Period      string `gorm:"not null;index"`
+	Method      string `gorm:"not null"` // zscore, percentile
+	ScoreSource string `gorm:"not null"` // The coefficient score source in effect when applied: raw, normalized
+	ReviewCount int
+	Parameters  string `gorm:"type:jsonb"` // The distributions the scores were mapped with, so a recomputed score can be normalized the same way
+	AppliedByID uint `gorm:"not null"`
+	AppliedBy   User `gorm:"foreignKey:AppliedByID"`
+	CreatedAt   time.Time
+}
+
+// AnnualReview 年度绩效汇总表
+// Aggregates an employee's monthly results for a year and combines them with the annual evaluation.
+type AnnualReview struct {
+	ID                   uint     `gorm:"primaryKey"`
+	UserID               uint     `gorm:"not null;uniqueIndex:idx_user_year,priority:1"`
+	User                 User     `gorm:"foreignKey:UserID"`
+	Year                 int      `gorm:"not null;uniqueIndex:idx_user_year,priority:2"`
+	MonthCount           int      // Number of scored monthly reviews in the year
+	AverageScore         *float64 `gorm:"type:numeric(5,2)"`
+	WeightedAverageScore *float64 `gorm:"type:numeric(5,2)"`
+	Trend                *float64 `gorm:"type:numeric(6,3)"` // Least-squares slope of the monthly scores, in points per month
+	EvaluationScore      *float64 `gorm:"type:numeric(5,2)"` // 年度考核结果
+	EvaluationComment    string
+	EvaluatedByID        *uint
+	EvaluatedBy          *User    `gorm:"foreignKey:EvaluatedByID"`
+	AnnualScore          *float64 `gorm:"type:numeric(5,2)"`
+	AnnualGrade          string
+	CreatedAt            time.Time
+	UpdatedAt            time.Time
+}
+
+// ReviewCycle 考核周期表
+// Every review belongs to a cycle and uses its Code as its Period; reviews are unique per (user, cycle).
+type ReviewCycle struct {
+	ID           uint      `gorm:"primaryKey"`
+	Name         string    `gorm:"not null"`
+	Code         string    `gorm:"not null;size:32;uniqueIndex"` // e.g. 2025-07, 2025-Q3, 2025-H1, or a custom code for probation/custom cycles
+	CycleType    string    `gorm:"not null"`                     // monthly, quarterly, half_year, probation, custom
+	StartDate    time.Time `gorm:"type:date;not null"`
+	EndDate      time.Time `gorm:"type:date;not null"`
+	ScopeType    string    `gorm:"not null;default:'all'"` // all, department, users
+	DepartmentID *uint     // Set when ScopeType is department; covers its sub-departments too
+	Department   *Department
+	Users        []User `gorm:"many2many:review_cycle_users;"` // Set when ScopeType is users
+	IsClosed     bool   `gorm:"default:false"`
+	CreatedByID  *uint
+	CreatedBy    *User `gorm:"foreignKey:CreatedByID"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// DefenseSession 述职答辩场次表
+type DefenseSession struct {
+	ID          uint      `gorm:"primaryKey"`
+	Title       string    `gorm:"not null"`
+	Period      string    `gorm:"not null;size:32;index"` // The review cycle code the results are attached to
+	ScheduledAt time.Time `gorm:"not null"`
+	Location    string
+	Rubric      string             `gorm:"type:text"`              // JSON-encoded rubric criteria, copied from settings when the session is scheduled
+	Status      string             `gorm:"not null;default:'待答辩'"` // 待答辩, 已结束
+	Panelists   []User             `gorm:"many2many:defense_session_panelists;"`
+	Candidates  []DefenseCandidate `gorm:"foreignKey:SessionID"`
+	CreatedByID uint               `gorm:"not null"`
+	CreatedBy   User               `gorm:"foreignKey:CreatedByID"`
+	ClosedAt    *time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// DefenseCandidate 述职答辩人表
+type DefenseCandidate struct {
+	ID         uint           `gorm:"primaryKey"`
+	SessionID  uint           `gorm:"not null;uniqueIndex:idx_session_candidate,priority:1"`
+	UserID     uint           `gorm:"not null;uniqueIndex:idx_session_candidate,priority:2"`
+	User       User           `gorm:"foreignKey:UserID"`
+	FinalScore *float64       `gorm:"type:numeric(5,2)"` // Trimmed mean of the panel scores, set when the session closes
+	Scores     []DefenseScore `gorm:"foreignKey:CandidateID"`
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// DefenseScore 答辩评委评分表
+type DefenseScore struct {
+	ID             uint    `gorm:"primaryKey"`
+	CandidateID    uint    `gorm:"not null;uniqueIndex:idx_candidate_panelist,priority:1"`
+	PanelistID     uint    `gorm:"not null;uniqueIndex:idx_candidate_panelist,priority:2"`
+	Panelist       User    `gorm:"foreignKey:PanelistID"`
+	CriteriaScores string  `gorm:"type:text"` // JSON-encoded score per rubric criterion
+	TotalScore     float64 `gorm:"not null;type:numeric(5,2)"`
+	Comment        string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// ReviewEvaluator 多考核人表
+// When a review has evaluators, each scores the items independently and the final item score
+// is the weighted combination of their scores.
+type ReviewEvaluator struct {
+	ID           uint               `gorm:"primaryKey"`
+	ReviewID     uint               `gorm:"not null;uniqueIndex:idx_review_evaluator,priority:1"`
+	Review       *PerformanceReview `gorm:"foreignKey:ReviewID"`
+	EvaluatorID  uint               `gorm:"not null;uniqueIndex:idx_review_evaluator,priority:2"`
+	Evaluator    User               `gorm:"foreignKey:EvaluatorID"`
+	Role         string             // e.g. 直属上级, 项目负责人
+	Weight       float64            `gorm:"not null;type:numeric(5,2)"` // Percentage; the weights of a review sum to 100
+	FinalComment string
+	SubmittedAt  *time.Time
+	Scores       []EvaluatorItemScore `gorm:"foreignKey:ReviewEvaluatorID"`
+	AssignedByID uint                 `gorm:"not null"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// EvaluatorItemScore 考核人单项评分表
+type EvaluatorItemScore struct {
+	ID                uint    `gorm:"primaryKey"`
+	ReviewEvaluatorID uint    `gorm:"not null;uniqueIndex:idx_evaluator_item,priority:1"`
+	ItemID            uint    `gorm:"not null;uniqueIndex:idx_evaluator_item,priority:2"`
+	Score             float64 `gorm:"not null;type:numeric(5,2)"`
+	CompletionDetails string
+	CreatedAt         time.Time
+}
+
+// PeerFeedbackRequest 360度同事反馈邀请表
+type PeerFeedbackRequest struct {
+	ID            uint                 `gorm:"primaryKey"`
+	ReviewID      uint                 `gorm:"not null;uniqueIndex:idx_review_peer,priority:1"`
+	Review        *PerformanceReview   `gorm:"foreignKey:ReviewID"`
+	PeerID        uint                 `gorm:"not null;uniqueIndex:idx_review_peer,priority:2"`
+	Peer          User                 `gorm:"foreignKey:PeerID"`
+	NominatedByID uint                 `gorm:"not null"`
+	Status        string               `gorm:"not null;default:'待填写'"` // 待填写, 已提交, 已拒绝
+	Answers       []PeerFeedbackAnswer `gorm:"foreignKey:RequestID"`
+	SubmittedAt   *time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// PeerFeedbackAnswer 同事反馈答案表
+// One rating per 价值观 item and question.
+type PeerFeedbackAnswer struct {
+	ID        uint   `gorm:"primaryKey"`
+	RequestID uint   `gorm:"not null;index"`
+	ItemID    uint   `gorm:"not null"` // The 价值观 PerformanceItem
+	Question  string `gorm:"not null"`
+	Rating    int    `gorm:"not null"` // 1-5
+	Comment   string
+}
+
+// UpwardFeedbackToken 上级评价提交凭证表
+// Marks that someone has rated a manager for a period without recording who: the token is a
+// one-way HMAC of the respondent, manager and period. It has no sequential ID, no timestamps and
+// no link to the response, so responses cannot be traced back to respondents.
+type UpwardFeedbackToken struct {
+	Token     string `gorm:"primaryKey;size:64"`
+	ManagerID uint   `gorm:"not null;index"`
+	Period    string `gorm:"not null;size:32"`
+}
+
+// UpwardFeedbackResponse 上级匿名评价表
+// Deliberately stores no respondent and no timestamp.
+type UpwardFeedbackResponse struct {
+	ID        uint   `gorm:"primaryKey"`
+	ManagerID uint   `gorm:"not null;index:idx_manager_period,priority:1"`
+	Period    string `gorm:"not null;size:32;index:idx_manager_period,priority:2"`
+	Comment   string
+	Answers   []UpwardFeedbackAnswer `gorm:"foreignKey:ResponseID"`
+}
+
+// UpwardFeedbackAnswer 上级匿名评价答案表
+type UpwardFeedbackAnswer struct {
+	ID         uint   `gorm:"primaryKey"`
+	ResponseID uint   `gorm:"not null;index"`
+	Question   string `gorm:"not null"`
+	Rating     int    `gorm:"not null"` // 1-5
+}
+
+// UpwardFeedbackResult 上级评价结果快照表
+// The released result of a manager for a closed period, frozen when the period's results are first
+// requested so later team changes cannot alter them.
+type UpwardFeedbackResult struct {
+	ID        uint   `gorm:"primaryKey"`
+	ManagerID uint   `gorm:"not null;uniqueIndex:idx_upward_result,priority:1"`
+	Period    string `gorm:"not null;size:32;uniqueIndex:idx_upward_result,priority:2"`
+	Result    string `gorm:"type:jsonb;not null"` // The aggregated result as released
+	CreatedAt time.Time
+}
+
+// ImprovementPlan 绩效改进计划(PIP)表
+// Opened as a draft automatically when a scored review meets the PIP trigger rule, or manually by HR.
+type ImprovementPlan struct {
+	ID             uint                   `gorm:"primaryKey"`
+	UserID         uint                   `gorm:"not null;index"`
+	User           User                   `gorm:"foreignKey:UserID"`
+	OwnerID        uint                   `gorm:"not null;index"` // The manager responsible for the plan
+	Owner          User                   `gorm:"foreignKey:OwnerID"`
+	Status         string                 `gorm:"not null;default:'草稿'"` // 草稿, 进行中, 已通过, 未通过, 已取消
+	TriggerReason  string                 // unqualified, consecutive_qualified, manual
+	StartDate      *time.Time             `gorm:"type:date"`
+	EndDate        *time.Time             `gorm:"type:date"`
+	OutcomeComment string                 // Set when the plan is closed
+	TriggerReviews []PerformanceReview    `gorm:"many2many:improvement_plan_reviews;"`
+	Goals          []ImprovementGoal      `gorm:"foreignKey:PlanID"`
+	CheckIns       []ImprovementCheckIn   `gorm:"foreignKey:PlanID"`
+	Events         []ImprovementPlanEvent `gorm:"foreignKey:PlanID"`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// ImprovementGoal 改进目标表
+type ImprovementGoal struct {
+	ID              uint   `gorm:"primaryKey"`
+	PlanID          uint   `gorm:"not null;index"`
+	Description     string `gorm:"not null"`
+	SuccessCriteria string
+	IsMet           *bool // Set when the plan is closed
+}
+
+// ImprovementCheckIn 改进计划跟进表
+type ImprovementCheckIn struct {
+	ID            uint      `gorm:"primaryKey"`
+	PlanID        uint      `gorm:"not null;index"`
+	ScheduledDate time.Time `gorm:"type:date;not null"`
+	CompletedAt   *time.Time
+	Notes         string
+}
+
+// ImprovementPlanEvent 改进计划状态历史表
+type ImprovementPlanEvent struct {
+	ID        uint   `gorm:"primaryKey"`
+	PlanID    uint   `gorm:"not null;index"`
+	ActorID   *uint  // Nil for events raised by the system, such as the automatic trigger
+	Actor     *User  `gorm:"foreignKey:ActorID"`
+	ReviewID  *uint  // The triggering review, for trigger events
+	Status    string `gorm:"not null"`
+	Comment   string
+	CreatedAt time.Time
+}
+
+// ItemCheckIn 绩效项进度更新表
+// Mid-period progress updates posted by the employee while a review is 待打分.
+type ItemCheckIn struct {
+	ID              uint      `gorm:"primaryKey"`
+	ReviewID        uint      `gorm:"not null;index"`
+	ItemID          uint      `gorm:"not null;index"`
+	AuthorID        uint      `gorm:"not null"`
+	Author          User      `gorm:"foreignKey:AuthorID"`
+	CheckInDate     time.Time `gorm:"type:date;not null"`
+	PercentComplete int       `gorm:"not null"`
+	RAGStatus       string    `gorm:"not null"` // red, amber, green
+	Note            string
+	ManagerReply    string
+	RepliedByID     *uint
+	RepliedBy       *User `gorm:"foreignKey:RepliedByID"`
+	RepliedAt       *time.Time
+	CreatedAt       time.Time
+}
+
+// ReviewComment 绩效评论表
+// Threaded discussion on a review or one of its items. Deleted comments are kept (DeletedAt)
+// so their replies stay in place.
+type ReviewComment struct {
+	ID          uint                `gorm:"primaryKey"`
+	ReviewID    uint                `gorm:"not null;index"`
+	ItemID      *uint               `gorm:"index"` // Set when the comment is on a specific PerformanceItem
+	ParentID    *uint               `gorm:"index"` // Set for replies
+	AuthorID    uint                `gorm:"not null"`
+	Author      User                `gorm:"foreignKey:AuthorID"`
+	Content     string              `gorm:"type:text;not null"`
+	Visibility  string              `gorm:"not null;default:'shared'"` // shared (with the employee), private (managers and HR only)
+	Mentions    []User              `gorm:"many2many:review_comment_mentions;"`
+	Edits       []ReviewCommentEdit `gorm:"foreignKey:CommentID"`
+	EditedAt    *time.Time
+	DeletedAt   *time.Time
+	DeletedByID *uint
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// ReviewCommentEdit 评论修改历史表
+type ReviewCommentEdit struct {
+	ID              uint   `gorm:"primaryKey"`
+	CommentID       uint   `gorm:"not null;index"`
+	PreviousContent string `gorm:"type:text"`
+	EditedByID      uint   `gorm:"not null"`
+	CreatedAt       time.Time
+}
+
+// Attachment 完成情况佐证附件表
+type Attachment struct {
+	ID           uint   `gorm:"primaryKey"`
+	ReviewID     uint   `gorm:"not null;index"`
+	ItemID       uint   `gorm:"not null;index"`
+	Kind         string `gorm:"not null"` // file, link
+	FileName     string
+	ContentType  string
+	Size         int64
+	Checksum     string `gorm:"size:64;index"` // SHA-256 of the file content; files with the same checksum share one stored blob
+	StorageKey   string
+	URL          string `gorm:"type:text"` // Set for links
+	UploadedByID uint   `gorm:"not null"`
+	UploadedBy   User   `gorm:"foreignKey:UploadedByID"`
+	CreatedAt    time.Time
+}
+
+// LLMCase 大模型应用案例表
+type LLMCase struct {
+	ID             uint       `gorm:"primaryKey"`
+	ReviewID       uint       `gorm:"not null;index"`
+	ItemID         uint       `gorm:"not null;index"` // The 大模型 PerformanceItem the case supports
+	AuthorID       uint       `gorm:"not null;index"`
+	Author         User       `gorm:"foreignKey:AuthorID"`
+	DepartmentID   *uint      `gorm:"index"` // The author's department when the case was recorded, for adoption statistics
+	Department     Department `gorm:"foreignKey:DepartmentID"`
+	Period         string     `gorm:"size:32;index"` // Copied from the review
+	Scenario       string     `gorm:"type:text;not null"`
+	Tool           string     `gorm:"not null;index"`
+	TimeSavedHours float64    `gorm:"type:numeric(7,2);default:0"`
+	Outcome        string     `gorm:"type:text"`
+	Reflection     string     `gorm:"type:text"`
+	LikeCount      int        `gorm:"not null;default:0"`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// LLMCaseLike 大模型案例点赞表
+type LLMCaseLike struct {
+	CaseID    uint `gorm:"primaryKey"`
+	UserID    uint `gorm:"primaryKey"`
+	CreatedAt time.Time
+}
+
+// PayrollExportBatch 薪酬系数导出批次
+// An immutable record of one export of the 月度考核系数 to payroll, kept so that re-exports can be reconciled.
+type PayrollExportBatch struct {
+	ID           uint                `gorm:"primaryKey"`
+	Period       string              `gorm:"not null;index"`
+	DepartmentID *uint               // Root of the exported department subtree; nil for the whole company
+	Department   *Department         `gorm:"foreignKey:DepartmentID"`
+	Format       string              `gorm:"not null"`           // csv, xlsx
+	Columns      string              `gorm:"type:text;not null"` // JSON-encoded column keys in export order
+	RowCount     int                 `gorm:"not null"`
+	Checksum     string              `gorm:"size:64;not null"` // SHA-256 of the exported file
+	Content      []byte              `gorm:"type:bytea;not null" json:"-"` // The exported file, served again on download
+	ExportedByID uint                `gorm:"not null"`
+	ExportedBy   User                `gorm:"foreignKey:ExportedByID"`
+	Lines        []PayrollExportLine `gorm:"foreignKey:BatchID"`
+	CreatedAt    time.Time
+}
+
+// PayrollExportLine 薪酬系数导出明细
+// A snapshot of one exported review as it was at export time.
+type PayrollExportLine struct {
+	ID             uint   `gorm:"primaryKey"`
+	BatchID        uint   `gorm:"not null;index"`
+	ReviewID       uint   `gorm:"not null;index"`
+	UserID         uint   `gorm:"not null"`
+	EmployeeName   string `gorm:"not null"`
+	WechatUserid   string
+	DepartmentID   *uint
+	DepartmentName string
+	Period         string    `gorm:"not null"`
+	TotalScore     float64   `gorm:"type:numeric(5,2);not null"`
+	GradeBand      string    `gorm:"not null"`
+	GradePoint     float64   `gorm:"type:numeric(5,2);not null"`
+	HRConfirmedAt  time.Time `gorm:"not null"`
+}
+
+// BonusBase 奖金基数
+// A base amount configured by HR, either for one employee or for every employee of a grade band.
+// An employee's own base takes precedence over the base of their grade band.
+type BonusBase struct {
+	ID          uint    `gorm:"primaryKey"`
+	UserID      *uint   `gorm:"uniqueIndex"`
+	User        *User   `gorm:"foreignKey:UserID"`
+	GradeBand   *string `gorm:"uniqueIndex"` // 优秀, 良好, 一般, 合格, 不合格
+	Amount      float64 `gorm:"type:numeric(12,2);not null"`
+	UpdatedByID uint    `gorm:"not null"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// BonusRun 奖金计算批次
+// A bonus calculation for a period from the coefficients of a payroll export batch. Its lines are
+// locked once the run is approved.
+type BonusRun struct {
+	ID             uint               `gorm:"primaryKey"`
+	Period         string             `gorm:"not null;index"`
+	PayrollBatchID uint               `gorm:"not null;index"` // The payroll export whose coefficients the run uses
+	PayrollBatch   PayrollExportBatch `gorm:"foreignKey:PayrollBatchID"`
+	Status         string             `gorm:"not null;default:'待审批'"` // 待审批, 已批准, 已驳回, 已作废
+	TotalAmount    float64            `gorm:"type:numeric(14,2);not null"`
+	CreatedByID    uint               `gorm:"not null"`
+	CreatedBy      User               `gorm:"foreignKey:CreatedByID"`
+	ReviewedByID   *uint              // The approver who approved or rejected the run
+	ReviewedBy     *User              `gorm:"foreignKey:ReviewedByID"`
+	ReviewedAt     *time.Time
+	ReviewComment  string
+	Budgets        []BonusBudget `gorm:"foreignKey:RunID"`
+	Lines          []BonusLine   `gorm:"foreignKey:RunID"`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// BonusBudget 部门奖金预算
+// The cap on the bonuses of a department subtree in a run. Lines count against the nearest budgeted
+// department above them and are pro-rated when the department's requested total exceeds the cap.
+type BonusBudget struct {
+	ID              uint       `gorm:"primaryKey"`
+	RunID           uint       `gorm:"not null;index"`
+	DepartmentID    uint       `gorm:"not null"`
+	Department      Department `gorm:"foreignKey:DepartmentID"`
+	Amount          float64    `gorm:"type:numeric(14,2);not null"`
+	RequestedAmount float64    `gorm:"type:numeric(14,2);not null"` // Total of base × n before pro-rating
+	AllocatedAmount float64    `gorm:"type:numeric(14,2);not null"`
+	ProrateFactor   float64    `gorm:"type:numeric(7,6);not null"` // 1 when the budget is not exceeded
+}
+
+// BonusLine 奖金明细
+type BonusLine struct {
+	ID                 uint   `gorm:"primaryKey"`
+	RunID              uint   `gorm:"not null;index"`
+	ReviewID           uint   `gorm:"not null"`
+	UserID             uint   `gorm:"not null;index"`
+	EmployeeName       string `gorm:"not null"`
+	DepartmentName     string
+	GradeBand          string  `gorm:"not null"`
+	GradePoint         float64 `gorm:"type:numeric(5,2);not null"`
+	BaseSource         string  `gorm:"not null"` // 员工, 等级
+	BaseAmount         float64 `gorm:"type:numeric(12,2);not null"`
+	RequestedAmount    float64 `gorm:"type:numeric(12,2);not null"` // base × n
+	BudgetDepartmentID *uint   // The department whose budget caps this line, if any
+	ProrateFactor      float64 `gorm:"type:numeric(7,6);not null"`
+	Amount             float64 `gorm:"type:numeric(12,2);not null"`
+}
+
 // AutoMigrate will automatically migrate the schema, creating tables and columns
 func AutoMigrate(db *gorm.DB) {
-	db.AutoMigrate(&Department{}, &Role{}, &User{}, &PerformanceReview{}, &PerformanceItem{}, &ApprovalHistory{}, &SystemSetting{})
+	db.AutoMigrate(&ReviewCycle{})
+	backfillReviewCycles(db)
+	db.AutoMigrate(&Department{}, &Role{}, &User{}, &PerformanceReview{}, &PerformanceItem{}, &ApprovalHistory{}, &SystemSetting{}, &ApprovalDelegation{}, &ReviewReminder{}, &Appeal{}, &AppealItem{}, &AppealEvent{}, &CalibrationSession{}, &CalibrationAdjustment{}, &NormalizationRun{}, &AnnualReview{}, &ReviewCycle{}, &DefenseSession{}, &DefenseCandidate{}, &DefenseScore{}, &ReviewEvaluator{}, &EvaluatorItemScore{}, &PeerFeedbackRequest{}, &PeerFeedbackAnswer{}, &UpwardFeedbackToken{}, &UpwardFeedbackResponse{}, &UpwardFeedbackAnswer{}, &UpwardFeedbackResult{}, &ImprovementPlan{}, &ImprovementGoal{}, &ImprovementCheckIn{}, &ImprovementPlanEvent{}, &ItemCheckIn{}, &ReviewComment{}, &ReviewCommentEdit{}, &Attachment{}, &LLMCase{}, &LLMCaseLike{}, &PayrollExportBatch{}, &PayrollExportLine{}, &BonusBase{}, &BonusRun{}, &BonusBudget{}, &BonusLine{})
+}
+
+// backfillReviewCycles gives reviews created before review cycles existed the company-wide monthly
+// cycle of their YYYY-MM period, so that performance_reviews.cycle_id can be made NOT NULL.
+func backfillReviewCycles(db *gorm.DB) {
+	if !db.Migrator().HasTable(&PerformanceReview{}) {
+		return
+	}
+	db.Exec(`ALTER TABLE performance_reviews ADD COLUMN IF NOT EXISTS cycle_id bigint`)
+	db.Exec(`INSERT INTO review_cycles (name, code, cycle_type, start_date, end_date, scope_type, is_closed, created_at, updated_at)
+		SELECT DISTINCT period, period, 'monthly', to_date(period, 'YYYY-MM'), (to_date(period, 'YYYY-MM') + interval '1 month - 1 day')::date, 'all', false, NOW(), NOW()
+		FROM performance_reviews WHERE cycle_id IS NULL AND period ~ '^[0-9]{4}-(0[1-9]|1[0-2])$'
+		ON CONFLICT (code) DO NOTHING`)
+	db.Exec(`UPDATE performance_reviews SET cycle_id = review_cycles.id FROM review_cycles
+		WHERE performance_reviews.cycle_id IS NULL AND review_cycles.code = performance_reviews.period`)
 }
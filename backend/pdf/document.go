@@ -0,0 +1,403 @@
+// Package pdf renders simple A4 report documents (titles, wrapped text, ruled tables and
+// signature lines) with Chinese text.
+//
+// Documents embed a subset of the TrueType font loaded with UseFont, so they render the same on
+// every viewer. Without a font no document can be created; see ErrNoFont.
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/sha1"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf16"
+)
+
+const (
+	pageWidth  = 595.28
+	pageHeight = 841.89
+	margin     = 50.0
+
+	contentWidth = pageWidth - 2*margin
+
+	tableFontSize   = 9.0
+	tableLineHeight = 13.0
+	cellPadding     = 4.0
+)
+
+var bundledFont *TrueTypeFont
+
+// ErrNoFont is returned by NewDocument when no font has been loaded with UseFont.
+var ErrNoFont = errors.New("pdf: no CJK font loaded, set pdf.font_path to a TrueType font")
+
+// UseFont loads the TrueType font that is embedded into every document created afterwards.
+func UseFont(path string) error {
+	font, err := LoadTrueTypeFont(path)
+	if err != nil {
+		return err
+	}
+	bundledFont = font
+	return nil
+}
+
+// Document is a PDF document laid out from top to bottom, adding pages as needed.
+type Document struct {
+	font  *TrueTypeFont
+	used  map[uint16]rune // Glyphs drawn with the embedded font
+	pages []*bytes.Buffer
+	y     float64
+}
+
+// NewDocument creates an empty document with a first page. It returns ErrNoFont if no font has been loaded.
+func NewDocument() (*Document, error) {
+	if bundledFont == nil {
+		return nil, ErrNoFont
+	}
+	d := &Document{font: bundledFont, used: make(map[uint16]rune)}
+	d.newPage()
+	return d, nil
+}
+
+func (d *Document) newPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+	d.y = pageHeight - margin
+}
+
+func (d *Document) page() *bytes.Buffer {
+	return d.pages[len(d.pages)-1]
+}
+
+// ensure starts a new page unless there are at least h points left on the current one.
+func (d *Document) ensure(h float64) bool {
+	if d.y-h < margin {
+		d.newPage()
+		return true
+	}
+	return false
+}
+
+// Space adds vertical space.
+func (d *Document) Space(h float64) {
+	d.y -= h
+}
+
+// Title adds a centered title line.
+func (d *Document) Title(text string, size float64) {
+	d.ensure(size * 2)
+	d.y -= size * 1.4
+	d.text(text, (pageWidth-d.textWidth(text, size))/2, d.y, size)
+	d.y -= size * 0.6
+}
+
+// Paragraph adds left-aligned text wrapped to the page width.
+func (d *Document) Paragraph(text string, size float64) {
+	lineHeight := size * 1.5
+	for _, line := range d.wrap(text, size, contentWidth) {
+		d.ensure(lineHeight)
+		d.y -= lineHeight
+		d.text(line, margin, d.y+size*0.35, size)
+	}
+}
+
+// Table adds a ruled table. Widths are the column shares of the page width; the header row is
+// shaded and repeated on every page the table spans. Pass a nil header for a table without one.
+func (d *Document) Table(widths []float64, header []string, rows [][]string) {
+	var total float64
+	for _, w := range widths {
+		total += w
+	}
+	columns := make([]float64, len(widths))
+	for i, w := range widths {
+		columns[i] = w / total * contentWidth
+	}
+
+	if header != nil {
+		d.ensure(d.rowHeight(columns, header) + d.rowHeight(columns, firstRow(rows)))
+		d.row(columns, header, true)
+	}
+	for _, cells := range rows {
+		if d.ensure(d.rowHeight(columns, cells)) && header != nil {
+			d.row(columns, header, true)
+		}
+		d.row(columns, cells, false)
+	}
+}
+
+func firstRow(rows [][]string) []string {
+	if len(rows) == 0 {
+		return nil
+	}
+	return rows[0]
+}
+
+func (d *Document) rowHeight(columns []float64, cells []string) float64 {
+	lines := 1
+	for i, cell := range cells {
+		if i < len(columns) {
+			if n := len(d.wrap(cell, tableFontSize, columns[i]-2*cellPadding)); n > lines {
+				lines = n
+			}
+		}
+	}
+	return float64(lines)*tableLineHeight + 2*cellPadding - (tableLineHeight - tableFontSize)
+}
+
+func (d *Document) row(columns []float64, cells []string, shaded bool) {
+	h := d.rowHeight(columns, cells)
+	top := d.y
+	d.y -= h
+	x := margin
+	for i, width := range columns {
+		if shaded {
+			fmt.Fprintf(d.page(), "0.9 g %.2f %.2f %.2f %.2f re f 0 g\n", x, d.y, width, h)
+		}
+		fmt.Fprintf(d.page(), "0.5 w %.2f %.2f %.2f %.2f re S\n", x, d.y, width, h)
+		if i < len(cells) {
+			for j, line := range d.wrap(cells[i], tableFontSize, width-2*cellPadding) {
+				d.text(line, x+cellPadding, top-cellPadding-tableFontSize*0.85-float64(j)*tableLineHeight, tableFontSize)
+			}
+		}
+		x += width
+	}
+}
+
+// SignatureLines adds one line per label for a handwritten signature and date.
+func (d *Document) SignatureLines(labels []string, size float64) {
+	for _, label := range labels {
+		d.ensure(size * 3.5)
+		d.y -= size * 3.5
+		labelText := label + "："
+		d.text(labelText, margin, d.y, size)
+		lineStart := margin + d.textWidth(labelText, size) + 4
+		fmt.Fprintf(d.page(), "0.5 w %.2f %.2f m %.2f %.2f l S\n", lineStart, d.y-2, lineStart+170, d.y-2)
+		dateX := margin + contentWidth - 170
+		d.text("日期：", dateX, d.y, size)
+		dateStart := dateX + d.textWidth("日期：", size) + 4
+		fmt.Fprintf(d.page(), "0.5 w %.2f %.2f m %.2f %.2f l S\n", dateStart, d.y-2, margin+contentWidth, d.y-2)
+	}
+}
+
+func (d *Document) text(s string, x, y, size float64) {
+	if s == "" {
+		return
+	}
+	fmt.Fprintf(d.page(), "BT /F1 %.2f Tf %.2f %.2f Td <%s> Tj ET\n", size, x, y, d.encode(s))
+}
+
+// encode returns the hex string of character codes for s, the glyph IDs of the embedded font.
+func (d *Document) encode(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		fmt.Fprintf(&b, "%04X", d.code(r))
+	}
+	return b.String()
+}
+
+func (d *Document) code(r rune) uint16 {
+	gid := d.font.glyph(r)
+	if gid != 0 {
+		d.used[gid] = r
+	}
+	return gid
+}
+
+// runeWidth returns the advance of a character in thousandths of the font size.
+func (d *Document) runeWidth(r rune) int {
+	return d.font.width(d.font.glyph(r))
+}
+
+func (d *Document) textWidth(s string, size float64) float64 {
+	var width int
+	for _, r := range s {
+		width += d.runeWidth(r)
+	}
+	return float64(width) * size / 1000
+}
+
+// wrap breaks text into lines that fit the width, at spaces within Latin words and anywhere in
+// Chinese text. Newlines in the text are kept.
+func (d *Document) wrap(text string, size, width float64) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(text, "\n") {
+		line := []rune{}
+		var lineWidth float64
+		for _, r := range paragraph {
+			w := float64(d.runeWidth(r)) * size / 1000
+			if lineWidth+w > width && len(line) > 0 {
+				cut := len(line)
+				if r < 0x80 && r != ' ' {
+					if i := lastSpace(line); i > 0 {
+						cut = i + 1
+					}
+				}
+				lines = append(lines, strings.TrimRight(string(line[:cut]), " "))
+				line = append([]rune{}, line[cut:]...)
+				lineWidth = 0
+				for _, rest := range line {
+					lineWidth += float64(d.runeWidth(rest)) * size / 1000
+				}
+			}
+			line = append(line, r)
+			lineWidth += w
+		}
+		lines = append(lines, string(line))
+	}
+	return lines
+}
+
+func lastSpace(line []rune) int {
+	for i := len(line) - 1; i >= 0; i-- {
+		if line[i] == ' ' {
+			return i
+		}
+		if line[i] >= 0x80 {
+			return -1
+		}
+	}
+	return -1
+}
+
+// Bytes serializes the document, numbering its pages.
+func (d *Document) Bytes() ([]byte, error) {
+	w := &writer{}
+	w.buf.WriteString("%PDF-1.4\n%\xE2\xE3\xCF\xD3\n")
+	catalog, pages := w.reserve(), w.reserve()
+
+	for i, content := range d.pages {
+		footer := fmt.Sprintf("第 %d 页 / 共 %d 页", i+1, len(d.pages))
+		fmt.Fprintf(content, "BT /F1 8 Tf %.2f %.2f Td <%s> Tj ET\n", (pageWidth-d.textWidth(footer, 8))/2, margin/2, d.encode(footer))
+	}
+	font, err := d.writeFont(w)
+	if err != nil {
+		return nil, err
+	}
+
+	kids := make([]string, 0, len(d.pages))
+	for _, content := range d.pages {
+		stream, err := w.stream("", content.Bytes())
+		if err != nil {
+			return nil, err
+		}
+		page := w.object(fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /Font << /F1 %d 0 R >> >> /Contents %d 0 R >>",
+			pages, pageWidth, pageHeight, font, stream))
+		kids = append(kids, fmt.Sprintf("%d 0 R", page))
+	}
+	w.write(pages, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(kids)))
+	w.write(catalog, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pages))
+	return w.finish(catalog), nil
+}
+
+// writeFont writes the font objects and returns the number of the Type0 font object.
+func (d *Document) writeFont(w *writer) (int, error) {
+	gids := make([]int, 0, len(d.used))
+	used := make(map[uint16]bool, len(d.used))
+	for gid := range d.used {
+		gids = append(gids, int(gid))
+		used[gid] = true
+	}
+	sort.Ints(gids)
+
+	// Subset fonts are named with a tag derived from the glyphs they contain
+	hash := sha1.New()
+	var widths, toUnicode strings.Builder
+	for _, gid := range gids {
+		fmt.Fprintf(hash, "%d,", gid)
+		fmt.Fprintf(&widths, "%d [%d] ", gid, d.font.width(uint16(gid)))
+	}
+	sum := hash.Sum(nil)
+	tag := make([]byte, 6)
+	for i := range tag {
+		tag[i] = 'A' + sum[i]%26
+	}
+	name := string(tag) + "+CJKFont"
+
+	fontData := d.font.subset(used)
+	fontFile, err := w.stream(fmt.Sprintf("/Length1 %d", len(fontData)), fontData)
+	if err != nil {
+		return 0, err
+	}
+	descriptor := w.object(fmt.Sprintf("<< /Type /FontDescriptor /FontName /%s /Flags 4 /FontBBox [%d %d %d %d] /ItalicAngle 0 /Ascent %d /Descent %d /CapHeight %d /StemV 80 /FontFile2 %d 0 R >>",
+		name, d.font.scale(d.font.bbox[0]), d.font.scale(d.font.bbox[1]), d.font.scale(d.font.bbox[2]), d.font.scale(d.font.bbox[3]),
+		d.font.scale(d.font.ascent), d.font.scale(d.font.descent), d.font.scale(d.font.ascent), fontFile))
+	cidFont := w.object(fmt.Sprintf("<< /Type /Font /Subtype /CIDFontType2 /BaseFont /%s /CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> /FontDescriptor %d 0 R /W [%s] /CIDToGIDMap /Identity >>",
+		name, descriptor, widths.String()))
+
+	// The ToUnicode map keeps the text searchable and copyable
+	toUnicode.WriteString("/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n/CMapName /Adobe-Identity-UCS def\n/CMapType 2 def\n1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n")
+	for start := 0; start < len(gids); start += 100 {
+		end := start + 100
+		if end > len(gids) {
+			end = len(gids)
+		}
+		fmt.Fprintf(&toUnicode, "%d beginbfchar\n", end-start)
+		for _, gid := range gids[start:end] {
+			var unicode strings.Builder
+			for _, unit := range utf16.Encode([]rune{d.used[uint16(gid)]}) {
+				fmt.Fprintf(&unicode, "%04X", unit)
+			}
+			fmt.Fprintf(&toUnicode, "<%04X> <%s>\n", gid, unicode.String())
+		}
+		toUnicode.WriteString("endbfchar\n")
+	}
+	toUnicode.WriteString("endcmap\nCMapName currentdict /CMap defineresource pop\nend\nend\n")
+	cmap, err := w.stream("", []byte(toUnicode.String()))
+	if err != nil {
+		return 0, err
+	}
+
+	return w.object(fmt.Sprintf("<< /Type /Font /Subtype /Type0 /BaseFont /%s /Encoding /Identity-H /DescendantFonts [%d 0 R] /ToUnicode %d 0 R >>",
+		name, cidFont, cmap)), nil
+}
+
+// writer writes numbered objects and the cross-reference table of a PDF file.
+type writer struct {
+	buf     bytes.Buffer
+	offsets []int // Byte offset of each object, indexed by object number - 1
+}
+
+func (w *writer) reserve() int {
+	w.offsets = append(w.offsets, 0)
+	return len(w.offsets)
+}
+
+func (w *writer) write(id int, body string) {
+	w.offsets[id-1] = w.buf.Len()
+	fmt.Fprintf(&w.buf, "%d 0 obj\n%s\nendobj\n", id, body)
+}
+
+func (w *writer) object(body string) int {
+	id := w.reserve()
+	w.write(id, body)
+	return id
+}
+
+// stream writes a Flate-compressed stream object with extra dictionary entries.
+func (w *writer) stream(entries string, data []byte) (int, error) {
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	if _, err := zw.Write(data); err != nil {
+		return 0, err
+	}
+	if err := zw.Close(); err != nil {
+		return 0, err
+	}
+	id := w.reserve()
+	w.offsets[id-1] = w.buf.Len()
+	fmt.Fprintf(&w.buf, "%d 0 obj\n<< /Length %d /Filter /FlateDecode %s>>\nstream\n", id, compressed.Len(), entries)
+	w.buf.Write(compressed.Bytes())
+	w.buf.WriteString("\nendstream\nendobj\n")
+	return id, nil
+}
+
+func (w *writer) finish(root int) []byte {
+	xref := w.buf.Len()
+	fmt.Fprintf(&w.buf, "xref\n0 %d\n0000000000 65535 f \n", len(w.offsets)+1)
+	for _, offset := range w.offsets {
+		fmt.Fprintf(&w.buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&w.buf, "trailer\n<< /Size %d /Root %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(w.offsets)+1, root, xref)
+	return w.buf.Bytes()
+}
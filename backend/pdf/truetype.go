@@ -0,0 +1,359 @@
+package pdf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"os"
+	"sort"
+)
+
+// TrueTypeFont is a parsed TrueType font (a .ttf file, or the first font of a .ttc collection)
+// that can be embedded into documents as a subset.
+type TrueTypeFont struct {
+	tables     map[string][]byte
+	unitsPerEm int
+	numGlyphs  int
+	longLoca   bool
+	advances   []uint16 // Advance width of each glyph in font units
+	cmap       map[rune]uint16
+	ascent     int
+	descent    int
+	bbox       [4]int
+}
+
+// LoadTrueTypeFont reads and parses a TrueType font file.
+func LoadTrueTypeFont(path string) (*TrueTypeFont, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseTrueTypeFont(data)
+}
+
+// ParseTrueTypeFont parses a TrueType font. Fonts with CFF outlines are not supported.
+func ParseTrueTypeFont(data []byte) (*TrueTypeFont, error) {
+	offset := 0
+	if len(data) >= 16 && string(data[:4]) == "ttcf" {
+		offset = int(binary.BigEndian.Uint32(data[12:]))
+	}
+	if len(data) < offset+12 {
+		return nil, errors.New("invalid font file")
+	}
+	numTables := int(binary.BigEndian.Uint16(data[offset+4:]))
+	f := &TrueTypeFont{tables: make(map[string][]byte)}
+	for i := 0; i < numTables; i++ {
+		record := offset + 12 + i*16
+		if len(data) < record+16 {
+			return nil, errors.New("invalid font file")
+		}
+		tag := string(data[record : record+4])
+		start := int(binary.BigEndian.Uint32(data[record+8:]))
+		length := int(binary.BigEndian.Uint32(data[record+12:]))
+		if start < 0 || length < 0 || start+length > len(data) {
+			return nil, errors.New("invalid font table " + tag)
+		}
+		f.tables[tag] = data[start : start+length]
+	}
+	for _, tag := range []string{"head", "hhea", "maxp", "hmtx", "cmap", "loca", "glyf"} {
+		if _, ok := f.tables[tag]; !ok {
+			if tag == "glyf" {
+				return nil, errors.New("only fonts with TrueType outlines are supported")
+			}
+			return nil, errors.New("font is missing the " + tag + " table")
+		}
+	}
+
+	head, hhea := f.tables["head"], f.tables["hhea"]
+	if len(head) < 54 || len(hhea) < 36 || len(f.tables["maxp"]) < 6 {
+		return nil, errors.New("invalid font header")
+	}
+	f.unitsPerEm = int(binary.BigEndian.Uint16(head[18:]))
+	f.bbox = [4]int{int(int16(binary.BigEndian.Uint16(head[36:]))), int(int16(binary.BigEndian.Uint16(head[38:]))),
+		int(int16(binary.BigEndian.Uint16(head[40:]))), int(int16(binary.BigEndian.Uint16(head[42:])))}
+	f.longLoca = binary.BigEndian.Uint16(head[50:]) == 1
+	f.ascent = int(int16(binary.BigEndian.Uint16(hhea[4:])))
+	f.descent = int(int16(binary.BigEndian.Uint16(hhea[6:])))
+	f.numGlyphs = int(binary.BigEndian.Uint16(f.tables["maxp"][4:]))
+	if f.unitsPerEm == 0 {
+		return nil, errors.New("invalid font units per em")
+	}
+
+	// Advance widths; glyphs past numberOfHMetrics repeat the last advance
+	numHMetrics := int(binary.BigEndian.Uint16(hhea[34:]))
+	hmtx := f.tables["hmtx"]
+	if numHMetrics == 0 || len(hmtx) < numHMetrics*4 {
+		return nil, errors.New("invalid font metrics")
+	}
+	f.advances = make([]uint16, f.numGlyphs)
+	for gid := range f.advances {
+		if gid < numHMetrics {
+			f.advances[gid] = binary.BigEndian.Uint16(hmtx[gid*4:])
+		} else {
+			f.advances[gid] = f.advances[numHMetrics-1]
+		}
+	}
+
+	cmap, err := parseCmap(f.tables["cmap"])
+	if err != nil {
+		return nil, err
+	}
+	f.cmap = cmap
+	return f, nil
+}
+
+// glyph returns the glyph ID of a character, 0 (.notdef) if the font does not have it.
+func (f *TrueTypeFont) glyph(r rune) uint16 {
+	return f.cmap[r]
+}
+
+// width returns the advance of a glyph in thousandths of the font size.
+func (f *TrueTypeFont) width(gid uint16) int {
+	if int(gid) >= len(f.advances) {
+		return 0
+	}
+	return int(f.advances[gid]) * 1000 / f.unitsPerEm
+}
+
+func (f *TrueTypeFont) scale(v int) int {
+	return v * 1000 / f.unitsPerEm
+}
+
+// parseCmap reads the Unicode character map, preferring the full-repertoire format 12 subtable.
+func parseCmap(cmap []byte) (map[rune]uint16, error) {
+	if len(cmap) < 4 {
+		return nil, errors.New("invalid cmap table")
+	}
+	best, bestRank := -1, 0
+	numSubtables := int(binary.BigEndian.Uint16(cmap[2:]))
+	for i := 0; i < numSubtables && 4+i*8+8 <= len(cmap); i++ {
+		record := cmap[4+i*8:]
+		platform, encoding := binary.BigEndian.Uint16(record), binary.BigEndian.Uint16(record[2:])
+		offset := int(binary.BigEndian.Uint32(record[4:]))
+		if offset+2 > len(cmap) {
+			continue
+		}
+		format := binary.BigEndian.Uint16(cmap[offset:])
+		rank := 0
+		switch {
+		case format == 12 && (platform == 3 && encoding == 10 || platform == 0):
+			rank = 2
+		case format == 4 && (platform == 3 && encoding == 1 || platform == 0):
+			rank = 1
+		}
+		if rank > bestRank {
+			best, bestRank = offset, rank
+		}
+	}
+	if best < 0 {
+		return nil, errors.New("font has no Unicode cmap")
+	}
+
+	result := make(map[rune]uint16)
+	table := cmap[best:]
+	if bestRank == 2 {
+		if len(table) < 16 {
+			return nil, errors.New("invalid cmap table")
+		}
+		numGroups := int(binary.BigEndian.Uint32(table[12:]))
+		for i := 0; i < numGroups && 16+i*12+12 <= len(table); i++ {
+			group := table[16+i*12:]
+			start, end := binary.BigEndian.Uint32(group), binary.BigEndian.Uint32(group[4:])
+			gid := binary.BigEndian.Uint32(group[8:])
+			for c := start; c <= end && end-start < 0x110000; c++ {
+				result[rune(c)] = uint16(gid + c - start)
+			}
+		}
+		return result, nil
+	}
+
+	if len(table) < 14 {
+		return nil, errors.New("invalid cmap table")
+	}
+	segCount := int(binary.BigEndian.Uint16(table[6:])) / 2
+	if len(table) < 16+segCount*8 {
+		return nil, errors.New("invalid cmap table")
+	}
+	ends := table[14:]
+	starts := table[16+segCount*2:]
+	deltas := table[16+segCount*4:]
+	rangeOffsets := table[16+segCount*6:]
+	for i := 0; i < segCount; i++ {
+		end := binary.BigEndian.Uint16(ends[i*2:])
+		start := binary.BigEndian.Uint16(starts[i*2:])
+		delta := binary.BigEndian.Uint16(deltas[i*2:])
+		rangeOffset := int(binary.BigEndian.Uint16(rangeOffsets[i*2:]))
+		for c := int(start); c <= int(end) && c != 0xFFFF; c++ {
+			var gid uint16
+			if rangeOffset == 0 {
+				gid = uint16(c) + delta
+			} else {
+				index := 16 + segCount*6 + i*2 + rangeOffset + (c-int(start))*2
+				if index+2 > len(table) {
+					continue
+				}
+				if gid = binary.BigEndian.Uint16(table[index:]); gid != 0 {
+					gid += delta
+				}
+			}
+			if gid != 0 {
+				result[rune(c)] = gid
+			}
+		}
+	}
+	return result, nil
+}
+
+// subset returns a font file that keeps the glyph IDs of the original font but only the outlines of
+// the used glyphs (and the components of composite glyphs), so that it can be embedded with an
+// identity CID-to-glyph mapping.
+func (f *TrueTypeFont) subset(used map[uint16]bool) []byte {
+	glyf, loca := f.tables["glyf"], f.tables["loca"]
+	glyphData := func(gid uint16) []byte {
+		var start, end int
+		if f.longLoca {
+			if int(gid)*4+8 > len(loca) {
+				return nil
+			}
+			start, end = int(binary.BigEndian.Uint32(loca[gid*4:])), int(binary.BigEndian.Uint32(loca[gid*4+4:]))
+		} else {
+			if int(gid)*2+4 > len(loca) {
+				return nil
+			}
+			start, end = int(binary.BigEndian.Uint16(loca[gid*2:]))*2, int(binary.BigEndian.Uint16(loca[gid*2+2:]))*2
+		}
+		if start >= end || end > len(glyf) {
+			return nil
+		}
+		return glyf[start:end]
+	}
+
+	// Add the components of composite glyphs
+	keep := map[uint16]bool{0: true}
+	queue := make([]uint16, 0, len(used))
+	for gid := range used {
+		queue = append(queue, gid)
+	}
+	for len(queue) > 0 {
+		gid := queue[len(queue)-1]
+		queue = queue[:len(queue)-1]
+		if keep[gid] && gid != 0 {
+			continue
+		}
+		keep[gid] = true
+		data := glyphData(gid)
+		if len(data) < 10 || int16(binary.BigEndian.Uint16(data)) >= 0 {
+			continue
+		}
+		for p := 10; p+4 <= len(data); {
+			flags := binary.BigEndian.Uint16(data[p:])
+			component := binary.BigEndian.Uint16(data[p+2:])
+			if !keep[component] {
+				queue = append(queue, component)
+			}
+			p += 4
+			if flags&0x0001 != 0 {
+				p += 4
+			} else {
+				p += 2
+			}
+			switch {
+			case flags&0x0008 != 0:
+				p += 2
+			case flags&0x0040 != 0:
+				p += 4
+			case flags&0x0080 != 0:
+				p += 8
+			}
+			if flags&0x0020 == 0 {
+				break
+			}
+		}
+	}
+
+	// Rebuild glyf and a long-format loca
+	var newGlyf bytes.Buffer
+	newLoca := make([]byte, (f.numGlyphs+1)*4)
+	for gid := 0; gid < f.numGlyphs; gid++ {
+		binary.BigEndian.PutUint32(newLoca[gid*4:], uint32(newGlyf.Len()))
+		if keep[uint16(gid)] {
+			newGlyf.Write(glyphData(uint16(gid)))
+			for newGlyf.Len()%4 != 0 {
+				newGlyf.WriteByte(0)
+			}
+		}
+	}
+	binary.BigEndian.PutUint32(newLoca[f.numGlyphs*4:], uint32(newGlyf.Len()))
+
+	head := append([]byte{}, f.tables["head"]...)
+	binary.BigEndian.PutUint32(head[8:], 0) // checkSumAdjustment
+	binary.BigEndian.PutUint16(head[50:], 1)
+
+	tables := map[string][]byte{
+		"head": head,
+		"hhea": f.tables["hhea"],
+		"maxp": f.tables["maxp"],
+		"hmtx": f.tables["hmtx"],
+		"loca": newLoca,
+		"glyf": newGlyf.Bytes(),
+	}
+	for _, tag := range []string{"cvt ", "fpgm", "prep"} {
+		if table, ok := f.tables[tag]; ok {
+			tables[tag] = table
+		}
+	}
+	return writeFontFile(tables)
+}
+
+// writeFontFile assembles a TrueType file from its tables.
+func writeFontFile(tables map[string][]byte) []byte {
+	tags := make([]string, 0, len(tables))
+	for tag := range tables {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	numTables := len(tags)
+	searchRange, entrySelector := 1, 0
+	for searchRange*2 <= numTables {
+		searchRange *= 2
+		entrySelector++
+	}
+	var out bytes.Buffer
+	header := make([]byte, 12)
+	binary.BigEndian.PutUint32(header, 0x00010000)
+	binary.BigEndian.PutUint16(header[4:], uint16(numTables))
+	binary.BigEndian.PutUint16(header[6:], uint16(searchRange*16))
+	binary.BigEndian.PutUint16(header[8:], uint16(entrySelector))
+	binary.BigEndian.PutUint16(header[10:], uint16(numTables*16-searchRange*16))
+	out.Write(header)
+
+	offset := 12 + numTables*16
+	records := make([]byte, numTables*16)
+	for i, tag := range tags {
+		table := tables[tag]
+		copy(records[i*16:], tag)
+		binary.BigEndian.PutUint32(records[i*16+4:], tableChecksum(table))
+		binary.BigEndian.PutUint32(records[i*16+8:], uint32(offset))
+		binary.BigEndian.PutUint32(records[i*16+12:], uint32(len(table)))
+		offset += (len(table) + 3) &^ 3
+	}
+	out.Write(records)
+	for _, tag := range tags {
+		out.Write(tables[tag])
+		for out.Len()%4 != 0 {
+			out.WriteByte(0)
+		}
+	}
+	return out.Bytes()
+}
+
+func tableChecksum(table []byte) uint32 {
+	var sum uint32
+	for i := 0; i < len(table); i += 4 {
+		var word [4]byte
+		copy(word[:], table[i:])
+		sum += binary.BigEndian.Uint32(word[:])
+	}
+	return sum
+}
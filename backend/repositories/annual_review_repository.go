@@ -0,0 +1,48 @@
+package repositories
+
+import (
+	"cepm-backend/database"
+	"cepm-backend/models"
+
+	"gorm.io/gorm"
+)
+
+type AnnualReviewRepository interface {
+	Save(annual *models.AnnualReview) error
+	GetByUserIDAndYear(userID uint, year int) (*models.AnnualReview, error)
+	ListByYear(year int, userIDs []uint) ([]models.AnnualReview, error)
+}
+
+type dbAnnualReviewRepository struct {
+	db *gorm.DB
+}
+
+func NewAnnualReviewRepository() AnnualReviewRepository {
+	return &dbAnnualReviewRepository{db: database.DB}
+}
+
+// Save creates or updates an annual review.
+func (r *dbAnnualReviewRepository) Save(annual *models.AnnualReview) error {
+	return r.db.Omit("User", "EvaluatedBy").Save(annual).Error
+}
+
+// GetByUserIDAndYear retrieves a user's annual review for a year.
+func (r *dbAnnualReviewRepository) GetByUserIDAndYear(userID uint, year int) (*models.AnnualReview, error) {
+	var annual models.AnnualReview
+	err := r.db.Preload("User.Department").Preload("EvaluatedBy").Where("user_id = ? AND year = ?", userID, year).First(&annual).Error
+	if err != nil {
+		return nil, err // Can be gorm.ErrRecordNotFound
+	}
+	return &annual, nil
+}
+
+// ListByYear retrieves the annual reviews of a year, optionally limited to some users.
+func (r *dbAnnualReviewRepository) ListByYear(year int, userIDs []uint) ([]models.AnnualReview, error) {
+	var annuals []models.AnnualReview
+	query := r.db.Preload("User.Department").Where("year = ?", year)
+	if userIDs != nil {
+		query = query.Where("user_id IN ?", userIDs)
+	}
+	err := query.Order("user_id asc").Find(&annuals).Error
+	return annuals, err
+}
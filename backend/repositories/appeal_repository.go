@@ -0,0 +1,118 @@
+package repositories
+
+import (
+	"errors"
+
+	"cepm-backend/database"
+	"cepm-backend/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type AppealRepository interface {
+	CreateAndLockReview(appeal *models.Appeal) error
+	GetByID(id uint) (*models.Appeal, error)
+	ListByReviewID(reviewID uint) ([]models.Appeal, error)
+	ListByHandlerID(handlerID uint) ([]models.Appeal, error)
+	ListPendingByHandlerID(handlerID uint) ([]models.Appeal, error)
+	ListByAppellantID(appellantID uint) ([]models.Appeal, error)
+	Resolve(appeal *models.Appeal, event *models.AppealEvent, revisedItems []models.PerformanceItem, reviewUpdates map[string]interface{}) error
+}
+
+type dbAppealRepository struct {
+	db *gorm.DB
+}
+
+func NewAppealRepository() AppealRepository {
+	return &dbAppealRepository{db: database.DB}
+}
+
+// CreateAndLockReview creates an appeal with its items and first timeline event,
+// and moves the review to '申诉中' in a single transaction. It fails if the review has left '已完成' since it was read.
+func (r *dbAppealRepository) CreateAndLockReview(appeal *models.Appeal) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.PerformanceReview{}).
+			Where("id = ? AND status = ? AND appeal_resolved_at IS NULL", appeal.ReviewID, "已完成").
+			Update("status", "申诉中")
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("绩效评估状态已变更，无法提交申诉")
+		}
+		return tx.Create(appeal).Error
+	})
+}
+
+// GetByID retrieves a single appeal with its items, timeline and review preloaded.
+func (r *dbAppealRepository) GetByID(id uint) (*models.Appeal, error) {
+	var appeal models.Appeal
+	err := r.db.Preload("Review.Items").Preload("Review.User").
+		Preload("Appellant").Preload("Handler").
+		Preload("Items.PerformanceItem").
+		Preload("Events", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at asc")
+		}).Preload("Events.Actor").
+		First(&appeal, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &appeal, nil
+}
+
+// ListByReviewID retrieves all appeals filed against a review.
+func (r *dbAppealRepository) ListByReviewID(reviewID uint) ([]models.Appeal, error) {
+	var appeals []models.Appeal
+	err := r.db.Preload("Appellant").Preload("Handler").Preload("Items").Where("review_id = ?", reviewID).Order("created_at desc").Find(&appeals).Error
+	return appeals, err
+}
+
+// ListByHandlerID retrieves all appeals routed to a handler.
+func (r *dbAppealRepository) ListByHandlerID(handlerID uint) ([]models.Appeal, error) {
+	var appeals []models.Appeal
+	err := r.db.Preload("Review").Preload("Appellant").Preload("Items").Where("handler_id = ?", handlerID).Order("created_at desc").Find(&appeals).Error
+	return appeals, err
+}
+
+// ListPendingByHandlerID retrieves the appeals awaiting a handler's decision, oldest first.
+func (r *dbAppealRepository) ListPendingByHandlerID(handlerID uint) ([]models.Appeal, error) {
+	var appeals []models.Appeal
+	err := r.db.Preload("Review.User.Department").Where("handler_id = ? AND status = ?", handlerID, "待处理").Order("created_at asc").Find(&appeals).Error
+	return appeals, err
+}
+
+// ListByAppellantID retrieves all appeals filed by a user.
+func (r *dbAppealRepository) ListByAppellantID(appellantID uint) ([]models.Appeal, error) {
+	var appeals []models.Appeal
+	err := r.db.Preload("Review").Preload("Handler").Preload("Items").Where("appellant_id = ?", appellantID).Order("created_at desc").Find(&appeals).Error
+	return appeals, err
+}
+
+// Resolve closes an appeal in a single transaction: it saves the appeal and its items, adds the timeline event,
+// applies any revised item scores and updates the review.
+func (r *dbAppealRepository) Resolve(appeal *models.Appeal, event *models.AppealEvent, revisedItems []models.PerformanceItem, reviewUpdates map[string]interface{}) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Save(appeal).Error; err != nil {
+			return err
+		}
+		for i := range appeal.Items {
+			if err := tx.Omit("PerformanceItem").Save(&appeal.Items[i]).Error; err != nil {
+				return err
+			}
+		}
+
+		event.AppealID = appeal.ID
+		if err := tx.Create(event).Error; err != nil {
+			return err
+		}
+
+		for _, item := range revisedItems {
+			if err := tx.Model(&models.PerformanceItem{}).Where("id = ?", item.ID).Update("score", item.Score).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Model(&models.PerformanceReview{}).Where("id = ?", appeal.ReviewID).Updates(reviewUpdates).Error
+	})
+}
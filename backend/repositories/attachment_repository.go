@@ -0,0 +1,103 @@
+package repositories
+
+import (
+	"cepm-backend/database"
+	"cepm-backend/models"
+
+	"gorm.io/gorm"
+)
+
+type AttachmentRepository interface {
+	Create(attachment *models.Attachment) error
+	CreateFile(attachment *models.Attachment, put func() error) error
+	GetByID(id uint) (*models.Attachment, error)
+	ListByReviewID(reviewID uint, itemID *uint) ([]models.Attachment, error)
+	Delete(id uint) error
+	DeleteFile(attachment *models.Attachment, remove func() error) error
+}
+
+type dbAttachmentRepository struct {
+	db *gorm.DB
+}
+
+func NewAttachmentRepository() AttachmentRepository {
+	return &dbAttachmentRepository{db: database.DB}
+}
+
+func (r *dbAttachmentRepository) Create(attachment *models.Attachment) error {
+	return r.db.Omit("UploadedBy").Create(attachment).Error
+}
+
+// CreateFile stores a file attachment. put is called to upload the content when no other attachment
+// references the storage key yet. Uploads and deletes of the same key are serialized with a transaction-level
+// advisory lock, so a file being reused cannot be removed by a concurrent delete.
+func (r *dbAttachmentRepository) CreateFile(attachment *models.Attachment, put func() error) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockStorageKey(tx, attachment.StorageKey); err != nil {
+			return err
+		}
+		count, err := countByStorageKey(tx, attachment.StorageKey)
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			if err := put(); err != nil {
+				return err
+			}
+		}
+		return tx.Omit("UploadedBy").Create(attachment).Error
+	})
+}
+
+func (r *dbAttachmentRepository) GetByID(id uint) (*models.Attachment, error) {
+	var attachment models.Attachment
+	if err := r.db.First(&attachment, id).Error; err != nil {
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+// ListByReviewID retrieves the attachments of a review, optionally for a single item, oldest first.
+func (r *dbAttachmentRepository) ListByReviewID(reviewID uint, itemID *uint) ([]models.Attachment, error) {
+	var attachments []models.Attachment
+	query := r.db.Preload("UploadedBy").Where("review_id = ?", reviewID)
+	if itemID != nil {
+		query = query.Where("item_id = ?", *itemID)
+	}
+	err := query.Order("item_id, id").Find(&attachments).Error
+	return attachments, err
+}
+
+func (r *dbAttachmentRepository) Delete(id uint) error {
+	return r.db.Delete(&models.Attachment{}, id).Error
+}
+
+// DeleteFile deletes a file attachment and calls remove to delete the stored content when no other
+// attachment references it. If remove fails the attachment is kept.
+func (r *dbAttachmentRepository) DeleteFile(attachment *models.Attachment, remove func() error) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockStorageKey(tx, attachment.StorageKey); err != nil {
+			return err
+		}
+		if err := tx.Delete(&models.Attachment{}, attachment.ID).Error; err != nil {
+			return err
+		}
+		count, err := countByStorageKey(tx, attachment.StorageKey)
+		if err != nil || count > 0 {
+			return err
+		}
+		return remove()
+	})
+}
+
+// lockStorageKey takes an advisory lock on a storage key until the end of the transaction.
+func lockStorageKey(tx *gorm.DB, storageKey string) error {
+	return tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", storageKey).Error
+}
+
+// countByStorageKey counts the attachments that reference a stored file.
+func countByStorageKey(tx *gorm.DB, storageKey string) (int64, error) {
+	var count int64
+	err := tx.Model(&models.Attachment{}).Where("storage_key = ?", storageKey).Count(&count).Error
+	return count, err
+}
@@ -0,0 +1,149 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"cepm-backend/database"
+	"cepm-backend/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrBonusPeriodApproved is returned by CreateRun when a run of the period has already been approved.
+var ErrBonusPeriodApproved = errors.New("bonus period already approved")
+
+type BonusRepository interface {
+	ListBases() ([]models.BonusBase, error)
+	SaveBases(bases []models.BonusBase) error
+	DeleteBase(id uint) error
+	CreateRun(run *models.BonusRun) error
+	GetRun(id uint) (*models.BonusRun, error)
+	ListRuns(period string) ([]models.BonusRun, error)
+	ReviewRun(id uint, status string, reviewerID uint, comment string) error
+}
+
+type dbBonusRepository struct {
+	db *gorm.DB
+}
+
+func NewBonusRepository() BonusRepository {
+	return &dbBonusRepository{db: database.DB}
+}
+
+// ListBases retrieves the grade band bases followed by the employee bases.
+func (r *dbBonusRepository) ListBases() ([]models.BonusBase, error) {
+	var bases []models.BonusBase
+	err := r.db.Preload("User").Order("user_id asc nulls first, grade_band asc").Find(&bases).Error
+	return bases, err
+}
+
+// SaveBases creates or updates the base of each employee or grade band in a single transaction.
+func (r *dbBonusRepository) SaveBases(bases []models.BonusBase) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for i := range bases {
+			var existing models.BonusBase
+			query := tx.Where("grade_band = ?", bases[i].GradeBand)
+			if bases[i].UserID != nil {
+				query = tx.Where("user_id = ?", *bases[i].UserID)
+			}
+			err := query.First(&existing).Error
+			switch {
+			case err == nil:
+				bases[i].ID = existing.ID
+				bases[i].CreatedAt = existing.CreatedAt
+				if err := tx.Model(&existing).Updates(map[string]interface{}{"amount": bases[i].Amount, "updated_by_id": bases[i].UpdatedByID}).Error; err != nil {
+					return err
+				}
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				if err := tx.Create(&bases[i]).Error; err != nil {
+					return err
+				}
+			default:
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *dbBonusRepository) DeleteBase(id uint) error {
+	result := r.db.Delete(&models.BonusBase{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// CreateRun creates a run with its budgets and lines, voiding the pending runs of the same period
+// in the same transaction. It returns ErrBonusPeriodApproved if a run of the period has been approved.
+// Runs of a period are created one at a time, and the existing runs are locked so that none of them
+// can be approved while the new run replaces them.
+func (r *dbBonusRepository) CreateRun(run *models.BonusRun) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "bonus_run:"+run.Period).Error; err != nil {
+			return err
+		}
+		var existing []models.BonusRun
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "status").
+			Where("period = ?", run.Period).Find(&existing).Error; err != nil {
+			return err
+		}
+		for _, other := range existing {
+			if other.Status == "已批准" {
+				return ErrBonusPeriodApproved
+			}
+		}
+		if err := tx.Model(&models.BonusRun{}).Where("period = ? AND status = ?", run.Period, "待审批").
+			Update("status", "已作废").Error; err != nil {
+			return err
+		}
+		return tx.Create(run).Error
+	})
+}
+
+// GetRun retrieves a single run with its budgets and lines preloaded.
+func (r *dbBonusRepository) GetRun(id uint) (*models.BonusRun, error) {
+	var run models.BonusRun
+	err := r.db.Preload("CreatedBy").Preload("ReviewedBy").Preload("Budgets.Department").
+		Preload("Lines", func(db *gorm.DB) *gorm.DB {
+			return db.Order("id asc")
+		}).First(&run, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// ListRuns retrieves all runs without their lines, optionally filtered by period.
+func (r *dbBonusRepository) ListRuns(period string) ([]models.BonusRun, error) {
+	var runs []models.BonusRun
+	query := r.db.Preload("CreatedBy").Preload("ReviewedBy")
+	if period != "" {
+		query = query.Where("period = ?", period)
+	}
+	err := query.Order("created_at desc").Find(&runs).Error
+	return runs, err
+}
+
+// ReviewRun approves or rejects a pending run. The status check is part of the update so that a run
+// cannot be reviewed twice; gorm.ErrRecordNotFound is returned if it is no longer pending.
+func (r *dbBonusRepository) ReviewRun(id uint, status string, reviewerID uint, comment string) error {
+	result := r.db.Model(&models.BonusRun{}).Where("id = ? AND status = ?", id, "待审批").Updates(map[string]interface{}{
+		"status":         status,
+		"reviewed_by_id": reviewerID,
+		"reviewed_at":    time.Now(),
+		"review_comment": comment,
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
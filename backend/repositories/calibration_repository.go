@@ -0,0 +1,106 @@
+package repositories
+
+import (
+	"time"
+
+	"cepm-backend/database"
+	"cepm-backend/models"
+
+	"gorm.io/gorm"
+)
+
+type CalibrationRepository interface {
+	FindReviewsForCalibration(period string, departmentIDs []uint, statuses []string) ([]models.PerformanceReview, error)
+	CreateAndLockReviews(session *models.CalibrationSession, reviewIDs []uint) error
+	GetByID(id uint) (*models.CalibrationSession, error)
+	ListByPeriod(period string) ([]models.CalibrationSession, error)
+	ListLockedReviews(sessionID uint) ([]models.PerformanceReview, error)
+	AddAdjustment(adjustment *models.CalibrationAdjustment, result map[string]interface{}) error
+	CloseAndUnlockReviews(sessionID uint) error
+}
+
+type dbCalibrationRepository struct {
+	db *gorm.DB
+}
+
+func NewCalibrationRepository() CalibrationRepository {
+	return &dbCalibrationRepository{db: database.DB}
+}
+
+// FindReviewsForCalibration retrieves the unlocked reviews of a period whose owners belong to the given departments.
+func (r *dbCalibrationRepository) FindReviewsForCalibration(period string, departmentIDs []uint, statuses []string) ([]models.PerformanceReview, error) {
+	var reviews []models.PerformanceReview
+	err := r.db.Where("period = ? AND status IN ? AND calibration_session_id IS NULL AND user_id IN (?)",
+		period, statuses, r.db.Model(&models.User{}).Select("id").Where("department_id IN ?", departmentIDs)).
+		Find(&reviews).Error
+	return reviews, err
+}
+
+// CreateAndLockReviews creates a session and locks the given reviews to it in a single transaction.
+func (r *dbCalibrationRepository) CreateAndLockReviews(session *models.CalibrationSession, reviewIDs []uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(session).Error; err != nil {
+			return err
+		}
+		if len(reviewIDs) == 0 {
+			return nil
+		}
+		return tx.Model(&models.PerformanceReview{}).Where("id IN ?", reviewIDs).Update("calibration_session_id", session.ID).Error
+	})
+}
+
+// GetByID retrieves a single session with its adjustments preloaded.
+func (r *dbCalibrationRepository) GetByID(id uint) (*models.CalibrationSession, error) {
+	var session models.CalibrationSession
+	err := r.db.Preload("Department").Preload("CreatedBy").
+		Preload("Adjustments", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at asc")
+		}).Preload("Adjustments.Adjuster").
+		First(&session, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// ListByPeriod retrieves all sessions, optionally filtered by period.
+func (r *dbCalibrationRepository) ListByPeriod(period string) ([]models.CalibrationSession, error) {
+	var sessions []models.CalibrationSession
+	query := r.db.Preload("Department").Preload("CreatedBy")
+	if period != "" {
+		query = query.Where("period = ?", period)
+	}
+	err := query.Order("created_at desc").Find(&sessions).Error
+	return sessions, err
+}
+
+// ListLockedReviews retrieves the reviews locked by a session.
+func (r *dbCalibrationRepository) ListLockedReviews(sessionID uint) ([]models.PerformanceReview, error) {
+	var reviews []models.PerformanceReview
+	err := r.db.Preload("User.Department").Where("calibration_session_id = ?", sessionID).Order("user_id asc").Find(&reviews).Error
+	return reviews, err
+}
+
+// AddAdjustment records an adjustment and applies the recomputed result (including the calibration delta)
+// to the review in a single transaction.
+func (r *dbCalibrationRepository) AddAdjustment(adjustment *models.CalibrationAdjustment, result map[string]interface{}) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(adjustment).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.PerformanceReview{}).Where("id = ?", adjustment.ReviewID).Updates(result).Error
+	})
+}
+
+// CloseAndUnlockReviews closes a session and releases its reviews in a single transaction.
+func (r *dbCalibrationRepository) CloseAndUnlockReviews(sessionID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.CalibrationSession{}).Where("id = ?", sessionID).Updates(map[string]interface{}{
+			"status":    "已结束",
+			"closed_at": time.Now(),
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.PerformanceReview{}).Where("calibration_session_id = ?", sessionID).Update("calibration_session_id", nil).Error
+	})
+}
@@ -0,0 +1,68 @@
+package repositories
+
+import (
+	"cepm-backend/database"
+	"cepm-backend/models"
+
+	"gorm.io/gorm"
+)
+
+type CheckInRepository interface {
+	Create(checkIn *models.ItemCheckIn) error
+	GetByID(id uint) (*models.ItemCheckIn, error)
+	ListByReviewID(reviewID uint, itemID *uint) ([]models.ItemCheckIn, error)
+	Reply(checkIn *models.ItemCheckIn) error
+}
+
+type dbCheckInRepository struct {
+	db *gorm.DB
+}
+
+func NewCheckInRepository() CheckInRepository {
+	return &dbCheckInRepository{db: database.DB}
+}
+
+// Create stores a check-in and copies its progress onto the item in a single transaction, unless
+// the item already has a later check-in (a backdated check-in does not replace the latest progress).
+func (r *dbCheckInRepository) Create(checkIn *models.ItemCheckIn) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Author", "RepliedBy").Create(checkIn).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.PerformanceItem{}).
+			Where("id = ? AND (latest_check_in_at IS NULL OR latest_check_in_at <= ?)", checkIn.ItemID, checkIn.CheckInDate).
+			Updates(map[string]interface{}{
+				"latest_progress":    checkIn.PercentComplete,
+				"latest_rag_status":  checkIn.RAGStatus,
+				"latest_check_in_at": checkIn.CheckInDate,
+			}).Error
+	})
+}
+
+func (r *dbCheckInRepository) GetByID(id uint) (*models.ItemCheckIn, error) {
+	var checkIn models.ItemCheckIn
+	if err := r.db.First(&checkIn, id).Error; err != nil {
+		return nil, err
+	}
+	return &checkIn, nil
+}
+
+// ListByReviewID retrieves the check-ins of a review, optionally for a single item, newest first.
+func (r *dbCheckInRepository) ListByReviewID(reviewID uint, itemID *uint) ([]models.ItemCheckIn, error) {
+	var checkIns []models.ItemCheckIn
+	query := r.db.Preload("Author").Preload("RepliedBy").Where("review_id = ?", reviewID)
+	if itemID != nil {
+		query = query.Where("item_id = ?", *itemID)
+	}
+	err := query.Order("check_in_date desc, id desc").Find(&checkIns).Error
+	return checkIns, err
+}
+
+// Reply stores the manager's reply to a check-in.
+func (r *dbCheckInRepository) Reply(checkIn *models.ItemCheckIn) error {
+	return r.db.Model(&models.ItemCheckIn{}).Where("id = ?", checkIn.ID).Updates(map[string]interface{}{
+		"manager_reply": checkIn.ManagerReply,
+		"replied_by_id": checkIn.RepliedByID,
+		"replied_at":    checkIn.RepliedAt,
+	}).Error
+}
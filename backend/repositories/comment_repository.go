@@ -0,0 +1,82 @@
+package repositories
+
+import (
+	"time"
+
+	"cepm-backend/database"
+	"cepm-backend/models"
+
+	"gorm.io/gorm"
+)
+
+type CommentRepository interface {
+	Create(comment *models.ReviewComment) error
+	GetByID(id uint) (*models.ReviewComment, error)
+	ListByReviewID(reviewID uint, itemID *uint, visibilities []string) ([]models.ReviewComment, error)
+	UpdateContent(comment *models.ReviewComment, edit *models.ReviewCommentEdit) error
+	SoftDelete(id uint, deletedByID uint) error
+}
+
+type dbCommentRepository struct {
+	db *gorm.DB
+}
+
+func NewCommentRepository() CommentRepository {
+	return &dbCommentRepository{db: database.DB}
+}
+
+// Create creates a comment together with its mentions.
+func (r *dbCommentRepository) Create(comment *models.ReviewComment) error {
+	return r.db.Omit("Author", "Mentions.*").Create(comment).Error
+}
+
+// GetByID retrieves a single comment with its mentions and edit history.
+func (r *dbCommentRepository) GetByID(id uint) (*models.ReviewComment, error) {
+	var comment models.ReviewComment
+	err := r.db.Preload("Author").Preload("Mentions").
+		Preload("Edits", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at asc")
+		}).
+		First(&comment, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+// ListByReviewID retrieves the comments of a review with the given visibilities, oldest first,
+// optionally limited to one item.
+func (r *dbCommentRepository) ListByReviewID(reviewID uint, itemID *uint, visibilities []string) ([]models.ReviewComment, error) {
+	var comments []models.ReviewComment
+	query := r.db.Preload("Author").Preload("Mentions").Where("review_id = ? AND visibility IN ?", reviewID, visibilities)
+	if itemID != nil {
+		query = query.Where("item_id = ?", *itemID)
+	}
+	err := query.Order("created_at asc, id asc").Find(&comments).Error
+	return comments, err
+}
+
+// UpdateContent records the previous content in the edit history and saves the new content and
+// mentions in a single transaction.
+func (r *dbCommentRepository) UpdateContent(comment *models.ReviewComment, edit *models.ReviewCommentEdit) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(edit).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.ReviewComment{}).Where("id = ?", comment.ID).Updates(map[string]interface{}{
+			"content":   comment.Content,
+			"edited_at": comment.EditedAt,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Model(comment).Association("Mentions").Replace(comment.Mentions)
+	})
+}
+
+// SoftDelete marks a comment as deleted. It is kept so its replies stay in the thread.
+func (r *dbCommentRepository) SoftDelete(id uint, deletedByID uint) error {
+	return r.db.Model(&models.ReviewComment{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"deleted_at":    time.Now(),
+		"deleted_by_id": deletedByID,
+	}).Error
+}
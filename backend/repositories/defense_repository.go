@@ -0,0 +1,97 @@
+package repositories
+
+import (
+	"time"
+
+	"cepm-backend/database"
+	"cepm-backend/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type DefenseRepository interface {
+	Create(session *models.DefenseSession) error
+	GetByID(id uint) (*models.DefenseSession, error)
+	List(period string) ([]models.DefenseSession, error)
+	ListByPanelistID(panelistID uint) ([]models.DefenseSession, error)
+	SaveScore(score *models.DefenseScore) error
+	Close(sessionID uint, finalScores map[uint]*float64, reviewUpdates map[uint]map[string]interface{}) error
+}
+
+type dbDefenseRepository struct {
+	db *gorm.DB
+}
+
+func NewDefenseRepository() DefenseRepository {
+	return &dbDefenseRepository{db: database.DB}
+}
+
+// Create creates a session together with its panelists and candidates.
+func (r *dbDefenseRepository) Create(session *models.DefenseSession) error {
+	return r.db.Create(session).Error
+}
+
+// GetByID retrieves a single session with its panel, candidates and scores preloaded.
+func (r *dbDefenseRepository) GetByID(id uint) (*models.DefenseSession, error) {
+	var session models.DefenseSession
+	err := r.db.Preload("Panelists").Preload("CreatedBy").
+		Preload("Candidates.User.Department").Preload("Candidates.Scores.Panelist").
+		First(&session, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// List retrieves all sessions, optionally filtered by period.
+func (r *dbDefenseRepository) List(period string) ([]models.DefenseSession, error) {
+	var sessions []models.DefenseSession
+	query := r.db.Preload("Panelists").Preload("Candidates.User")
+	if period != "" {
+		query = query.Where("period = ?", period)
+	}
+	err := query.Order("scheduled_at desc").Find(&sessions).Error
+	return sessions, err
+}
+
+// ListByPanelistID retrieves the sessions a user sits on the panel of.
+func (r *dbDefenseRepository) ListByPanelistID(panelistID uint) ([]models.DefenseSession, error) {
+	var sessions []models.DefenseSession
+	err := r.db.Preload("Panelists").Preload("Candidates.User").
+		Where("id IN (?)", r.db.Table("defense_session_panelists").Select("defense_session_id").Where("user_id = ?", panelistID)).
+		Order("scheduled_at desc").Find(&sessions).Error
+	return sessions, err
+}
+
+// SaveScore creates or replaces a panelist's score for a candidate.
+func (r *dbDefenseRepository) SaveScore(score *models.DefenseScore) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "candidate_id"}, {Name: "panelist_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"criteria_scores", "total_score", "comment", "updated_at"}),
+	}).Create(score).Error
+}
+
+// Close closes a session, stores each candidate's final score and attaches the results
+// to the candidates' reviews in a single transaction.
+func (r *dbDefenseRepository) Close(sessionID uint, finalScores map[uint]*float64, reviewUpdates map[uint]map[string]interface{}) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.DefenseSession{}).Where("id = ?", sessionID).Updates(map[string]interface{}{
+			"status":    "已结束",
+			"closed_at": time.Now(),
+		}).Error; err != nil {
+			return err
+		}
+		for candidateID, finalScore := range finalScores {
+			if err := tx.Model(&models.DefenseCandidate{}).Where("id = ?", candidateID).Update("final_score", finalScore).Error; err != nil {
+				return err
+			}
+		}
+		for reviewID, updates := range reviewUpdates {
+			if err := tx.Model(&models.PerformanceReview{}).Where("id = ?", reviewID).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
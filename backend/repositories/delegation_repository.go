@@ -0,0 +1,79 @@
+package repositories
+
+import (
+	"time"
+
+	"cepm-backend/database"
+	"cepm-backend/models"
+
+	"gorm.io/gorm"
+)
+
+type DelegationRepository interface {
+	Create(delegation *models.ApprovalDelegation) error
+	GetByID(id uint) (*models.ApprovalDelegation, error)
+	ListByDelegatorID(delegatorID uint) ([]models.ApprovalDelegation, error)
+	ListByDelegateID(delegateID uint) ([]models.ApprovalDelegation, error)
+	FindActive(delegatorID uint, delegateID uint, at time.Time) ([]models.ApprovalDelegation, error)
+	FindActiveByDelegateID(delegateID uint, at time.Time) ([]models.ApprovalDelegation, error)
+	Revoke(id uint) error
+}
+
+type dbDelegationRepository struct {
+	db *gorm.DB
+}
+
+func NewDelegationRepository() DelegationRepository {
+	return &dbDelegationRepository{db: database.DB}
+}
+
+func (r *dbDelegationRepository) Create(delegation *models.ApprovalDelegation) error {
+	return r.db.Create(delegation).Error
+}
+
+// GetByID retrieves a single delegation with its scoped reports preloaded.
+func (r *dbDelegationRepository) GetByID(id uint) (*models.ApprovalDelegation, error) {
+	var delegation models.ApprovalDelegation
+	err := r.db.Preload("Delegator").Preload("Delegate").Preload("Reports").First(&delegation, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &delegation, nil
+}
+
+// ListByDelegatorID retrieves all delegations created by a given user.
+func (r *dbDelegationRepository) ListByDelegatorID(delegatorID uint) ([]models.ApprovalDelegation, error) {
+	var delegations []models.ApprovalDelegation
+	err := r.db.Preload("Delegate").Preload("Department").Preload("Reports").Where("delegator_id = ?", delegatorID).Order("start_date desc").Find(&delegations).Error
+	return delegations, err
+}
+
+// ListByDelegateID retrieves all delegations granted to a given user.
+func (r *dbDelegationRepository) ListByDelegateID(delegateID uint) ([]models.ApprovalDelegation, error) {
+	var delegations []models.ApprovalDelegation
+	err := r.db.Preload("Delegator").Preload("Department").Preload("Reports").Where("delegate_id = ?", delegateID).Order("start_date desc").Find(&delegations).Error
+	return delegations, err
+}
+
+// FindActive retrieves the non-revoked delegations from delegator to delegate that cover the given time.
+func (r *dbDelegationRepository) FindActive(delegatorID uint, delegateID uint, at time.Time) ([]models.ApprovalDelegation, error) {
+	var delegations []models.ApprovalDelegation
+	err := r.db.Preload("Reports").
+		Where("delegator_id = ? AND delegate_id = ? AND is_revoked = ? AND start_date <= ? AND end_date >= ?", delegatorID, delegateID, false, at, at).
+		Find(&delegations).Error
+	return delegations, err
+}
+
+// FindActiveByDelegateID retrieves all non-revoked delegations granted to a user that cover the given time.
+func (r *dbDelegationRepository) FindActiveByDelegateID(delegateID uint, at time.Time) ([]models.ApprovalDelegation, error) {
+	var delegations []models.ApprovalDelegation
+	err := r.db.Preload("Reports").
+		Where("delegate_id = ? AND is_revoked = ? AND start_date <= ? AND end_date >= ?", delegateID, false, at, at).
+		Find(&delegations).Error
+	return delegations, err
+}
+
+// Revoke marks a delegation as revoked so it no longer grants approval rights.
+func (r *dbDelegationRepository) Revoke(id uint) error {
+	return r.db.Model(&models.ApprovalDelegation{}).Where("id = ?", id).Update("is_revoked", true).Error
+}
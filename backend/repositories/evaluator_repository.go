@@ -0,0 +1,99 @@
+package repositories
+
+import (
+	"cepm-backend/database"
+	"cepm-backend/models"
+
+	"gorm.io/gorm"
+)
+
+type EvaluatorRepository interface {
+	ListByReviewID(reviewID uint) ([]models.ReviewEvaluator, error)
+	ListPendingByEvaluatorID(evaluatorID uint) ([]models.ReviewEvaluator, error)
+	ReviewIDsWithEvaluators(reviewIDs []uint) (map[uint]bool, error)
+	ReplaceForReview(reviewID uint, evaluators []models.ReviewEvaluator) error
+	SaveEvaluation(evaluator *models.ReviewEvaluator, scores []models.EvaluatorItemScore, approval *models.ApprovalHistory) error
+}
+
+type dbEvaluatorRepository struct {
+	db *gorm.DB
+}
+
+func NewEvaluatorRepository() EvaluatorRepository {
+	return &dbEvaluatorRepository{db: database.DB}
+}
+
+// ListByReviewID retrieves the evaluators of a review with their item scores, highest weight first.
+func (r *dbEvaluatorRepository) ListByReviewID(reviewID uint) ([]models.ReviewEvaluator, error) {
+	var evaluators []models.ReviewEvaluator
+	err := r.db.Preload("Evaluator").Preload("Scores").Where("review_id = ?", reviewID).Order("weight desc, id asc").Find(&evaluators).Error
+	return evaluators, err
+}
+
+// ListPendingByEvaluatorID retrieves the unsubmitted evaluations of a user on reviews awaiting scoring.
+func (r *dbEvaluatorRepository) ListPendingByEvaluatorID(evaluatorID uint) ([]models.ReviewEvaluator, error) {
+	var evaluators []models.ReviewEvaluator
+	err := r.db.Preload("Review.User.Department").Where("evaluator_id = ? AND submitted_at IS NULL AND review_id IN (?)",
+		evaluatorID, r.db.Model(&models.PerformanceReview{}).Select("id").Where("status = ?", "待打分")).
+		Order("created_at asc").Find(&evaluators).Error
+	return evaluators, err
+}
+
+// ReviewIDsWithEvaluators reports which of the given reviews have evaluators assigned.
+func (r *dbEvaluatorRepository) ReviewIDsWithEvaluators(reviewIDs []uint) (map[uint]bool, error) {
+	result := make(map[uint]bool)
+	if len(reviewIDs) == 0 {
+		return result, nil
+	}
+	var ids []uint
+	if err := r.db.Model(&models.ReviewEvaluator{}).Distinct("review_id").Where("review_id IN ?", reviewIDs).Pluck("review_id", &ids).Error; err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		result[id] = true
+	}
+	return result, nil
+}
+
+// ReplaceForReview replaces all evaluator assignments of a review in a single transaction.
+func (r *dbEvaluatorRepository) ReplaceForReview(reviewID uint, evaluators []models.ReviewEvaluator) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("review_evaluator_id IN (?)", tx.Model(&models.ReviewEvaluator{}).Select("id").Where("review_id = ?", reviewID)).
+			Delete(&models.EvaluatorItemScore{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("review_id = ?", reviewID).Delete(&models.ReviewEvaluator{}).Error; err != nil {
+			return err
+		}
+		if len(evaluators) == 0 {
+			return nil
+		}
+		return tx.Omit("Evaluator", "Review").Create(&evaluators).Error
+	})
+}
+
+// SaveEvaluation stores an evaluator's submission, replacing any earlier item scores, and records
+// the submission in the approval history in a single transaction.
+func (r *dbEvaluatorRepository) SaveEvaluation(evaluator *models.ReviewEvaluator, scores []models.EvaluatorItemScore, approval *models.ApprovalHistory) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.ReviewEvaluator{}).Where("id = ?", evaluator.ID).Updates(map[string]interface{}{
+			"final_comment": evaluator.FinalComment,
+			"submitted_at":  evaluator.SubmittedAt,
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("review_evaluator_id = ?", evaluator.ID).Delete(&models.EvaluatorItemScore{}).Error; err != nil {
+			return err
+		}
+		for i := range scores {
+			scores[i].ReviewEvaluatorID = evaluator.ID
+		}
+		if len(scores) > 0 {
+			if err := tx.Create(&scores).Error; err != nil {
+				return err
+			}
+		}
+		approval.ReviewID = evaluator.ReviewID
+		return tx.Create(approval).Error
+	})
+}
@@ -0,0 +1,49 @@
+package repositories
+
+import (
+	"cepm-backend/database"
+	"cepm-backend/models"
+
+	"gorm.io/gorm"
+)
+
+type ExportRepository interface {
+	GetReview(id uint) (*models.PerformanceReview, error)
+	ListReviews(period string, departmentIDs []uint) ([]models.PerformanceReview, error)
+}
+
+type dbExportRepository struct {
+	db *gorm.DB
+}
+
+func NewExportRepository() ExportRepository {
+	return &dbExportRepository{db: database.DB}
+}
+
+// withFormDetails preloads what the 月度绩效考核表 shows: the items in order, the employee and their
+// manager with their departments, and the approval trail.
+func withFormDetails(db *gorm.DB) *gorm.DB {
+	return db.Preload("Items", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id asc")
+	}).Preload("User.Department").Preload("User.Manager.Department").
+		Preload("Approvals", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at asc, id asc")
+		}).Preload("Approvals.Approver").Preload("Approvals.OnBehalfOf").Preload("HRConfirmedBy")
+}
+
+func (r *dbExportRepository) GetReview(id uint) (*models.PerformanceReview, error) {
+	var review models.PerformanceReview
+	if err := withFormDetails(r.db).First(&review, id).Error; err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
+
+// ListReviews retrieves the non-draft reviews of a period for the users in the given departments, ordered by user.
+func (r *dbExportRepository) ListReviews(period string, departmentIDs []uint) ([]models.PerformanceReview, error) {
+	var reviews []models.PerformanceReview
+	err := withFormDetails(r.db).Where("period = ? AND status != ? AND user_id IN (?)",
+		period, "草稿", r.db.Model(&models.User{}).Select("id").Where("department_id IN ?", departmentIDs)).
+		Order("user_id asc").Find(&reviews).Error
+	return reviews, err
+}
@@ -0,0 +1,106 @@
+package repositories
+
+import (
+	"cepm-backend/database"
+	"cepm-backend/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ReviewImport is a review created or updated by a workbook import.
+type ReviewImport struct {
+	Review       *models.PerformanceReview // New when ID is 0
+	ReplaceItems bool                      // Replace the items of an existing review; otherwise only the completion details and scores of its items are updated
+	Approval     *models.ApprovalHistory
+	Cycle        *models.ReviewCycle // The review's cycle; a monthly cycle that does not exist yet has ID 0 and is created on commit
+}
+
+type ImportRepository interface {
+	Commit(imports []ReviewImport) error
+}
+
+type dbImportRepository struct {
+	db *gorm.DB
+}
+
+func NewImportRepository() ImportRepository {
+	return &dbImportRepository{db: database.DB}
+}
+
+// Commit saves all imported reviews, and the monthly cycles they need, in a single transaction.
+func (r *dbImportRepository) Commit(imports []ReviewImport) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		created := make(map[string]*models.ReviewCycle)
+		for _, imp := range imports {
+			review := imp.Review
+			if imp.Cycle != nil {
+				if err := createCycleOnce(tx, imp.Cycle, created); err != nil {
+					return err
+				}
+				review.CycleID = &imp.Cycle.ID
+			}
+			switch {
+			case review.ID == 0:
+				if err := tx.Omit("User", "Cycle", "HRConfirmedBy").Create(review).Error; err != nil {
+					return err
+				}
+			case imp.ReplaceItems:
+				if err := tx.Where("review_id = ?", review.ID).Delete(&models.PerformanceItem{}).Error; err != nil {
+					return err
+				}
+				for i := range review.Items {
+					review.Items[i].ReviewID = review.ID
+				}
+				if err := tx.Create(&review.Items).Error; err != nil {
+					return err
+				}
+				if err := tx.Omit(clause.Associations).Save(review).Error; err != nil {
+					return err
+				}
+			default:
+				for _, item := range review.Items {
+					if err := tx.Model(&models.PerformanceItem{}).Where("id = ?", item.ID).Updates(map[string]interface{}{
+						"completion_details": item.CompletionDetails,
+						"score":              item.Score,
+					}).Error; err != nil {
+						return err
+					}
+				}
+				if err := tx.Omit(clause.Associations).Save(review).Error; err != nil {
+					return err
+				}
+			}
+
+			if imp.Approval != nil {
+				imp.Approval.ReviewID = review.ID
+				if err := tx.Create(imp.Approval).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// createCycleOnce creates a cycle that does not exist yet, reusing one created earlier in the import
+// or concurrently by another request.
+func createCycleOnce(tx *gorm.DB, cycle *models.ReviewCycle, created map[string]*models.ReviewCycle) error {
+	if cycle.ID != 0 {
+		return nil
+	}
+	if existing, ok := created[cycle.Code]; ok {
+		*cycle = *existing
+		return nil
+	}
+	if err := tx.Omit(clause.Associations).Clauses(clause.OnConflict{DoNothing: true}).Create(cycle).Error; err != nil {
+		return err
+	}
+	if cycle.ID == 0 {
+		if err := tx.Where("code = ?", cycle.Code).First(cycle).Error; err != nil {
+			return err
+		}
+	}
+	created[cycle.Code] = cycle
+	return nil
+}
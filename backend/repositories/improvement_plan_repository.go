@@ -0,0 +1,147 @@
+package repositories
+
+import (
+	"cepm-backend/database"
+	"cepm-backend/models"
+
+	"gorm.io/gorm"
+)
+
+type ImprovementPlanRepository interface {
+	Create(plan *models.ImprovementPlan, event *models.ImprovementPlanEvent) error
+	GetByID(id uint) (*models.ImprovementPlan, error)
+	FindOpenByUserID(userID uint) (*models.ImprovementPlan, error)
+	List(status string, ownerID *uint, userID *uint) ([]models.ImprovementPlan, error)
+	UpdateDraft(plan *models.ImprovementPlan) error
+	UpdateStatus(plan *models.ImprovementPlan, event *models.ImprovementPlanEvent) error
+	CompleteCheckIn(checkIn *models.ImprovementCheckIn) error
+}
+
+type dbImprovementPlanRepository struct {
+	db *gorm.DB
+}
+
+func NewImprovementPlanRepository() ImprovementPlanRepository {
+	return &dbImprovementPlanRepository{db: database.DB}
+}
+
+// Create creates a plan with its trigger reviews, goals and check-ins, and records its first event.
+func (r *dbImprovementPlanRepository) Create(plan *models.ImprovementPlan, event *models.ImprovementPlanEvent) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("User", "Owner", "TriggerReviews.*").Create(plan).Error; err != nil {
+			return err
+		}
+		event.PlanID = plan.ID
+		return tx.Create(event).Error
+	})
+}
+
+// GetByID retrieves a single plan with its goals, check-ins, trigger reviews and history.
+func (r *dbImprovementPlanRepository) GetByID(id uint) (*models.ImprovementPlan, error) {
+	var plan models.ImprovementPlan
+	err := r.db.Preload("User.Department").Preload("Owner").Preload("TriggerReviews").
+		Preload("Goals", func(db *gorm.DB) *gorm.DB {
+			return db.Order("id asc")
+		}).
+		Preload("CheckIns", func(db *gorm.DB) *gorm.DB {
+			return db.Order("scheduled_date asc")
+		}).
+		Preload("Events", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at asc")
+		}).Preload("Events.Actor").
+		First(&plan, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &plan, nil
+}
+
+// FindOpenByUserID retrieves the user's draft or active plan, if any.
+func (r *dbImprovementPlanRepository) FindOpenByUserID(userID uint) (*models.ImprovementPlan, error) {
+	var plan models.ImprovementPlan
+	err := r.db.Where("user_id = ? AND status IN ?", userID, []string{"草稿", "进行中"}).First(&plan).Error
+	if err != nil {
+		return nil, err // Can be gorm.ErrRecordNotFound
+	}
+	return &plan, nil
+}
+
+// List retrieves plans, optionally filtered by status, owner and employee.
+func (r *dbImprovementPlanRepository) List(status string, ownerID *uint, userID *uint) ([]models.ImprovementPlan, error) {
+	var plans []models.ImprovementPlan
+	query := r.db.Preload("User.Department").Preload("Owner")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if ownerID != nil {
+		query = query.Where("owner_id = ?", *ownerID)
+	}
+	if userID != nil {
+		query = query.Where("user_id = ?", *userID)
+	}
+	err := query.Order("created_at desc").Find(&plans).Error
+	return plans, err
+}
+
+// UpdateDraft updates a draft plan's owner and dates and replaces its goals and check-ins.
+func (r *dbImprovementPlanRepository) UpdateDraft(plan *models.ImprovementPlan) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.ImprovementPlan{}).Where("id = ?", plan.ID).Updates(map[string]interface{}{
+			"owner_id":   plan.OwnerID,
+			"start_date": plan.StartDate,
+			"end_date":   plan.EndDate,
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("plan_id = ?", plan.ID).Delete(&models.ImprovementGoal{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("plan_id = ?", plan.ID).Delete(&models.ImprovementCheckIn{}).Error; err != nil {
+			return err
+		}
+		for i := range plan.Goals {
+			plan.Goals[i].PlanID = plan.ID
+		}
+		for i := range plan.CheckIns {
+			plan.CheckIns[i].PlanID = plan.ID
+		}
+		if len(plan.Goals) > 0 {
+			if err := tx.Create(&plan.Goals).Error; err != nil {
+				return err
+			}
+		}
+		if len(plan.CheckIns) > 0 {
+			if err := tx.Create(&plan.CheckIns).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// UpdateStatus changes a plan's status and outcome, stores its goal results and records the event.
+func (r *dbImprovementPlanRepository) UpdateStatus(plan *models.ImprovementPlan, event *models.ImprovementPlanEvent) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.ImprovementPlan{}).Where("id = ?", plan.ID).Updates(map[string]interface{}{
+			"status":          plan.Status,
+			"outcome_comment": plan.OutcomeComment,
+		}).Error; err != nil {
+			return err
+		}
+		for _, goal := range plan.Goals {
+			if err := tx.Model(&models.ImprovementGoal{}).Where("id = ?", goal.ID).Update("is_met", goal.IsMet).Error; err != nil {
+				return err
+			}
+		}
+		event.PlanID = plan.ID
+		return tx.Create(event).Error
+	})
+}
+
+// CompleteCheckIn records the notes of a held check-in.
+func (r *dbImprovementPlanRepository) CompleteCheckIn(checkIn *models.ImprovementCheckIn) error {
+	return r.db.Model(&models.ImprovementCheckIn{}).Where("id = ?", checkIn.ID).Updates(map[string]interface{}{
+		"completed_at": checkIn.CompletedAt,
+		"notes":        checkIn.Notes,
+	}).Error
+}
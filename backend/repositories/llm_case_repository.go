@@ -0,0 +1,181 @@
+package repositories
+
+import (
+	"strings"
+
+	"cepm-backend/database"
+	"cepm-backend/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// LLMCaseFilter defines the search conditions for the case library.
+type LLMCaseFilter struct {
+	Keyword      string
+	Tool         string
+	DepartmentID *uint
+	Period       string
+	// ReviewStatuses limits the library to cases whose review is in one of these statuses
+	ReviewStatuses []string
+	SortBy         string // recent (default), likes, time_saved
+	Offset         int
+	Limit          int
+}
+
+type LLMCaseRepository interface {
+	Create(llmCase *models.LLMCase) error
+	GetByID(id uint) (*models.LLMCase, error)
+	Update(llmCase *models.LLMCase) error
+	Delete(id uint) error
+	ListByReviewID(reviewID uint) ([]models.LLMCase, error)
+	Search(filter *LLMCaseFilter) ([]models.LLMCase, int64, error)
+	ListByPeriod(period string, reviewStatuses []string) ([]models.LLMCase, error)
+	Like(caseID uint, userID uint) error
+	Unlike(caseID uint, userID uint) error
+	LikedCaseIDs(userID uint, caseIDs []uint) (map[uint]bool, error)
+}
+
+type dbLLMCaseRepository struct {
+	db *gorm.DB
+}
+
+func NewLLMCaseRepository() LLMCaseRepository {
+	return &dbLLMCaseRepository{db: database.DB}
+}
+
+func (r *dbLLMCaseRepository) Create(llmCase *models.LLMCase) error {
+	return r.db.Omit("Author", "Department").Create(llmCase).Error
+}
+
+func (r *dbLLMCaseRepository) GetByID(id uint) (*models.LLMCase, error) {
+	var llmCase models.LLMCase
+	if err := r.db.Preload("Author").Preload("Department").First(&llmCase, id).Error; err != nil {
+		return nil, err
+	}
+	return &llmCase, nil
+}
+
+// Update saves the editable fields of a case.
+func (r *dbLLMCaseRepository) Update(llmCase *models.LLMCase) error {
+	return r.db.Model(&models.LLMCase{}).Where("id = ?", llmCase.ID).Updates(map[string]interface{}{
+		"scenario":         llmCase.Scenario,
+		"tool":             llmCase.Tool,
+		"time_saved_hours": llmCase.TimeSavedHours,
+		"outcome":          llmCase.Outcome,
+		"reflection":       llmCase.Reflection,
+	}).Error
+}
+
+// Delete removes a case and its likes in a single transaction.
+func (r *dbLLMCaseRepository) Delete(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("case_id = ?", id).Delete(&models.LLMCaseLike{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.LLMCase{}, id).Error
+	})
+}
+
+// ListByReviewID retrieves the cases attached to a review, oldest first.
+func (r *dbLLMCaseRepository) ListByReviewID(reviewID uint) ([]models.LLMCase, error) {
+	var cases []models.LLMCase
+	err := r.db.Preload("Author").Where("review_id = ?", reviewID).Order("id").Find(&cases).Error
+	return cases, err
+}
+
+// Search retrieves a page of the case library together with the total number of matching cases.
+func (r *dbLLMCaseRepository) Search(filter *LLMCaseFilter) ([]models.LLMCase, int64, error) {
+	query := r.db.Model(&models.LLMCase{})
+	if filter.Keyword != "" {
+		pattern := "%" + escapeLike(filter.Keyword) + "%"
+		query = query.Where("scenario ILIKE ? OR tool ILIKE ? OR outcome ILIKE ? OR reflection ILIKE ?", pattern, pattern, pattern, pattern)
+	}
+	if filter.Tool != "" {
+		query = query.Where("tool = ?", filter.Tool)
+	}
+	if filter.DepartmentID != nil {
+		query = query.Where("department_id = ?", *filter.DepartmentID)
+	}
+	if filter.Period != "" {
+		query = query.Where("period = ?", filter.Period)
+	}
+	if len(filter.ReviewStatuses) > 0 {
+		reviewIDs := r.db.Model(&models.PerformanceReview{}).Select("id").Where("status IN ?", filter.ReviewStatuses)
+		query = query.Where("review_id IN (?)", reviewIDs)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	order := "created_at desc, id desc"
+	switch filter.SortBy {
+	case "likes":
+		order = "like_count desc, id desc"
+	case "time_saved":
+		order = "time_saved_hours desc, id desc"
+	}
+	var cases []models.LLMCase
+	err := query.Preload("Author").Preload("Department").Order(order).Offset(filter.Offset).Limit(filter.Limit).Find(&cases).Error
+	return cases, total, err
+}
+
+// ListByPeriod retrieves all cases, optionally for a single period, whose reviews are in one of the given statuses.
+func (r *dbLLMCaseRepository) ListByPeriod(period string, reviewStatuses []string) ([]models.LLMCase, error) {
+	var cases []models.LLMCase
+	query := r.db.Model(&models.LLMCase{})
+	if period != "" {
+		query = query.Where("period = ?", period)
+	}
+	if len(reviewStatuses) > 0 {
+		reviewIDs := r.db.Model(&models.PerformanceReview{}).Select("id").Where("status IN ?", reviewStatuses)
+		query = query.Where("review_id IN (?)", reviewIDs)
+	}
+	err := query.Find(&cases).Error
+	return cases, err
+}
+
+// Like records a user's like and increments the case's like count. Liking twice has no effect.
+func (r *dbLLMCaseRepository) Like(caseID uint, userID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.LLMCaseLike{CaseID: caseID, UserID: userID})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return tx.Model(&models.LLMCase{}).Where("id = ?", caseID).
+			UpdateColumn("like_count", gorm.Expr("like_count + 1")).Error
+	})
+}
+
+// Unlike removes a user's like and decrements the case's like count.
+func (r *dbLLMCaseRepository) Unlike(caseID uint, userID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("case_id = ? AND user_id = ?", caseID, userID).Delete(&models.LLMCaseLike{})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return tx.Model(&models.LLMCase{}).Where("id = ? AND like_count > 0", caseID).
+			UpdateColumn("like_count", gorm.Expr("like_count - 1")).Error
+	})
+}
+
+// LikedCaseIDs returns which of the given cases the user has liked.
+func (r *dbLLMCaseRepository) LikedCaseIDs(userID uint, caseIDs []uint) (map[uint]bool, error) {
+	liked := make(map[uint]bool)
+	if len(caseIDs) == 0 {
+		return liked, nil
+	}
+	var ids []uint
+	err := r.db.Model(&models.LLMCaseLike{}).Where("user_id = ? AND case_id IN ?", userID, caseIDs).Pluck("case_id", &ids).Error
+	for _, id := range ids {
+		liked[id] = true
+	}
+	return liked, err
+}
+
+// escapeLike escapes the wildcard characters of a LIKE pattern.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
@@ -0,0 +1,48 @@
+package repositories
+
+import (
+	"cepm-backend/database"
+	"cepm-backend/models"
+
+	"gorm.io/gorm"
+)
+
+type NormalizationRepository interface {
+	ApplyRun(run *models.NormalizationRun, reviewUpdates map[uint]map[string]interface{}) error
+	ListByPeriod(period string) ([]models.NormalizationRun, error)
+}
+
+type dbNormalizationRepository struct {
+	db *gorm.DB
+}
+
+func NewNormalizationRepository() NormalizationRepository {
+	return &dbNormalizationRepository{db: database.DB}
+}
+
+// ApplyRun records a normalization run and applies the per-review updates in a single transaction.
+func (r *dbNormalizationRepository) ApplyRun(run *models.NormalizationRun, reviewUpdates map[uint]map[string]interface{}) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(run).Error; err != nil {
+			return err
+		}
+		for reviewID, updates := range reviewUpdates {
+			updates["normalization_run_id"] = run.ID
+			if err := tx.Model(&models.PerformanceReview{}).Where("id = ?", reviewID).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ListByPeriod retrieves all normalization runs, optionally filtered by period.
+func (r *dbNormalizationRepository) ListByPeriod(period string) ([]models.NormalizationRun, error) {
+	var runs []models.NormalizationRun
+	query := r.db.Preload("AppliedBy")
+	if period != "" {
+		query = query.Where("period = ?", period)
+	}
+	err := query.Order("created_at desc").Find(&runs).Error
+	return runs, err
+}
@@ -0,0 +1,64 @@
+package repositories
+
+import (
+	"cepm-backend/database"
+	"cepm-backend/models"
+
+	"gorm.io/gorm"
+)
+
+type PayrollRepository interface {
+	ListReviews(period string, departmentIDs []uint) ([]models.PerformanceReview, error)
+	CreateBatch(batch *models.PayrollExportBatch) error
+	GetBatch(id uint) (*models.PayrollExportBatch, error)
+	ListBatches(period string) ([]models.PayrollExportBatch, error)
+}
+
+type dbPayrollRepository struct {
+	db *gorm.DB
+}
+
+func NewPayrollRepository() PayrollRepository {
+	return &dbPayrollRepository{db: database.DB}
+}
+
+// ListReviews retrieves the non-draft reviews of a period, ordered by user. A nil departmentIDs covers
+// every department.
+func (r *dbPayrollRepository) ListReviews(period string, departmentIDs []uint) ([]models.PerformanceReview, error) {
+	var reviews []models.PerformanceReview
+	query := r.db.Preload("User.Department").Where("period = ? AND status != ?", period, "草稿")
+	if departmentIDs != nil {
+		query = query.Where("user_id IN (?)", r.db.Model(&models.User{}).Select("id").Where("department_id IN ?", departmentIDs))
+	}
+	err := query.Order("user_id asc").Find(&reviews).Error
+	return reviews, err
+}
+
+// CreateBatch records a batch with its lines. Batches are never updated afterwards.
+func (r *dbPayrollRepository) CreateBatch(batch *models.PayrollExportBatch) error {
+	return r.db.Create(batch).Error
+}
+
+// GetBatch retrieves a single batch with its lines preloaded.
+func (r *dbPayrollRepository) GetBatch(id uint) (*models.PayrollExportBatch, error) {
+	var batch models.PayrollExportBatch
+	err := r.db.Preload("Department").Preload("ExportedBy").
+		Preload("Lines", func(db *gorm.DB) *gorm.DB {
+			return db.Order("id asc")
+		}).First(&batch, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+// ListBatches retrieves all batches without their lines or files, optionally filtered by period.
+func (r *dbPayrollRepository) ListBatches(period string) ([]models.PayrollExportBatch, error) {
+	var batches []models.PayrollExportBatch
+	query := r.db.Omit("Content").Preload("Department").Preload("ExportedBy")
+	if period != "" {
+		query = query.Where("period = ?", period)
+	}
+	err := query.Order("created_at desc").Find(&batches).Error
+	return batches, err
+}
@@ -0,0 +1,88 @@
+package repositories
+
+import (
+	"cepm-backend/database"
+	"cepm-backend/models"
+
+	"gorm.io/gorm"
+)
+
+type PeerFeedbackRepository interface {
+	CreateRequests(requests []models.PeerFeedbackRequest) error
+	GetByID(id uint) (*models.PeerFeedbackRequest, error)
+	ListByReviewID(reviewID uint) ([]models.PeerFeedbackRequest, error)
+	ListPendingByPeerID(peerID uint) ([]models.PeerFeedbackRequest, error)
+	Submit(request *models.PeerFeedbackRequest, answers []models.PeerFeedbackAnswer) error
+	UpdateSuggestedScores(suggestions map[uint]*float64) error
+	Decline(id uint) error
+}
+
+type dbPeerFeedbackRepository struct {
+	db *gorm.DB
+}
+
+func NewPeerFeedbackRepository() PeerFeedbackRepository {
+	return &dbPeerFeedbackRepository{db: database.DB}
+}
+
+func (r *dbPeerFeedbackRepository) CreateRequests(requests []models.PeerFeedbackRequest) error {
+	return r.db.Omit("Review", "Peer").Create(&requests).Error
+}
+
+// GetByID retrieves a single request with its review items and answers preloaded.
+func (r *dbPeerFeedbackRepository) GetByID(id uint) (*models.PeerFeedbackRequest, error) {
+	var request models.PeerFeedbackRequest
+	err := r.db.Preload("Review.Items").Preload("Review.User").Preload("Peer").Preload("Answers").First(&request, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+// ListByReviewID retrieves all feedback requests of a review with their answers.
+func (r *dbPeerFeedbackRepository) ListByReviewID(reviewID uint) ([]models.PeerFeedbackRequest, error) {
+	var requests []models.PeerFeedbackRequest
+	err := r.db.Preload("Peer").Preload("Answers").Where("review_id = ?", reviewID).Order("created_at asc").Find(&requests).Error
+	return requests, err
+}
+
+// ListPendingByPeerID retrieves the requests a peer has not answered yet.
+func (r *dbPeerFeedbackRepository) ListPendingByPeerID(peerID uint) ([]models.PeerFeedbackRequest, error) {
+	var requests []models.PeerFeedbackRequest
+	err := r.db.Preload("Review.User.Department").Preload("Review.Items", "category = ?", "价值观").
+		Where("peer_id = ? AND status = ?", peerID, "待填写").Order("created_at asc").Find(&requests).Error
+	return requests, err
+}
+
+// Submit stores a peer's answers and marks the request as submitted in a single transaction.
+func (r *dbPeerFeedbackRepository) Submit(request *models.PeerFeedbackRequest, answers []models.PeerFeedbackAnswer) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.PeerFeedbackRequest{}).Where("id = ?", request.ID).Updates(map[string]interface{}{
+			"status":       request.Status,
+			"submitted_at": request.SubmittedAt,
+		}).Error; err != nil {
+			return err
+		}
+		for i := range answers {
+			answers[i].RequestID = request.ID
+		}
+		return tx.Create(&answers).Error
+	})
+}
+
+// UpdateSuggestedScores sets the peer-suggested score of each given item.
+func (r *dbPeerFeedbackRepository) UpdateSuggestedScores(suggestions map[uint]*float64) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for itemID, score := range suggestions {
+			if err := tx.Model(&models.PerformanceItem{}).Where("id = ?", itemID).Update("peer_suggested_score", score).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Decline marks a request as declined by the peer.
+func (r *dbPeerFeedbackRepository) Decline(id uint) error {
+	return r.db.Model(&models.PeerFeedbackRequest{}).Where("id = ?", id).Update("status", "已拒绝").Error
+}
@@ -0,0 +1,36 @@
+package repositories
+
+import (
+	"time"
+
+	"cepm-backend/database"
+	"cepm-backend/models"
+
+	"gorm.io/gorm"
+)
+
+type ReminderRepository interface {
+	Create(reminder *models.ReviewReminder) error
+	ExistsSince(reviewID uint, reviewStatus string, reminderType string, since time.Time) (bool, error)
+}
+
+type dbReminderRepository struct {
+	db *gorm.DB
+}
+
+func NewReminderRepository() ReminderRepository {
+	return &dbReminderRepository{db: database.DB}
+}
+
+func (r *dbReminderRepository) Create(reminder *models.ReviewReminder) error {
+	return r.db.Create(reminder).Error
+}
+
+// ExistsSince reports whether a reminder of the given type was already sent for the review status after the given time.
+func (r *dbReminderRepository) ExistsSince(reviewID uint, reviewStatus string, reminderType string, since time.Time) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.ReviewReminder{}).
+		Where("review_id = ? AND review_status = ? AND type = ? AND created_at >= ?", reviewID, reviewStatus, reminderType, since).
+		Count(&count).Error
+	return count > 0, err
+}
@@ -0,0 +1,64 @@
+package repositories
+
+import (
+	"cepm-backend/database"
+	"cepm-backend/models"
+
+	"gorm.io/gorm"
+)
+
+type ReviewCycleRepository interface {
+	Create(cycle *models.ReviewCycle) error
+	GetByID(id uint) (*models.ReviewCycle, error)
+	GetByCode(code string) (*models.ReviewCycle, error)
+	List(includeClosed bool) ([]models.ReviewCycle, error)
+	Close(id uint) error
+}
+
+type dbReviewCycleRepository struct {
+	db *gorm.DB
+}
+
+func NewReviewCycleRepository() ReviewCycleRepository {
+	return &dbReviewCycleRepository{db: database.DB}
+}
+
+func (r *dbReviewCycleRepository) Create(cycle *models.ReviewCycle) error {
+	return r.db.Create(cycle).Error
+}
+
+// GetByID retrieves a single review cycle with its scope preloaded.
+func (r *dbReviewCycleRepository) GetByID(id uint) (*models.ReviewCycle, error) {
+	var cycle models.ReviewCycle
+	err := r.db.Preload("Department").Preload("Users").First(&cycle, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &cycle, nil
+}
+
+// GetByCode retrieves a single review cycle by its code.
+func (r *dbReviewCycleRepository) GetByCode(code string) (*models.ReviewCycle, error) {
+	var cycle models.ReviewCycle
+	err := r.db.Preload("Department").Preload("Users").Where("code = ?", code).First(&cycle).Error
+	if err != nil {
+		return nil, err // Can be gorm.ErrRecordNotFound
+	}
+	return &cycle, nil
+}
+
+// List retrieves review cycles, newest first.
+func (r *dbReviewCycleRepository) List(includeClosed bool) ([]models.ReviewCycle, error) {
+	var cycles []models.ReviewCycle
+	query := r.db.Preload("Department").Preload("Users")
+	if !includeClosed {
+		query = query.Where("is_closed = ?", false)
+	}
+	err := query.Order("start_date desc, id desc").Find(&cycles).Error
+	return cycles, err
+}
+
+// Close marks a review cycle as closed so no new reviews can be created for it.
+func (r *dbReviewCycleRepository) Close(id uint) error {
+	return r.db.Model(&models.ReviewCycle{}).Where("id = ?", id).Update("is_closed", true).Error
+}
@@ -1,6 +1,8 @@
 package repositories
 
 import (
+	"fmt"
+
 	"cepm-backend/database"
 	"cepm-backend/models"
 
@@ -13,12 +15,22 @@ type PerformanceReviewRepository interface {
 	ListByUserID(userID uint) ([]models.PerformanceReview, error)
 	ListByManagerID(managerID uint) ([]models.PerformanceReview, error)
 	ListAllSubmittedReviews() ([]models.PerformanceReview, error)
-	UpdateWithItems(review *models.PerformanceReview, items []models.PerformanceItem) error
+	UpdateWithItems(review *models.PerformanceReview, items []models.PerformanceItem, approval *models.ApprovalHistory) error
 	UpdateStatus(reviewID uint, newStatus string) error
 	UpdateStatusAndAddApproval(reviewID uint, newStatus string, approverID uint, comment string) error
+	UpdateStatusAndAddApprovalOnBehalf(reviewID uint, newStatus string, approverID uint, onBehalfOfID *uint, comment string) error
 	GetByUserIDAndPeriod(userID uint, period string) (*models.PerformanceReview, error)
 	Update(review *models.PerformanceReview) error
 	FindAllReviewsByPeriod(period string) ([]models.PerformanceReview, error)
+	ListByStatuses(statuses []string) ([]models.PerformanceReview, error)
+	ListByManagerIDsAndStatuses(managerIDs []uint, statuses []string) ([]models.PerformanceReview, error)
+	ListByUserIDAndStatuses(userID uint, statuses []string) ([]models.PerformanceReview, error)
+	ListEscalatedTo(userID uint, statuses []string) ([]models.PerformanceReview, error)
+	AddApprovalHistory(approval *models.ApprovalHistory) error
+	ListApprovals(reviewID uint) ([]models.ApprovalHistory, error)
+	UpdateFieldsAndAddApproval(reviewID uint, updates map[string]interface{}, approval *models.ApprovalHistory) error
+	ListScoredReviews(period string) ([]models.PerformanceReview, error)
+	ListScoredReviewsByYear(year int, userIDs []uint) ([]models.PerformanceReview, error)
 }
 
 type dbPerformanceReviewRepository struct {
@@ -62,7 +74,10 @@ func (r *dbPerformanceReviewRepository) ListByManagerID(managerID uint) ([]model
 	}
 
 	// Find all reviews for those user IDs, and preload the User info for display, excluding '草稿' status
-	err := r.db.Preload("User").Where("user_id IN ? AND status != ?", userIDs, "草稿").Order("period desc").Find(&reviews).Error
+	// Items carry the latest check-in progress so at-risk goals show in the team view; they are only
+	// loaded for the reviews still in progress, as older periods are not checked in on
+	inProgress := r.db.Model(&models.PerformanceReview{}).Select("id").Where("user_id IN ? AND status = ?", userIDs, "待打分")
+	err := r.db.Preload("User").Preload("Items", "review_id IN (?)", inProgress).Where("user_id IN ? AND status != ?", userIDs, "草稿").Order("period desc").Find(&reviews).Error
 	return reviews, err
 }
 
@@ -70,14 +85,15 @@ func (r *dbPerformanceReviewRepository) ListByManagerID(managerID uint) ([]model
 func (r *dbPerformanceReviewRepository) ListAllSubmittedReviews() ([]models.PerformanceReview, error) {
 	var reviews []models.PerformanceReview
 	// Define statuses that are considered 'submitted' (i.e., not '草稿')
-	submittedStatuses := []string{"待审批", "已批准", "已完成", "待人事确认", "已驳回"}
+	submittedStatuses := []string{"待审批", "已批准", "已完成", "待人事确认", "已归档", "已驳回"}
 
 	err := r.db.Preload("User.Department").Preload("User.Role").Preload("Items").Where("status IN ?", submittedStatuses).Order("period desc, user_id asc").Find(&reviews).Error
 	return reviews, err
 }
 
 // UpdateWithItems updates a review and its associated items in a single transaction.
-func (r *dbPerformanceReviewRepository) UpdateWithItems(review *models.PerformanceReview, items []models.PerformanceItem) error {
+// If approval is not nil, it is added to the review's approval history in the same transaction.
+func (r *dbPerformanceReviewRepository) UpdateWithItems(review *models.PerformanceReview, items []models.PerformanceItem, approval *models.ApprovalHistory) error {
 	return r.db.Transaction(func(tx *gorm.DB) error {
 		// 1. Update each performance item
 		for _, item := range items {
@@ -94,6 +110,14 @@ func (r *dbPerformanceReviewRepository) UpdateWithItems(review *models.Performan
 				return err
 		}
 
+		// 3. Record who performed the update
+		if approval != nil {
+			approval.ReviewID = review.ID
+			if err := tx.Create(approval).Error; err != nil {
+				return err
+			}
+		}
+
 		return nil
 	})
 }
@@ -105,6 +129,12 @@ func (r *dbPerformanceReviewRepository) UpdateStatus(reviewID uint, newStatus st
 
 // UpdateStatusAndAddApproval updates the status of a review and adds an approval history entry.
 func (r *dbPerformanceReviewRepository) UpdateStatusAndAddApproval(reviewID uint, newStatus string, approverID uint, comment string) error {
+	return r.UpdateStatusAndAddApprovalOnBehalf(reviewID, newStatus, approverID, nil, comment)
+}
+
+// UpdateStatusAndAddApprovalOnBehalf is like UpdateStatusAndAddApproval, but also records
+// the manager the approver acted for when the action was taken through a delegation.
+func (r *dbPerformanceReviewRepository) UpdateStatusAndAddApprovalOnBehalf(reviewID uint, newStatus string, approverID uint, onBehalfOfID *uint, comment string) error {
 	return r.db.Transaction(func(tx *gorm.DB) error {
 		// Update review status
 		if err := tx.Model(&models.PerformanceReview{}).Where("id = ?", reviewID).Update("status", newStatus).Error; err != nil {
@@ -113,10 +143,11 @@ func (r *dbPerformanceReviewRepository) UpdateStatusAndAddApproval(reviewID uint
 
 		// Add approval history entry
 		approval := models.ApprovalHistory{
-			ReviewID:   reviewID,
-			ApproverID: approverID,
-			Status:     newStatus, // Use the new status as the approval status
-			Comment:    comment,
+			ReviewID:     reviewID,
+			ApproverID:   approverID,
+			OnBehalfOfID: onBehalfOfID,
+			Status:       newStatus, // Use the new status as the approval status
+			Comment:      comment,
 		}
 		if err := tx.Create(&approval).Error; err != nil {
 			return err
@@ -172,4 +203,102 @@ func (r *dbPerformanceReviewRepository) FindAllReviewsByPeriod(period string) ([
 	var reviews []models.PerformanceReview
 	err := r.db.Preload("User.Department").Preload("User.Role").Preload("Items").Where("period = ? AND status != ?", period, "草稿").Order("user_id asc").Find(&reviews).Error
 	return reviews, err
-}
\ No newline at end of file
+}
+
+// ListByStatuses retrieves all performance reviews in the given statuses,
+// with the user and the approval history (newest first) preloaded.
+func (r *dbPerformanceReviewRepository) ListByStatuses(statuses []string) ([]models.PerformanceReview, error) {
+	var reviews []models.PerformanceReview
+	err := r.preloadForWorkflow(r.db).Where("status IN ?", statuses).Order("id asc").Find(&reviews).Error
+	return reviews, err
+}
+
+// ListByManagerIDsAndStatuses retrieves the reviews in the given statuses of users reporting to any of the given managers.
+func (r *dbPerformanceReviewRepository) ListByManagerIDsAndStatuses(managerIDs []uint, statuses []string) ([]models.PerformanceReview, error) {
+	var reviews []models.PerformanceReview
+	if len(managerIDs) == 0 {
+		return reviews, nil
+	}
+	err := r.preloadForWorkflow(r.db).
+		Where("status IN ? AND user_id IN (?)", statuses, r.db.Model(&models.User{}).Select("id").Where("manager_id IN ?", managerIDs)).
+		Order("id asc").Find(&reviews).Error
+	return reviews, err
+}
+
+// ListByUserIDAndStatuses retrieves a user's own reviews in the given statuses.
+func (r *dbPerformanceReviewRepository) ListByUserIDAndStatuses(userID uint, statuses []string) ([]models.PerformanceReview, error) {
+	var reviews []models.PerformanceReview
+	err := r.preloadForWorkflow(r.db).Where("user_id = ? AND status IN ?", userID, statuses).Order("period desc").Find(&reviews).Error
+	return reviews, err
+}
+
+// ListEscalatedTo retrieves the reviews in the given statuses that the SLA scheduler escalated to a user.
+func (r *dbPerformanceReviewRepository) ListEscalatedTo(userID uint, statuses []string) ([]models.PerformanceReview, error) {
+	var reviews []models.PerformanceReview
+	err := r.preloadForWorkflow(r.db).
+		Where("status IN ? AND id IN (?)", statuses, r.db.Model(&models.ApprovalHistory{}).Select("review_id").Where("approver_id = ? AND status = ?", userID, "已升级")).
+		Order("id asc").Find(&reviews).Error
+	return reviews, err
+}
+
+// preloadForWorkflow preloads what is needed to route a review: its user, their department,
+// and the approval history (newest first).
+func (r *dbPerformanceReviewRepository) preloadForWorkflow(db *gorm.DB) *gorm.DB {
+	return db.Preload("User.Department").Preload("Cycle").Preload("Approvals", func(db *gorm.DB) *gorm.DB {
+		return db.Order("created_at desc")
+	})
+}
+
+// AddApprovalHistory adds an approval history entry without changing the review's status.
+func (r *dbPerformanceReviewRepository) AddApprovalHistory(approval *models.ApprovalHistory) error {
+	return r.db.Create(approval).Error
+}
+
+// ListApprovals retrieves the approval history of a review, newest first.
+func (r *dbPerformanceReviewRepository) ListApprovals(reviewID uint) ([]models.ApprovalHistory, error) {
+	var approvals []models.ApprovalHistory
+	err := r.db.Where("review_id = ?", reviewID).Order("created_at desc").Find(&approvals).Error
+	return approvals, err
+}
+
+// UpdateFieldsAndAddApproval updates the given review columns and adds an approval history entry in a single transaction.
+func (r *dbPerformanceReviewRepository) UpdateFieldsAndAddApproval(reviewID uint, updates map[string]interface{}, approval *models.ApprovalHistory) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.PerformanceReview{}).Where("id = ?", reviewID).Updates(updates).Error; err != nil {
+			return err
+		}
+		approval.ReviewID = reviewID
+		return tx.Create(approval).Error
+	})
+}
+
+// scoredStatuses are the statuses of reviews that have a final score.
+var scoredStatuses = []string{"已完成", "待人事确认", "申诉中", "已归档"}
+
+// ListScoredReviews retrieves the scored reviews of a period (or of all periods if period is empty),
+// with items, the user's department and the approval history (newest first) preloaded.
+func (r *dbPerformanceReviewRepository) ListScoredReviews(period string) ([]models.PerformanceReview, error) {
+	var reviews []models.PerformanceReview
+	query := r.preloadForWorkflow(r.db).Preload("Items").Where("status IN ?", scoredStatuses)
+	if period != "" {
+		query = query.Where("period = ?", period)
+	}
+	err := query.Order("period asc, user_id asc").Find(&reviews).Error
+	return reviews, err
+}
+
+// ListScoredReviewsByYear retrieves the scored reviews of a year, optionally limited to some users.
+// Reviews of a cycle belong to the year the cycle ends in; reviews without a cycle are matched by period.
+func (r *dbPerformanceReviewRepository) ListScoredReviewsByYear(year int, userIDs []uint) ([]models.PerformanceReview, error) {
+	var reviews []models.PerformanceReview
+	yearStart := fmt.Sprintf("%04d-01-01", year)
+	yearEnd := fmt.Sprintf("%04d-12-31", year)
+	query := r.db.Preload("User").Preload("Cycle").Where("status IN ?", scoredStatuses).
+		Where("(cycle_id IS NULL AND period LIKE ?) OR cycle_id IN (?)", fmt.Sprintf("%04d-%%", year),
+			r.db.Model(&models.ReviewCycle{}).Select("id").Where("end_date BETWEEN ? AND ?", yearStart, yearEnd))
+	if userIDs != nil {
+		query = query.Where("user_id IN ?", userIDs)
+	}
+	err := query.Order("user_id asc, period asc").Find(&reviews).Error
+	return reviews, err
+}
@@ -0,0 +1,74 @@
+package repositories
+
+import (
+	"cepm-backend/database"
+	"cepm-backend/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type UpwardFeedbackRepository interface {
+	TokenExists(token string) (bool, error)
+	CreateWithToken(token *models.UpwardFeedbackToken, response *models.UpwardFeedbackResponse) error
+	ListResponses(period string, managerID *uint) ([]models.UpwardFeedbackResponse, error)
+	ListResults(period string, managerID *uint) ([]models.UpwardFeedbackResult, error)
+	SaveResults(results []models.UpwardFeedbackResult) error
+}
+
+type dbUpwardFeedbackRepository struct {
+	db *gorm.DB
+}
+
+func NewUpwardFeedbackRepository() UpwardFeedbackRepository {
+	return &dbUpwardFeedbackRepository{db: database.DB}
+}
+
+// TokenExists reports whether a submission token has already been used.
+func (r *dbUpwardFeedbackRepository) TokenExists(token string) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.UpwardFeedbackToken{}).Where("token = ?", token).Count(&count).Error
+	return count > 0, err
+}
+
+// CreateWithToken stores a response and its submission token in a single transaction.
+// The token primary key rejects a second submission by the same respondent.
+func (r *dbUpwardFeedbackRepository) CreateWithToken(token *models.UpwardFeedbackToken, response *models.UpwardFeedbackResponse) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(token).Error; err != nil {
+			return err
+		}
+		return tx.Create(response).Error
+	})
+}
+
+// ListResponses retrieves the responses of a period, optionally for a single manager.
+func (r *dbUpwardFeedbackRepository) ListResponses(period string, managerID *uint) ([]models.UpwardFeedbackResponse, error) {
+	var responses []models.UpwardFeedbackResponse
+	query := r.db.Preload("Answers").Where("period = ?", period)
+	if managerID != nil {
+		query = query.Where("manager_id = ?", *managerID)
+	}
+	err := query.Order("manager_id asc").Find(&responses).Error
+	return responses, err
+}
+
+// ListResults retrieves the frozen results of a period, optionally for a single manager.
+func (r *dbUpwardFeedbackRepository) ListResults(period string, managerID *uint) ([]models.UpwardFeedbackResult, error) {
+	var results []models.UpwardFeedbackResult
+	query := r.db.Where("period = ?", period)
+	if managerID != nil {
+		query = query.Where("manager_id = ?", *managerID)
+	}
+	err := query.Order("manager_id asc").Find(&results).Error
+	return results, err
+}
+
+// SaveResults freezes the results of a period in a single transaction. Results that were already
+// frozen, for example by a concurrent request, are kept as they are.
+func (r *dbUpwardFeedbackRepository) SaveResults(results []models.UpwardFeedbackResult) error {
+	if len(results) == 0 {
+		return nil
+	}
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&results).Error
+}
@@ -13,7 +13,7 @@ import (
 	"github.com/gin-contrib/cors"
 )
 
-func SetupRouter(userService *services.UserService, departmentService *services.DepartmentService, systemSettingService *services.SystemSettingService, authService services.AuthService) *gin.Engine {
+func SetupRouter(userService *services.UserService, departmentService *services.DepartmentService, systemSettingService *services.SystemSettingService, authService services.AuthService, notificationService services.NotificationService, attachmentService services.AttachmentService, upwardFeedbackService services.UpwardFeedbackService) *gin.Engine {
 	r := gin.Default()
 
 	// CORS Middleware
@@ -21,7 +21,7 @@ func SetupRouter(userService *services.UserService, departmentService *services.
 		AllowOrigins:     []string{"http://localhost:3100"}, // Allow your frontend origin
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
 		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-User-Email"},
-		ExposeHeaders:    []string{"Content-Length"},
+		ExposeHeaders:    []string{"Content-Length", "Content-Disposition", "X-Export-Batch-ID"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
@@ -35,8 +35,53 @@ func SetupRouter(userService *services.UserService, departmentService *services.
 
 	// Dependency Injection
 	performanceReviewRepo := repositories.NewPerformanceReviewRepository()
-	performanceReviewService := services.NewPerformanceReviewService(performanceReviewRepo)
+	delegationRepo := repositories.NewDelegationRepository()
+	reviewCycleRepo := repositories.NewReviewCycleRepository()
+	evaluatorRepo := repositories.NewEvaluatorRepository()
+	appealRepo := repositories.NewAppealRepository()
+	peerFeedbackRepo := repositories.NewPeerFeedbackRepository()
+	improvementPlanService := services.NewImprovementPlanService(repositories.NewImprovementPlanRepository(), performanceReviewRepo, systemSettingService)
+	performanceReviewService := services.NewPerformanceReviewService(performanceReviewRepo, delegationRepo, reviewCycleRepo, evaluatorRepo, improvementPlanService, systemSettingService)
 	performanceReviewHandler := api.NewPerformanceReviewHandler(performanceReviewService)
+	delegationService := services.NewDelegationService(delegationRepo)
+	delegationHandler := api.NewDelegationHandler(delegationService)
+	inboxService := services.NewInboxService(performanceReviewRepo, delegationRepo, appealRepo, evaluatorRepo, peerFeedbackRepo, systemSettingService)
+	inboxHandler := api.NewInboxHandler(inboxService)
+	appealService := services.NewAppealService(appealRepo, performanceReviewRepo, improvementPlanService, systemSettingService)
+	appealHandler := api.NewAppealHandler(appealService)
+	calibrationService := services.NewCalibrationService(repositories.NewCalibrationRepository(), performanceReviewRepo, improvementPlanService, systemSettingService)
+	calibrationHandler := api.NewCalibrationHandler(calibrationService)
+	analyticsService := services.NewAnalyticsService(performanceReviewRepo, systemSettingService)
+	analyticsHandler := api.NewAnalyticsHandler(analyticsService)
+	normalizationService := services.NewNormalizationService(repositories.NewNormalizationRepository(), performanceReviewRepo, improvementPlanService, systemSettingService)
+	normalizationHandler := api.NewNormalizationHandler(normalizationService)
+	annualReviewService := services.NewAnnualReviewService(repositories.NewAnnualReviewRepository(), performanceReviewRepo, systemSettingService)
+	annualReviewHandler := api.NewAnnualReviewHandler(annualReviewService)
+	reviewCycleService := services.NewReviewCycleService(reviewCycleRepo)
+	reviewCycleHandler := api.NewReviewCycleHandler(reviewCycleService)
+	defenseService := services.NewDefenseService(repositories.NewDefenseRepository(), performanceReviewRepo, improvementPlanService, systemSettingService)
+	defenseHandler := api.NewDefenseHandler(defenseService)
+	evaluatorService := services.NewEvaluatorService(evaluatorRepo, performanceReviewRepo)
+	evaluatorHandler := api.NewEvaluatorHandler(evaluatorService)
+	peerFeedbackService := services.NewPeerFeedbackService(peerFeedbackRepo, performanceReviewRepo, systemSettingService)
+	peerFeedbackHandler := api.NewPeerFeedbackHandler(peerFeedbackService)
+	upwardFeedbackHandler := api.NewUpwardFeedbackHandler(upwardFeedbackService)
+	improvementPlanHandler := api.NewImprovementPlanHandler(improvementPlanService)
+	checkInService := services.NewCheckInService(repositories.NewCheckInRepository(), performanceReviewRepo)
+	checkInHandler := api.NewCheckInHandler(checkInService)
+	commentService := services.NewCommentService(repositories.NewCommentRepository(), performanceReviewRepo, notificationService)
+	commentHandler := api.NewCommentHandler(commentService)
+	attachmentHandler := api.NewAttachmentHandler(attachmentService)
+	llmCaseService := services.NewLLMCaseService(repositories.NewLLMCaseRepository(), performanceReviewRepo)
+	llmCaseHandler := api.NewLLMCaseHandler(llmCaseService)
+	exportService := services.NewExportService(repositories.NewExportRepository())
+	exportHandler := api.NewExportHandler(exportService)
+	importService := services.NewImportService(repositories.NewImportRepository(), performanceReviewRepo, reviewCycleRepo, improvementPlanService, systemSettingService)
+	importHandler := api.NewImportHandler(importService)
+	payrollService := services.NewPayrollService(repositories.NewPayrollRepository(), systemSettingService)
+	payrollHandler := api.NewPayrollHandler(payrollService)
+	bonusService := services.NewBonusService(repositories.NewBonusRepository(), payrollService)
+	bonusHandler := api.NewBonusHandler(bonusService)
 	adminHandler := api.NewAdminHandler(userService, departmentService, systemSettingService)
 	authHandler := api.NewAuthHandler(authService)
 
@@ -59,6 +104,9 @@ func SetupRouter(userService *services.UserService, departmentService *services.
 			reviews.GET("/by-period", performanceReviewHandler.GetPerformanceReviewByPeriod)
 			reviews.GET("/all-submitted", performanceReviewHandler.ListAllSubmittedReviews) // New route for HR role
 			reviews.GET("/all-by-period", performanceReviewHandler.ListAllReviewsByPeriod) // New route for HR to view all reviews by period
+			reviews.GET("/export.xlsx", middleware.RequireRole("人事", "HR"), exportHandler.ExportDepartmentWorkbook)
+			reviews.GET("/export.zip", middleware.RequireRole("人事", "HR"), exportHandler.ExportDepartmentPDFs)
+			reviews.POST("/import", middleware.RequireRole("人事", "HR"), importHandler.ImportWorkbook)
 
 			// Routes with path parameters
 			reviews.GET("/:id", performanceReviewHandler.GetPerformanceReview)
@@ -67,12 +115,200 @@ func SetupRouter(userService *services.UserService, departmentService *services.
 			reviews.POST("/:id/submit", performanceReviewHandler.SubmitPerformanceReview)
 			reviews.POST("/:id/approve", performanceReviewHandler.ApprovePerformanceReview)
 			reviews.POST("/:id/reject", performanceReviewHandler.RejectPerformanceReview)
+			reviews.POST("/:id/acknowledge", performanceReviewHandler.AcknowledgePerformanceReview)
+			reviews.POST("/:id/hr-confirm", middleware.RequireRole("人事", "HR"), performanceReviewHandler.HRConfirmPerformanceReview)
+			reviews.POST("/:id/appeals", appealHandler.FileAppeal)
+			reviews.GET("/:id/appeals", appealHandler.ListReviewAppeals)
+			reviews.GET("/:id/evaluators", evaluatorHandler.ListEvaluators)
+			reviews.PUT("/:id/evaluators", evaluatorHandler.SetEvaluators)
+			reviews.POST("/:id/peer-feedback", peerFeedbackHandler.NominatePeers)
+			reviews.GET("/:id/peer-feedback", peerFeedbackHandler.GetSummary)
+			reviews.POST("/:id/items/:itemId/check-ins", checkInHandler.CreateCheckIn)
+			reviews.GET("/:id/check-ins", checkInHandler.ListCheckIns)
+			reviews.POST("/:id/comments", commentHandler.CreateComment)
+			reviews.GET("/:id/comments", commentHandler.ListComments)
+			reviews.POST("/:id/items/:itemId/attachments", attachmentHandler.UploadFile)
+			reviews.POST("/:id/items/:itemId/links", attachmentHandler.AddLink)
+			reviews.GET("/:id/attachments", attachmentHandler.ListAttachments)
+			reviews.POST("/:id/items/:itemId/llm-cases", llmCaseHandler.CreateCase)
+			reviews.GET("/:id/llm-cases", llmCaseHandler.ListReviewCases)
+			reviews.GET("/:id/export.xlsx", exportHandler.ExportReviewWorkbook)
+			reviews.GET("/:id/export.pdf", exportHandler.ExportReviewPDF)
 		}
 
 		// Team-related routes
 		team := apiV1.Group("/team")
 		{
 			team.GET("/reviews", performanceReviewHandler.ListTeamReviews)
+			team.POST("/reviews/approve", performanceReviewHandler.BatchApprovePerformanceReviews)
+			team.POST("/reviews/reject", performanceReviewHandler.BatchRejectPerformanceReviews)
+			team.POST("/reviews/score", performanceReviewHandler.BatchScorePerformanceReviews)
+		}
+
+		// Pending actions for the current user
+		apiV1.GET("/inbox", inboxHandler.GetInbox)
+
+		// Approval delegation routes
+		delegations := apiV1.Group("/delegations")
+		{
+			delegations.POST("", delegationHandler.CreateDelegation)
+			delegations.GET("", delegationHandler.ListDelegations)
+			delegations.DELETE("/:id", delegationHandler.RevokeDelegation)
+		}
+
+		// Appeal (申诉) routes
+		appeals := apiV1.Group("/appeals")
+		{
+			appeals.GET("", appealHandler.ListMyAppeals)
+			appeals.GET("/:id", appealHandler.GetAppeal)
+			appeals.POST("/:id/resolve", appealHandler.ResolveAppeal)
+			appeals.POST("/:id/withdraw", appealHandler.WithdrawAppeal)
+		}
+
+		// HR calibration routes
+		calibrations := apiV1.Group("/calibrations")
+		calibrations.Use(middleware.RequireRole("人事", "HR"))
+		{
+			calibrations.POST("", calibrationHandler.OpenSession)
+			calibrations.GET("", calibrationHandler.ListSessions)
+			calibrations.GET("/:id", calibrationHandler.GetReport)
+			calibrations.POST("/:id/adjustments", calibrationHandler.AdjustScore)
+			calibrations.POST("/:id/close", calibrationHandler.CloseSession)
+		}
+
+		// Payroll export routes
+		payroll := apiV1.Group("/payroll")
+		payroll.Use(middleware.RequireRole("人事", "HR"))
+		{
+			payroll.POST("/exports", payrollHandler.Export)
+			payroll.GET("/exports", payrollHandler.ListBatches)
+			payroll.GET("/exports/:id", payrollHandler.GetBatch)
+			payroll.GET("/exports/:id/download", payrollHandler.DownloadBatch)
+			payroll.GET("/exports/:id/reconcile", payrollHandler.ReconcileBatch)
+		}
+
+		// Bonus routes; runs are calculated by HR and approved by another HR member or an administrator
+		bonus := apiV1.Group("/bonus")
+		{
+			bonus.GET("/bases", middleware.RequireRole("人事", "HR"), bonusHandler.ListBases)
+			bonus.PUT("/bases", middleware.RequireRole("人事", "HR"), bonusHandler.SetBases)
+			bonus.POST("/bases/import", middleware.RequireRole("人事", "HR"), bonusHandler.ImportBases)
+			bonus.DELETE("/bases/:id", middleware.RequireRole("人事", "HR"), bonusHandler.DeleteBase)
+			bonus.POST("/runs", middleware.RequireRole("人事", "HR"), bonusHandler.CreateRun)
+			bonus.GET("/runs", middleware.RequireRole("人事", "HR", "管理员"), bonusHandler.ListRuns)
+			bonus.GET("/runs/:id", middleware.RequireRole("人事", "HR", "管理员"), bonusHandler.GetRun)
+			bonus.POST("/runs/:id/approve", middleware.RequireRole("人事", "HR", "管理员"), bonusHandler.ApproveRun)
+			bonus.POST("/runs/:id/reject", middleware.RequireRole("人事", "HR", "管理员"), bonusHandler.RejectRun)
+			bonus.GET("/runs/:id/export", middleware.RequireRole("人事", "HR"), bonusHandler.ExportRun)
+		}
+
+		// HR analytics routes
+		analytics := apiV1.Group("/analytics")
+		analytics.Use(middleware.RequireRole("人事", "HR"))
+		{
+			analytics.GET("/rater-leniency", analyticsHandler.RaterLeniency)
+		}
+
+		// Cross-department normalization routes
+		normalizations := apiV1.Group("/normalizations")
+		normalizations.Use(middleware.RequireRole("人事", "HR"))
+		{
+			normalizations.POST("/preview", normalizationHandler.Preview)
+			normalizations.POST("", normalizationHandler.Apply)
+			normalizations.GET("", normalizationHandler.ListRuns)
+		}
+
+		// Review cycle routes
+		cycles := apiV1.Group("/review-cycles")
+		{
+			cycles.GET("/mine", reviewCycleHandler.ListMyCycles)
+			cycles.GET("", reviewCycleHandler.ListCycles)
+			cycles.GET("/:id", reviewCycleHandler.GetCycle)
+			cycles.POST("", middleware.RequireRole("人事", "HR"), reviewCycleHandler.CreateCycle)
+			cycles.POST("/:id/close", middleware.RequireRole("人事", "HR"), reviewCycleHandler.CloseCycle)
+		}
+
+		// Comment routes
+		comments := apiV1.Group("/comments")
+		{
+			comments.PUT("/:id", commentHandler.UpdateComment)
+			comments.DELETE("/:id", commentHandler.DeleteComment)
+			comments.GET("/:id/edits", commentHandler.GetEditHistory)
+		}
+
+		// Evidence attachment routes
+		attachments := apiV1.Group("/attachments")
+		{
+			attachments.GET("/:id/download", attachmentHandler.DownloadAttachment)
+			attachments.DELETE("/:id", attachmentHandler.DeleteAttachment)
+		}
+
+		// 大模型 case library routes
+		llmCases := apiV1.Group("/llm-cases")
+		{
+			llmCases.GET("", llmCaseHandler.SearchCases)
+			llmCases.GET("/adoption", middleware.RequireRole("人事", "HR"), llmCaseHandler.GetAdoptionReport)
+			llmCases.GET("/:id", llmCaseHandler.GetCase)
+			llmCases.PUT("/:id", llmCaseHandler.UpdateCase)
+			llmCases.DELETE("/:id", llmCaseHandler.DeleteCase)
+			llmCases.POST("/:id/like", llmCaseHandler.LikeCase)
+			llmCases.DELETE("/:id/like", llmCaseHandler.UnlikeCase)
+		}
+
+		// Check-in routes
+		apiV1.POST("/check-ins/:id/reply", checkInHandler.ReplyCheckIn)
+
+		// Multi-rater evaluation routes
+		apiV1.GET("/evaluations/pending", evaluatorHandler.ListPendingEvaluations)
+
+		// 360 peer feedback routes
+		peerFeedback := apiV1.Group("/peer-feedback")
+		{
+			peerFeedback.GET("/pending", peerFeedbackHandler.ListPendingRequests)
+			peerFeedback.POST("/:id/submit", peerFeedbackHandler.SubmitFeedback)
+			peerFeedback.POST("/:id/decline", peerFeedbackHandler.DeclineRequest)
+		}
+
+		// Anonymous upward feedback routes
+		upwardFeedback := apiV1.Group("/upward-feedback")
+		{
+			upwardFeedback.GET("/questionnaire", upwardFeedbackHandler.GetQuestionnaire)
+			upwardFeedback.POST("", upwardFeedbackHandler.SubmitFeedback)
+			upwardFeedback.GET("/mine", upwardFeedbackHandler.GetMyResult)
+			upwardFeedback.GET("/report", middleware.RequireRole("人事", "HR"), upwardFeedbackHandler.GetEffectivenessReport)
+		}
+
+		// Performance improvement plan routes
+		plans := apiV1.Group("/improvement-plans")
+		{
+			plans.GET("", improvementPlanHandler.ListPlans)
+			plans.GET("/:id", improvementPlanHandler.GetPlan)
+			plans.PUT("/:id", improvementPlanHandler.UpdatePlan)
+			plans.POST("/:id/start", improvementPlanHandler.StartPlan)
+			plans.POST("/:id/check-ins/:checkInId/complete", improvementPlanHandler.CompleteCheckIn)
+			plans.POST("/:id/close", improvementPlanHandler.ClosePlan)
+			plans.POST("/:id/cancel", improvementPlanHandler.CancelPlan)
+			plans.POST("", middleware.RequireRole("人事", "HR"), improvementPlanHandler.CreatePlan)
+		}
+
+		// 述职答辩 routes
+		defenses := apiV1.Group("/defenses")
+		{
+			defenses.GET("/panel", defenseHandler.ListPanelSessions)
+			defenses.GET("/:id", defenseHandler.GetSession)
+			defenses.POST("/:id/candidates/:candidateId/scores", defenseHandler.SubmitScore)
+			defenses.POST("", middleware.RequireRole("人事", "HR"), defenseHandler.ScheduleSession)
+			defenses.GET("", middleware.RequireRole("人事", "HR"), defenseHandler.ListSessions)
+			defenses.POST("/:id/close", middleware.RequireRole("人事", "HR"), defenseHandler.CloseSession)
+		}
+
+		// Annual review routes
+		annual := apiV1.Group("/annual-reviews/:year")
+		{
+			annual.GET("/users/:userId", annualReviewHandler.GetUserAnnualReview)
+			annual.PUT("/users/:userId/evaluation", middleware.RequireRole("人事", "HR"), annualReviewHandler.SetEvaluation)
+			annual.POST("/compute", middleware.RequireRole("人事", "HR"), annualReviewHandler.ComputeYear)
+			annual.GET("/departments/:departmentId", middleware.RequireRole("人事", "HR"), annualReviewHandler.GetDepartmentReport)
 		}
 
 		// Admin routes
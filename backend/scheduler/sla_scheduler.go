@@ -0,0 +1,54 @@
+package scheduler
+
+import (
+	"log"
+	"time"
+
+	"cepm-backend/services"
+)
+
+// SLAScheduler periodically checks pending reviews against their SLAs inside the backend process.
+type SLAScheduler struct {
+	slaService services.SLAService
+	interval   time.Duration
+	stop       chan struct{}
+}
+
+// NewSLAScheduler creates a new SLAScheduler that runs a check every interval.
+func NewSLAScheduler(slaService services.SLAService, interval time.Duration) *SLAScheduler {
+	return &SLAScheduler{
+		slaService: slaService,
+		interval:   interval,
+		stop:       make(chan struct{}),
+	}
+}
+
+// Start runs the scheduler in a background goroutine.
+func (s *SLAScheduler) Start() {
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		s.run()
+		for {
+			select {
+			case <-ticker.C:
+				s.run()
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+	log.Printf("SLA scheduler started, checking every %s", s.interval)
+}
+
+// Stop stops the scheduler.
+func (s *SLAScheduler) Stop() {
+	close(s.stop)
+}
+
+func (s *SLAScheduler) run() {
+	if err := s.slaService.CheckPendingReviews(time.Now()); err != nil {
+		log.Printf("SLA check failed: %v", err)
+	}
+}
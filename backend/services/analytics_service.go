@@ -0,0 +1,264 @@
+package services
+
+import (
+	"math"
+	"sort"
+	"strconv"
+
+	"cepm-backend/database"
+	"cepm-backend/models"
+	"cepm-backend/repositories"
+
+	"gorm.io/gorm"
+)
+
+// SettingLeniencyZThreshold is the SystemSetting key for the z-score beyond which an evaluator is flagged as an outlier.
+const SettingLeniencyZThreshold = "leniency_z_threshold"
+
+// ScoreStats summarizes a set of scores.
+type ScoreStats struct {
+	Count  int     `json:"count"`
+	Mean   float64 `json:"mean"`
+	StdDev float64 `json:"stdDev"`
+}
+
+// RaterStats compares the scores one evaluator gave in a period with the department and company baselines.
+type RaterStats struct {
+	EvaluatorID      uint            `json:"evaluatorId"`
+	EvaluatorName    string          `json:"evaluatorName"`
+	DepartmentID     *uint           `json:"departmentId"`
+	DepartmentName   string          `json:"departmentName"`
+	Period           string          `json:"period"`
+	Stats            ScoreStats      `json:"stats"`
+	Distribution     []GradeBandStat `json:"distribution"`
+	Department       ScoreStats      `json:"department"`
+	Company          ScoreStats      `json:"company"`
+	DepartmentZScore float64         `json:"departmentZScore"`
+	CompanyZScore    float64         `json:"companyZScore"`
+	IsOutlier        bool            `json:"isOutlier"`
+	Direction        string          `json:"direction,omitempty"` // lenient, strict
+}
+
+// RaterLeniencyReport lists evaluator statistics for one or all periods.
+type RaterLeniencyReport struct {
+	Period     string       `json:"period"`
+	ZThreshold float64      `json:"zThreshold"`
+	Raters     []RaterStats `json:"raters"`
+}
+
+// AnalyticsService defines the interface for performance analytics.
+type AnalyticsService interface {
+	RaterLeniency(period string, zThreshold *float64) (*RaterLeniencyReport, error)
+}
+
+type analyticsService struct {
+	reviewRepo           repositories.PerformanceReviewRepository
+	systemSettingService *SystemSettingService
+	db                   *gorm.DB
+}
+
+// NewAnalyticsService creates a new instance of AnalyticsService.
+func NewAnalyticsService(reviewRepo repositories.PerformanceReviewRepository, systemSettingService *SystemSettingService) AnalyticsService {
+	return &analyticsService{reviewRepo: reviewRepo, systemSettingService: systemSettingService, db: database.DB}
+}
+
+// RaterLeniency computes, per evaluator and period, the mean, standard deviation and grade distribution
+// of the item-based scores they gave. Each evaluator is compared with the scores given to everyone in their
+// department and in the company in the same period, and flagged when either z-score exceeds the threshold.
+func (s *analyticsService) RaterLeniency(period string, zThreshold *float64) (*RaterLeniencyReport, error) {
+	threshold := 2.0
+	if zThreshold != nil {
+		threshold = *zThreshold
+	} else if setting, err := s.systemSettingService.GetSetting(SettingLeniencyZThreshold); err == nil {
+		if value, err := strconv.ParseFloat(setting.Value, 64); err == nil && value > 0 {
+			threshold = value
+		}
+	}
+
+	reviews, err := s.reviewRepo.ListScoredReviews(period)
+	if err != nil {
+		return nil, err
+	}
+	multiRater, err := s.evaluatorScores(reviews)
+	if err != nil {
+		return nil, err
+	}
+
+	// 1. Group raw scores by (evaluator, period), (department, period) and period
+	type raterKey struct {
+		evaluatorID uint
+		period      string
+	}
+	type departmentKey struct {
+		departmentID uint
+		period       string
+	}
+	raterScores := make(map[raterKey][]float64)
+	departmentScores := make(map[departmentKey][]float64)
+	companyScores := make(map[string][]float64)
+	evaluatorIDs := []uint{}
+	for i := range reviews {
+		review := &reviews[i]
+		score := calculateTotalScore(review.Items)
+
+		// A multi-rater review counts once for each evaluator, with the score they gave themselves
+		given := multiRater[review.ID]
+		if len(given) == 0 {
+			evaluatorID, ok := reviewEvaluator(review)
+			if !ok {
+				continue
+			}
+			given = map[uint]float64{evaluatorID: score}
+		}
+		for evaluatorID, evaluatorScore := range given {
+			key := raterKey{evaluatorID, review.Period}
+			if _, seen := raterScores[key]; !seen {
+				evaluatorIDs = append(evaluatorIDs, evaluatorID)
+			}
+			raterScores[key] = append(raterScores[key], evaluatorScore)
+		}
+		if review.User.DepartmentID != nil {
+			deptKey := departmentKey{*review.User.DepartmentID, review.Period}
+			departmentScores[deptKey] = append(departmentScores[deptKey], score)
+		}
+		companyScores[review.Period] = append(companyScores[review.Period], score)
+	}
+
+	// 2. Load evaluator details
+	var evaluators []models.User
+	if len(evaluatorIDs) > 0 {
+		if err := s.db.Preload("Department").Where("id IN ?", evaluatorIDs).Find(&evaluators).Error; err != nil {
+			return nil, err
+		}
+	}
+	evaluatorMap := make(map[uint]*models.User)
+	for i := range evaluators {
+		evaluatorMap[evaluators[i].ID] = &evaluators[i]
+	}
+
+	// 3. Compare each evaluator with the baselines
+	raters := make([]RaterStats, 0, len(raterScores))
+	for key, scores := range raterScores {
+		stats := RaterStats{
+			EvaluatorID:  key.evaluatorID,
+			Period:       key.period,
+			Stats:        scoreStats(scores),
+			Distribution: gradeDistribution(scores, nil),
+			Company:      scoreStats(companyScores[key.period]),
+		}
+		if evaluator, ok := evaluatorMap[key.evaluatorID]; ok {
+			stats.EvaluatorName = evaluator.Name
+			stats.DepartmentID = evaluator.DepartmentID
+			stats.DepartmentName = evaluator.Department.Name
+			if evaluator.DepartmentID != nil {
+				stats.Department = scoreStats(departmentScores[departmentKey{*evaluator.DepartmentID, key.period}])
+			}
+		}
+		stats.CompanyZScore = zScore(stats.Stats.Mean, stats.Company)
+		stats.DepartmentZScore = zScore(stats.Stats.Mean, stats.Department)
+		if math.Abs(stats.CompanyZScore) >= threshold || math.Abs(stats.DepartmentZScore) >= threshold {
+			stats.IsOutlier = true
+			// The direction follows whichever baseline flagged the evaluator; if both did, the larger deviation
+			z := stats.CompanyZScore
+			if math.Abs(stats.DepartmentZScore) > math.Abs(z) {
+				z = stats.DepartmentZScore
+			}
+			stats.Direction = "strict"
+			if z > 0 {
+				stats.Direction = "lenient"
+			}
+		}
+		raters = append(raters, stats)
+	}
+
+	sort.Slice(raters, func(i, j int) bool {
+		if raters[i].Period != raters[j].Period {
+			return raters[i].Period < raters[j].Period
+		}
+		return math.Abs(raters[i].CompanyZScore) > math.Abs(raters[j].CompanyZScore)
+	})
+	return &RaterLeniencyReport{Period: period, ZThreshold: threshold, Raters: raters}, nil
+}
+
+// evaluatorScores returns, for each multi-rater review, the score each evaluator who submitted gave it.
+func (s *analyticsService) evaluatorScores(reviews []models.PerformanceReview) (map[uint]map[uint]float64, error) {
+	reviewIDs := make([]uint, 0, len(reviews))
+	items := make(map[uint][]models.PerformanceItem, len(reviews))
+	for _, review := range reviews {
+		reviewIDs = append(reviewIDs, review.ID)
+		items[review.ID] = review.Items
+	}
+	scores := make(map[uint]map[uint]float64)
+	if len(reviewIDs) == 0 {
+		return scores, nil
+	}
+	var evaluators []models.ReviewEvaluator
+	if err := s.db.Preload("Scores").Where("review_id IN ? AND submitted_at IS NOT NULL", reviewIDs).Find(&evaluators).Error; err != nil {
+		return nil, err
+	}
+	for _, evaluator := range evaluators {
+		if scores[evaluator.ReviewID] == nil {
+			scores[evaluator.ReviewID] = make(map[uint]float64)
+		}
+		scores[evaluator.ReviewID][evaluator.EvaluatorID] = evaluatorTotalScore(items[evaluator.ReviewID], evaluator.Scores)
+	}
+	return scores, nil
+}
+
+// evaluatorTotalScore weights one evaluator's item scores by the item weights, as calculateTotalScore does
+// for the combined scores. Items the evaluator did not score count as 0.
+func evaluatorTotalScore(items []models.PerformanceItem, scores []models.EvaluatorItemScore) float64 {
+	byItem := make(map[uint]float64, len(scores))
+	for _, score := range scores {
+		byItem[score.ItemID] = score.Score
+	}
+	var total float64
+	for _, item := range items {
+		total += item.Weight / 100 * byItem[item.ID]
+	}
+	return total
+}
+
+// reviewEvaluator returns who scored a review without assigned evaluators: the latest scorer in the approval history,
+// otherwise the manager who approved the plan, otherwise the owner's manager.
+// Approvals must be ordered newest first.
+func reviewEvaluator(review *models.PerformanceReview) (uint, bool) {
+	for _, status := range []string{"已完成", "待打分"} {
+		for _, approval := range review.Approvals {
+			if approval.Status == status {
+				return approval.ApproverID, true
+			}
+		}
+	}
+	if review.User.ManagerID != nil {
+		return *review.User.ManagerID, true
+	}
+	return 0, false
+}
+
+// scoreStats computes the count, mean and population standard deviation of a set of scores.
+func scoreStats(scores []float64) ScoreStats {
+	stats := ScoreStats{Count: len(scores)}
+	if len(scores) == 0 {
+		return stats
+	}
+	var sum float64
+	for _, score := range scores {
+		sum += score
+	}
+	stats.Mean = sum / float64(len(scores))
+	var variance float64
+	for _, score := range scores {
+		variance += (score - stats.Mean) * (score - stats.Mean)
+	}
+	stats.StdDev = math.Sqrt(variance / float64(len(scores)))
+	return stats
+}
+
+// zScore returns how many baseline standard deviations value is from the baseline mean, or 0 if the baseline has no spread.
+func zScore(value float64, baseline ScoreStats) float64 {
+	if baseline.StdDev == 0 {
+		return 0
+	}
+	return (value - baseline.Mean) / baseline.StdDev
+}
@@ -0,0 +1,348 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+
+	"cepm-backend/database"
+	"cepm-backend/models"
+	"cepm-backend/repositories"
+
+	"gorm.io/gorm"
+)
+
+// SettingAnnualScoreFormula is the SystemSetting key holding the AnnualScoreFormula as JSON.
+const SettingAnnualScoreFormula = "annual_score_formula"
+
+// AnnualScoreFormula configures how the annual score is computed:
+// AnnualScore = (MonthlyWeight × monthly score + EvaluationWeight × 年度考核结果) / (MonthlyWeight + EvaluationWeight).
+// Until the annual evaluation is entered, the annual score is the monthly score alone.
+type AnnualScoreFormula struct {
+	MonthlySource    string    `json:"monthlySource"` // average, weighted
+	MonthlyWeight    float64   `json:"monthlyWeight"`
+	EvaluationWeight float64   `json:"evaluationWeight"`
+	MonthWeights     []float64 `json:"monthWeights"` // Weights for January to December, used by the weighted average
+}
+
+// defaultAnnualScoreFormula weights later months more heavily and combines the monthly and annual results 70/30.
+func defaultAnnualScoreFormula() AnnualScoreFormula {
+	monthWeights := make([]float64, 12)
+	for i := range monthWeights {
+		monthWeights[i] = float64(i + 1)
+	}
+	return AnnualScoreFormula{MonthlySource: "weighted", MonthlyWeight: 0.7, EvaluationWeight: 0.3, MonthWeights: monthWeights}
+}
+
+// AnnualEvaluationInput defines the structure for entering the annual evaluation result.
+type AnnualEvaluationInput struct {
+	Score   float64 `json:"score"`
+	Comment string  `json:"comment"`
+}
+
+// AnnualDepartmentReport lists a department subtree's annual reviews with their score statistics.
+type AnnualDepartmentReport struct {
+	Year         int                   `json:"year"`
+	DepartmentID uint                  `json:"departmentId"`
+	Stats        ScoreStats            `json:"stats"`
+	Distribution []GradeBandStat       `json:"distribution"`
+	Reviews      []models.AnnualReview `json:"reviews"`
+}
+
+// AnnualReviewService defines the interface for annual performance aggregation.
+type AnnualReviewService interface {
+	ComputeYear(year int, departmentID *uint) ([]models.AnnualReview, error)
+	GetUserAnnualReview(year int, userID uint, viewer *models.User) (*models.AnnualReview, error)
+	SetEvaluation(year int, userID uint, hrID uint, input *AnnualEvaluationInput) (*models.AnnualReview, error)
+	GetDepartmentReport(year int, departmentID uint) (*AnnualDepartmentReport, error)
+}
+
+type annualReviewService struct {
+	repo                 repositories.AnnualReviewRepository
+	reviewRepo           repositories.PerformanceReviewRepository
+	systemSettingService *SystemSettingService
+	db                   *gorm.DB
+}
+
+// NewAnnualReviewService creates a new instance of AnnualReviewService.
+func NewAnnualReviewService(repo repositories.AnnualReviewRepository, reviewRepo repositories.PerformanceReviewRepository, systemSettingService *SystemSettingService) AnnualReviewService {
+	return &annualReviewService{repo: repo, reviewRepo: reviewRepo, systemSettingService: systemSettingService, db: database.DB}
+}
+
+// ComputeYear recomputes the annual reviews of a year from the monthly results,
+// for everyone or for a department subtree. Annual evaluation results already entered are kept.
+func (s *annualReviewService) ComputeYear(year int, departmentID *uint) ([]models.AnnualReview, error) {
+	var userIDs []uint
+	if departmentID != nil {
+		var err error
+		if userIDs, err = s.departmentUserIDs(*departmentID); err != nil {
+			return nil, err
+		}
+	}
+	return s.compute(year, userIDs)
+}
+
+// GetUserAnnualReview returns a user's annual review, computed from the current monthly results
+// without saving it; the stored reviews are refreshed by ComputeYear and SetEvaluation.
+// Only the user, their direct manager and HR can view it.
+func (s *annualReviewService) GetUserAnnualReview(year int, userID uint, viewer *models.User) (*models.AnnualReview, error) {
+	if viewer.ID != userID && !isHRUser(viewer) {
+		var user models.User
+		if err := s.db.First(&user, userID).Error; err != nil {
+			return nil, errors.New("用户不存在")
+		}
+		if user.ManagerID == nil || *user.ManagerID != viewer.ID {
+			return nil, errors.New("您无权查看此员工的年度绩效")
+		}
+	}
+
+	stored, err := s.repo.GetByUserIDAndYear(userID, year)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	annuals, err := s.aggregate(year, []uint{userID})
+	if err != nil {
+		return nil, err
+	}
+	if len(annuals) == 0 {
+		if stored == nil {
+			return nil, errors.New("该员工在此年度没有绩效记录")
+		}
+		return stored, nil // Only the annual evaluation has been entered so far
+	}
+
+	annual := &annuals[0]
+	if stored != nil {
+		annual.User, annual.EvaluatedBy = stored.User, stored.EvaluatedBy
+	} else if err := s.db.Preload("Department").First(&annual.User, userID).Error; err != nil {
+		return nil, err
+	}
+	return annual, nil
+}
+
+// SetEvaluation records the annual evaluation result for a user and recomputes their annual score and grade.
+func (s *annualReviewService) SetEvaluation(year int, userID uint, hrID uint, input *AnnualEvaluationInput) (*models.AnnualReview, error) {
+	if input.Score < 0 || input.Score > 120 {
+		return nil, errors.New("年度考核结果必须在0到120之间")
+	}
+
+	annual, err := s.repo.GetByUserIDAndYear(userID, year)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		annual = &models.AnnualReview{UserID: userID, Year: year}
+	}
+	annual.EvaluationScore = &input.Score
+	annual.EvaluationComment = input.Comment
+	annual.EvaluatedByID = &hrID
+	if err := s.repo.Save(annual); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.compute(year, []uint{userID}); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByUserIDAndYear(userID, year)
+}
+
+// GetDepartmentReport returns the annual reviews of a department subtree with their statistics.
+func (s *annualReviewService) GetDepartmentReport(year int, departmentID uint) (*AnnualDepartmentReport, error) {
+	userIDs, err := s.departmentUserIDs(departmentID)
+	if err != nil {
+		return nil, err
+	}
+	annuals, err := s.repo.ListByYear(year, userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	var scores []float64
+	for _, annual := range annuals {
+		if annual.AnnualScore != nil {
+			scores = append(scores, *annual.AnnualScore)
+		}
+	}
+	return &AnnualDepartmentReport{
+		Year:         year,
+		DepartmentID: departmentID,
+		Stats:        scoreStats(scores),
+		Distribution: gradeDistribution(scores, nil),
+		Reviews:      annuals,
+	}, nil
+}
+
+// compute aggregates the monthly results of the given users (or everyone if userIDs is nil) and saves the annual reviews.
+func (s *annualReviewService) compute(year int, userIDs []uint) ([]models.AnnualReview, error) {
+	annuals, err := s.aggregate(year, userIDs)
+	if err != nil {
+		return nil, err
+	}
+	for i := range annuals {
+		if err := s.repo.Save(&annuals[i]); err != nil {
+			return nil, err
+		}
+	}
+	return annuals, nil
+}
+
+// aggregate computes the annual reviews of the given users (or everyone if userIDs is nil) from their
+// results of the year, starting from the stored reviews so entered evaluations are kept. Nothing is saved.
+func (s *annualReviewService) aggregate(year int, userIDs []uint) ([]models.AnnualReview, error) {
+	formula := s.formula()
+	reviews, err := s.reviewRepo.ListScoredReviewsByYear(year, userIDs)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := s.repo.ListByYear(year, userIDs)
+	if err != nil {
+		return nil, err
+	}
+	annualMap := make(map[uint]*models.AnnualReview)
+	for i := range existing {
+		annualMap[existing[i].UserID] = &existing[i]
+	}
+
+	// 1. Collect each user's monthly scores
+	months := make(map[uint][]int)
+	scores := make(map[uint][]float64)
+	var order []uint
+	for i := range reviews {
+		review := &reviews[i]
+		month, ok := reviewMonth(review, year)
+		if review.TotalScore == nil || !ok {
+			continue
+		}
+		if _, ok := scores[review.UserID]; !ok {
+			order = append(order, review.UserID)
+		}
+		months[review.UserID] = append(months[review.UserID], month)
+		scores[review.UserID] = append(scores[review.UserID], *review.TotalScore)
+	}
+
+	// 2. Aggregate
+	results := make([]models.AnnualReview, 0, len(order))
+	for _, userID := range order {
+		annual, ok := annualMap[userID]
+		if !ok {
+			annual = &models.AnnualReview{UserID: userID, Year: year}
+		}
+		userMonths, userScores := months[userID], scores[userID]
+
+		average := round2(scoreStats(userScores).Mean)
+		weighted := round2(weightedAverage(userMonths, userScores, formula.MonthWeights))
+		trend := math.Round(trendSlope(userMonths, userScores)*1000) / 1000
+		annual.MonthCount = len(userScores)
+		annual.AverageScore = &average
+		annual.WeightedAverageScore = &weighted
+		annual.Trend = &trend
+
+		monthly := weighted
+		if formula.MonthlySource == "average" {
+			monthly = average
+		}
+		annualScore := monthly
+		if annual.EvaluationScore != nil && formula.MonthlyWeight+formula.EvaluationWeight > 0 {
+			annualScore = (formula.MonthlyWeight*monthly + formula.EvaluationWeight*(*annual.EvaluationScore)) / (formula.MonthlyWeight + formula.EvaluationWeight)
+		}
+		annualScore = round2(annualScore)
+		annual.AnnualScore = &annualScore
+		annual.AnnualGrade = gradeBand(annualScore)
+		results = append(results, *annual)
+	}
+	return results, nil
+}
+
+// formula reads the annual score formula from settings, filling unset fields with the defaults.
+func (s *annualReviewService) formula() AnnualScoreFormula {
+	formula := defaultAnnualScoreFormula()
+	if setting, err := s.systemSettingService.GetSetting(SettingAnnualScoreFormula); err == nil && setting.Value != "" {
+		var configured AnnualScoreFormula
+		if err := json.Unmarshal([]byte(setting.Value), &configured); err == nil {
+			if configured.MonthlySource != "" {
+				formula.MonthlySource = configured.MonthlySource
+			}
+			if configured.MonthlyWeight > 0 || configured.EvaluationWeight > 0 {
+				formula.MonthlyWeight = configured.MonthlyWeight
+				formula.EvaluationWeight = configured.EvaluationWeight
+			}
+			if len(configured.MonthWeights) == 12 {
+				formula.MonthWeights = configured.MonthWeights
+			}
+		}
+	}
+	return formula
+}
+
+// departmentUserIDs returns the IDs of all users in a department subtree.
+func (s *annualReviewService) departmentUserIDs(departmentID uint) ([]uint, error) {
+	departmentIDs, err := departmentSubtreeIDs(s.db, departmentID)
+	if err != nil {
+		return nil, err
+	}
+	userIDs := []uint{}
+	err = s.db.Model(&models.User{}).Where("department_id IN ?", departmentIDs).Pluck("id", &userIDs).Error
+	return userIDs, err
+}
+
+// reviewMonth returns the month (1-12) of the year a review's result counts for: the month its
+// cycle ends in, so quarterly and other non-monthly results are included, or for reviews
+// without a cycle the month of their YYYY-MM period.
+func reviewMonth(review *models.PerformanceReview, year int) (int, bool) {
+	if review.Cycle != nil {
+		if review.Cycle.EndDate.Year() != year {
+			return 0, false
+		}
+		return int(review.Cycle.EndDate.Month()), true
+	}
+	if len(review.Period) != 7 || review.Period[:5] != fmt.Sprintf("%04d-", year) {
+		return 0, false
+	}
+	month, err := strconv.Atoi(review.Period[5:])
+	if err != nil || month < 1 || month > 12 {
+		return 0, false
+	}
+	return month, true
+}
+
+// weightedAverage averages scores using the weight of each score's month (1-12).
+func weightedAverage(months []int, scores []float64, monthWeights []float64) float64 {
+	var sum, totalWeight float64
+	for i, score := range scores {
+		weight := monthWeights[months[i]-1]
+		sum += weight * score
+		totalWeight += weight
+	}
+	if totalWeight == 0 {
+		return scoreStats(scores).Mean
+	}
+	return sum / totalWeight
+}
+
+// trendSlope returns the least-squares slope of scores against their months, or 0 with fewer than two months.
+func trendSlope(months []int, scores []float64) float64 {
+	n := float64(len(scores))
+	if n < 2 {
+		return 0
+	}
+	var sumX, sumY, sumXY, sumXX float64
+	for i, score := range scores {
+		x := float64(months[i])
+		sumX += x
+		sumY += score
+		sumXY += x * score
+		sumXX += x * x
+	}
+	denominator := n*sumXX - sumX*sumX
+	if denominator == 0 {
+		return 0
+	}
+	return (n*sumXY - sumX*sumY) / denominator
+}
+
+// round2 rounds a score to two decimal places, matching the numeric(5,2) columns.
+func round2(value float64) float64 {
+	return math.Round(value*100) / 100
+}
@@ -0,0 +1,297 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"cepm-backend/database"
+	"cepm-backend/models"
+	"cepm-backend/repositories"
+
+	"gorm.io/gorm"
+)
+
+// SettingAppealHandler is the SystemSetting key that decides who handles appeals: "hr" (default) or "skip_level".
+const SettingAppealHandler = "appeal_handler"
+
+// AppealItemInput defines a single disputed item in an appeal.
+type AppealItemInput struct {
+	ItemID uint   `json:"itemId"`
+	Reason string `json:"reason"`
+}
+
+// AppealInput defines the structure for filing an appeal from the API.
+type AppealInput struct {
+	Reason string            `json:"reason"`
+	Items  []AppealItemInput `json:"items"`
+}
+
+// AppealRevisionInput defines a revised score for a disputed item.
+type AppealRevisionInput struct {
+	ItemID uint     `json:"itemId"`
+	Score  *float64 `json:"score"`
+}
+
+// AppealResolutionInput defines the structure for resolving an appeal.
+type AppealResolutionInput struct {
+	Decision string                `json:"decision"` // uphold, revise
+	Comment  string                `json:"comment"`
+	Items    []AppealRevisionInput `json:"items"`
+}
+
+// AppealList groups the appeals a user has filed and the ones assigned to them.
+type AppealList struct {
+	Filed    []models.Appeal `json:"filed"`
+	Assigned []models.Appeal `json:"assigned"`
+}
+
+// AppealService defines the interface for the appeal workflow.
+type AppealService interface {
+	FileAppeal(reviewID uint, userID uint, input *AppealInput) (*models.Appeal, error)
+	GetAppeal(id uint, user *models.User) (*models.Appeal, error)
+	ListReviewAppeals(reviewID uint, user *models.User) ([]models.Appeal, error)
+	ListMyAppeals(userID uint) (*AppealList, error)
+	ResolveAppeal(id uint, handlerID uint, input *AppealResolutionInput) error
+	WithdrawAppeal(id uint, userID uint, comment string) error
+}
+
+type appealService struct {
+	repo                 repositories.AppealRepository
+	reviewRepo           repositories.PerformanceReviewRepository
+	improvementPlans     ImprovementPlanService
+	systemSettingService *SystemSettingService
+	db                   *gorm.DB
+}
+
+// NewAppealService creates a new instance of AppealService.
+func NewAppealService(repo repositories.AppealRepository, reviewRepo repositories.PerformanceReviewRepository, improvementPlans ImprovementPlanService, systemSettingService *SystemSettingService) AppealService {
+	return &appealService{repo: repo, reviewRepo: reviewRepo, improvementPlans: improvementPlans, systemSettingService: systemSettingService, db: database.DB}
+}
+
+// FileAppeal lets the review owner dispute specific items of a scored result.
+// The appeal is routed to HR or the skip-level manager, and the review is locked as '申诉中'.
+func (s *appealService) FileAppeal(reviewID uint, userID uint, input *AppealInput) (*models.Appeal, error) {
+	// 1. Get the review and check ownership and status
+	review, err := s.reviewRepo.GetByID(reviewID)
+	if err != nil {
+		return nil, errors.New("绩效评估不存在")
+	}
+	if err := checkAppealable(review, userID); err != nil {
+		return nil, err
+	}
+
+	// 2. Validate the disputed items
+	if input.Reason == "" || len(input.Items) == 0 {
+		return nil, errors.New("申诉理由和申诉项均不能为空")
+	}
+	itemMap := make(map[uint]*models.PerformanceItem)
+	for i := range review.Items {
+		itemMap[review.Items[i].ID] = &review.Items[i]
+	}
+	var appealItems []models.AppealItem
+	for _, itemInput := range input.Items {
+		item, ok := itemMap[itemInput.ItemID]
+		if !ok {
+			return nil, errors.New("无效的绩效项ID")
+		}
+		appealItems = append(appealItems, models.AppealItem{
+			PerformanceItemID: item.ID,
+			Reason:            itemInput.Reason,
+			OriginalScore:     item.Score,
+		})
+	}
+
+	// 3. Route the appeal
+	handler, err := s.appealHandler(review)
+	if err != nil {
+		return nil, err
+	}
+
+	appeal := &models.Appeal{
+		ReviewID:    reviewID,
+		AppellantID: userID,
+		HandlerID:   handler.ID,
+		Reason:      input.Reason,
+		Status:      "待处理",
+		Items:       appealItems,
+		Events: []models.AppealEvent{
+			{ActorID: userID, Status: "已提交", Comment: input.Reason},
+		},
+	}
+	if err := s.repo.CreateAndLockReview(appeal); err != nil {
+		return nil, err
+	}
+	return appeal, nil
+}
+
+// GetAppeal retrieves an appeal. Only the appellant, the handler and HR can view it.
+func (s *appealService) GetAppeal(id uint, user *models.User) (*models.Appeal, error) {
+	appeal, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, errors.New("申诉不存在")
+	}
+	if appeal.AppellantID != user.ID && appeal.HandlerID != user.ID && !isHRUser(user) {
+		return nil, errors.New("您无权查看此申诉")
+	}
+	return appeal, nil
+}
+
+// ListReviewAppeals retrieves the appeals filed against a review. Only the owner, the handlers and HR can view them.
+func (s *appealService) ListReviewAppeals(reviewID uint, user *models.User) ([]models.Appeal, error) {
+	review, err := s.reviewRepo.GetByID(reviewID)
+	if err != nil {
+		return nil, errors.New("绩效评估不存在")
+	}
+	appeals, err := s.repo.ListByReviewID(reviewID)
+	if err != nil {
+		return nil, err
+	}
+	if review.UserID == user.ID || isHRUser(user) {
+		return appeals, nil
+	}
+	for _, appeal := range appeals {
+		if appeal.HandlerID == user.ID {
+			return appeals, nil
+		}
+	}
+	return nil, errors.New("您无权查看此绩效评估的申诉")
+}
+
+// ListMyAppeals retrieves the appeals a user has filed and the ones assigned to them.
+func (s *appealService) ListMyAppeals(userID uint) (*AppealList, error) {
+	filed, err := s.repo.ListByAppellantID(userID)
+	if err != nil {
+		return nil, err
+	}
+	assigned, err := s.repo.ListByHandlerID(userID)
+	if err != nil {
+		return nil, err
+	}
+	return &AppealList{Filed: filed, Assigned: assigned}, nil
+}
+
+// ResolveAppeal lets the handler uphold the original score or revise the disputed items.
+// A revision recomputes the total score and grade point. Either way the review returns to '已完成'
+// so the employee can acknowledge the outcome, and cannot be appealed again.
+func (s *appealService) ResolveAppeal(id uint, handlerID uint, input *AppealResolutionInput) error {
+	appeal, err := s.repo.GetByID(id)
+	if err != nil {
+		return errors.New("申诉不存在")
+	}
+	if appeal.HandlerID != handlerID {
+		return errors.New("您无权处理此申诉")
+	}
+	if appeal.Status != "待处理" {
+		return errors.New("只有待处理的申诉才能处理")
+	}
+
+	now := time.Now()
+	reviewUpdates := map[string]interface{}{"status": "已完成", "appeal_resolved_at": now}
+	var revisedItems []models.PerformanceItem
+
+	switch input.Decision {
+	case "uphold":
+		appeal.Status = "维持原分"
+	case "revise":
+		if len(input.Items) == 0 {
+			return errors.New("改分时必须提供至少一个申诉项的新分数")
+		}
+		appealItemMap := make(map[uint]*models.AppealItem)
+		for i := range appeal.Items {
+			appealItemMap[appeal.Items[i].PerformanceItemID] = &appeal.Items[i]
+		}
+		revisedScores := make(map[uint]*float64)
+		for _, revision := range input.Items {
+			appealItem, ok := appealItemMap[revision.ItemID]
+			if !ok {
+				return errors.New("只能修改申诉中的绩效项")
+			}
+			if revision.Score == nil || *revision.Score < 0 || *revision.Score > 120 {
+				return errors.New("单项分数必须在0到120之间")
+			}
+			appealItem.RevisedScore = revision.Score
+			revisedScores[revision.ItemID] = revision.Score
+			revisedItems = append(revisedItems, models.PerformanceItem{ID: revision.ItemID, Score: revision.Score})
+		}
+
+		// Recompute the total with the revised scores applied
+		items := make([]models.PerformanceItem, len(appeal.Review.Items))
+		copy(items, appeal.Review.Items)
+		for i := range items {
+			if score, ok := revisedScores[items[i].ID]; ok {
+				items[i].Score = score
+			}
+		}
+		result, err := recomputeResult(s.db, s.systemSettingService, &appeal.Review, calculateTotalScore(items))
+		if err != nil {
+			return err
+		}
+		for column, value := range result.updates() {
+			reviewUpdates[column] = value
+		}
+		reviewUpdates["scored_at"] = now
+		appeal.Status = "已改分"
+	default:
+		return errors.New("无效的处理结果")
+	}
+
+	appeal.Resolution = input.Comment
+	appeal.ResolvedAt = &now
+	event := &models.AppealEvent{ActorID: handlerID, Status: appeal.Status, Comment: input.Comment}
+	if err := s.repo.Resolve(appeal, event, revisedItems, reviewUpdates); err != nil {
+		return err
+	}
+	if appeal.Status == "已改分" {
+		reevaluateResults(s.improvementPlans, appeal.ReviewID)
+	}
+	return nil
+}
+
+// checkAppealable checks that the user may appeal the review: it must be their own scored, unconfirmed
+// result outside HR calibration, and a result can only be appealed once, withdrawn appeals included.
+func checkAppealable(review *models.PerformanceReview, userID uint) error {
+	if review.UserID != userID {
+		return errors.New("只能对自己的考核结果提出申诉")
+	}
+	if review.Status != "已完成" {
+		return errors.New("只有已完成打分且尚未确认的绩效评估才能申诉")
+	}
+	if review.AppealResolvedAt != nil {
+		return errors.New("该绩效评估的申诉已处理，不能再次申诉")
+	}
+	return ensureNotCalibrating(review)
+}
+
+// WithdrawAppeal lets the appellant withdraw a pending appeal, returning the review to '已完成'.
+func (s *appealService) WithdrawAppeal(id uint, userID uint, comment string) error {
+	appeal, err := s.repo.GetByID(id)
+	if err != nil {
+		return errors.New("申诉不存在")
+	}
+	if appeal.AppellantID != userID {
+		return errors.New("只能撤回自己的申诉")
+	}
+	if appeal.Status != "待处理" {
+		return errors.New("只有待处理的申诉才能撤回")
+	}
+
+	now := time.Now()
+	appeal.Status = "已撤回"
+	appeal.ResolvedAt = &now
+	event := &models.AppealEvent{ActorID: userID, Status: appeal.Status, Comment: comment}
+	return s.repo.Resolve(appeal, event, nil, map[string]interface{}{"status": "已完成", "appeal_resolved_at": now})
+}
+
+// appealHandler returns the user an appeal is routed to, based on the appeal_handler setting.
+func (s *appealService) appealHandler(review *models.PerformanceReview) (*models.User, error) {
+	if setting, err := s.systemSettingService.GetSetting(SettingAppealHandler); err == nil && setting.Value == "skip_level" {
+		if skipLevel, err := findSkipLevelManager(s.db, &review.User); err == nil {
+			return skipLevel, nil
+		}
+	}
+	hr, err := findHRUser(s.db)
+	if err != nil {
+		return nil, errors.New("找不到申诉处理人")
+	}
+	return hr, nil
+}
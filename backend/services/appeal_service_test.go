@@ -0,0 +1,35 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"cepm-backend/models"
+)
+
+func TestCheckAppealable(t *testing.T) {
+	resolvedAt := time.Date(2025, 8, 3, 10, 0, 0, 0, time.UTC)
+	sessionID := uint(4)
+
+	tests := []struct {
+		name    string
+		review  models.PerformanceReview
+		userID  uint
+		wantErr bool
+	}{
+		{"own completed result", models.PerformanceReview{UserID: 1, Status: "已完成"}, 1, false},
+		{"someone else's result", models.PerformanceReview{UserID: 1, Status: "已完成"}, 2, true},
+		{"appeal already open", models.PerformanceReview{UserID: 1, Status: "申诉中"}, 1, true},
+		{"result already acknowledged", models.PerformanceReview{UserID: 1, Status: "待人事确认"}, 1, true},
+		{"re-filing after a decided or withdrawn appeal", models.PerformanceReview{UserID: 1, Status: "已完成", AppealResolvedAt: &resolvedAt}, 1, true},
+		{"locked by HR calibration", models.PerformanceReview{UserID: 1, Status: "已完成", CalibrationSessionID: &sessionID}, 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkAppealable(&tt.review, tt.userID)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkAppealable error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
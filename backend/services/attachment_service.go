@@ -0,0 +1,240 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"cepm-backend/config"
+	"cepm-backend/models"
+	"cepm-backend/repositories"
+	"cepm-backend/storage"
+)
+
+// defaultAllowedMimeTypes are accepted when the storage configuration does not list any.
+var defaultAllowedMimeTypes = []string{
+	"image/png",
+	"image/jpeg",
+	"image/gif",
+	"application/pdf",
+	"application/zip",
+	"text/plain; charset=utf-8",
+}
+
+// LinkInput defines the structure for attaching a link as evidence.
+type LinkInput struct {
+	URL   string `json:"url"`
+	Title string `json:"title"`
+}
+
+// AttachmentService defines the interface for evidence attachments on review items.
+type AttachmentService interface {
+	MaxUploadBytes() int64
+	UploadFile(reviewID uint, itemID uint, uploader *models.User, fileName string, content io.Reader) (*models.Attachment, error)
+	AddLink(reviewID uint, itemID uint, uploader *models.User, input *LinkInput) (*models.Attachment, error)
+	ListAttachments(reviewID uint, itemID *uint, viewer *models.User) ([]models.Attachment, error)
+	OpenAttachment(id uint, viewer *models.User) (*models.Attachment, io.ReadCloser, error)
+	DeleteAttachment(id uint, actor *models.User) error
+}
+
+type attachmentService struct {
+	repo         repositories.AttachmentRepository
+	reviewRepo   repositories.PerformanceReviewRepository
+	storage      storage.Storage
+	maxBytes     int64
+	allowedTypes map[string]bool
+}
+
+// NewAttachmentService creates a new instance of AttachmentService.
+func NewAttachmentService(repo repositories.AttachmentRepository, reviewRepo repositories.PerformanceReviewRepository, store storage.Storage, cfg *config.StorageConfig) AttachmentService {
+	maxMB := cfg.MaxUploadMB
+	if maxMB <= 0 {
+		maxMB = 20
+	}
+	types := cfg.AllowedMimeTypes
+	if len(types) == 0 {
+		types = defaultAllowedMimeTypes
+	}
+	allowedTypes := make(map[string]bool)
+	for _, t := range types {
+		allowedTypes[t] = true
+	}
+	return &attachmentService{
+		repo:         repo,
+		reviewRepo:   reviewRepo,
+		storage:      store,
+		maxBytes:     int64(maxMB) << 20,
+		allowedTypes: allowedTypes,
+	}
+}
+
+// MaxUploadBytes returns the maximum size of an uploaded file.
+func (s *attachmentService) MaxUploadBytes() int64 {
+	return s.maxBytes
+}
+
+// UploadFile stores a file as evidence for a review item. The MIME type is detected from the content,
+// and files with the same content are stored only once.
+func (s *attachmentService) UploadFile(reviewID uint, itemID uint, uploader *models.User, fileName string, content io.Reader) (*models.Attachment, error) {
+	if _, err := s.editableReview(reviewID, itemID, uploader); err != nil {
+		return nil, err
+	}
+
+	// 1. Read the file within the size limit
+	data, err := io.ReadAll(io.LimitReader(content, s.maxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, errors.New("文件不能为空")
+	}
+	if int64(len(data)) > s.maxBytes {
+		return nil, fmt.Errorf("文件大小不能超过%dMB", s.maxBytes>>20)
+	}
+
+	// 2. Check the detected MIME type
+	contentType := http.DetectContentType(data)
+	if !s.allowedTypes[contentType] {
+		return nil, fmt.Errorf("不支持的文件类型: %s", contentType)
+	}
+
+	// 3. Store the content unless an identical file already exists
+	sum := sha256.Sum256(data)
+	checksum := hex.EncodeToString(sum[:])
+	storageKey := "sha256/" + checksum[:2] + "/" + checksum
+	attachment := &models.Attachment{
+		ReviewID:     reviewID,
+		ItemID:       itemID,
+		Kind:         "file",
+		FileName:     filepath.Base(fileName),
+		ContentType:  contentType,
+		Size:         int64(len(data)),
+		Checksum:     checksum,
+		StorageKey:   storageKey,
+		UploadedByID: uploader.ID,
+	}
+	err = s.repo.CreateFile(attachment, func() error {
+		return s.storage.Put(storageKey, data, contentType)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return attachment, nil
+}
+
+// AddLink attaches an http(s) link as evidence for a review item.
+func (s *attachmentService) AddLink(reviewID uint, itemID uint, uploader *models.User, input *LinkInput) (*models.Attachment, error) {
+	if _, err := s.editableReview(reviewID, itemID, uploader); err != nil {
+		return nil, err
+	}
+	parsed, err := url.Parse(strings.TrimSpace(input.URL))
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, errors.New("链接必须是有效的http或https地址")
+	}
+	title := input.Title
+	if title == "" {
+		title = parsed.String()
+	}
+
+	attachment := &models.Attachment{
+		ReviewID:     reviewID,
+		ItemID:       itemID,
+		Kind:         "link",
+		FileName:     title,
+		URL:          parsed.String(),
+		UploadedByID: uploader.ID,
+	}
+	if err := s.repo.Create(attachment); err != nil {
+		return nil, err
+	}
+	return attachment, nil
+}
+
+// ListAttachments retrieves the attachments of a review. They are visible to the employee, their direct manager and HR.
+func (s *attachmentService) ListAttachments(reviewID uint, itemID *uint, viewer *models.User) ([]models.Attachment, error) {
+	review, err := s.reviewRepo.GetByID(reviewID)
+	if err != nil {
+		return nil, errors.New("绩效评估不存在")
+	}
+	if !canFollowReview(review, viewer) {
+		return nil, errors.New("您无权查看此绩效评估的附件")
+	}
+	return s.repo.ListByReviewID(reviewID, itemID)
+}
+
+// OpenAttachment returns a file attachment with its content, following the review's view permissions.
+// For links the reader is nil.
+func (s *attachmentService) OpenAttachment(id uint, viewer *models.User) (*models.Attachment, io.ReadCloser, error) {
+	attachment, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, nil, errors.New("附件不存在")
+	}
+	review, err := s.reviewRepo.GetByID(attachment.ReviewID)
+	if err != nil {
+		return nil, nil, errors.New("绩效评估不存在")
+	}
+	if !canFollowReview(review, viewer) {
+		return nil, nil, errors.New("您无权查看此附件")
+	}
+	if attachment.Kind == "link" {
+		return attachment, nil, nil
+	}
+	reader, err := s.storage.Get(attachment.StorageKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return attachment, reader, nil
+}
+
+// DeleteAttachment removes an attachment. Only the uploader or HR can delete it, and not once the review is archived.
+// The stored file is removed when no other attachment references it.
+func (s *attachmentService) DeleteAttachment(id uint, actor *models.User) error {
+	attachment, err := s.repo.GetByID(id)
+	if err != nil {
+		return errors.New("附件不存在")
+	}
+	if attachment.UploadedByID != actor.ID && !isHRUser(actor) {
+		return errors.New("只能删除自己上传的附件")
+	}
+	review, err := s.reviewRepo.GetByID(attachment.ReviewID)
+	if err != nil {
+		return errors.New("绩效评估不存在")
+	}
+	if review.Status == "已归档" {
+		return errors.New("已归档的绩效评估不能删除附件")
+	}
+
+	if attachment.Kind != "file" {
+		return s.repo.Delete(id)
+	}
+	return s.repo.DeleteFile(attachment, func() error {
+		return s.storage.Delete(attachment.StorageKey)
+	})
+}
+
+// editableReview checks that the user can attach evidence to the item: the employee, their direct manager or HR,
+// while the review has not been archived.
+func (s *attachmentService) editableReview(reviewID uint, itemID uint, user *models.User) (*models.PerformanceReview, error) {
+	review, err := s.reviewRepo.GetByID(reviewID)
+	if err != nil {
+		return nil, errors.New("绩效评估不存在")
+	}
+	if !canFollowReview(review, user) {
+		return nil, errors.New("您无权为此绩效评估上传附件")
+	}
+	if review.Status == "已归档" {
+		return nil, errors.New("已归档的绩效评估不能上传附件")
+	}
+	for _, item := range review.Items {
+		if item.ID == itemID {
+			return review, nil
+		}
+	}
+	return nil, errors.New("无效的绩效项ID")
+}
@@ -0,0 +1,523 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"cepm-backend/database"
+	"cepm-backend/models"
+	"cepm-backend/repositories"
+	"cepm-backend/xlsx"
+
+	"gorm.io/gorm"
+)
+
+// bonusBaseHeadings maps the accepted headings of a base amount import to their fields.
+var bonusBaseHeadings = map[string]string{
+	"员工ID":   "userId",
+	"企业微信账号": "wechatUserId",
+	"等级":     "gradeBand",
+	"考核等级":   "gradeBand",
+	"奖金基数":   "amount",
+	"基数":     "amount",
+}
+
+// BonusBaseInput defines a base amount for one employee or for a grade band.
+type BonusBaseInput struct {
+	UserID    *uint   `json:"userId"`
+	GradeBand string  `json:"gradeBand"`
+	Amount    float64 `json:"amount"`
+}
+
+// BonusBaseImportResult reports the bases read from an import file and the rows that were rejected.
+// Nothing is saved unless every row is valid.
+type BonusBaseImportResult struct {
+	DryRun    bool               `json:"dryRun"`
+	Committed bool               `json:"committed"`
+	Bases     []models.BonusBase `json:"bases"`
+	Errors    []ImportRowError   `json:"errors"`
+}
+
+// BonusBudgetInput defines the budget cap of a department subtree.
+type BonusBudgetInput struct {
+	DepartmentID uint    `json:"departmentId"`
+	Amount       float64 `json:"amount"`
+}
+
+// BonusRunInput defines the structure for calculating the bonuses of a period.
+type BonusRunInput struct {
+	PayrollBatchID uint               `json:"payrollBatchId"`
+	Budgets        []BonusBudgetInput `json:"budgets"`
+}
+
+// BonusService defines the interface for bonus bases, calculation runs and their approval.
+type BonusService interface {
+	ListBases() ([]models.BonusBase, error)
+	SetBases(hrID uint, inputs []BonusBaseInput) ([]models.BonusBase, error)
+	DeleteBase(id uint) error
+	ImportBases(hrID uint, data []byte, dryRun bool) (*BonusBaseImportResult, error)
+	CreateRun(hrID uint, input *BonusRunInput) (*models.BonusRun, error)
+	ListRuns(period string) ([]models.BonusRun, error)
+	GetRun(id uint) (*models.BonusRun, error)
+	ApproveRun(id uint, approverID uint, comment string) error
+	RejectRun(id uint, approverID uint, comment string) error
+	ExportRun(id uint, format string) (*ExportFile, error)
+}
+
+type bonusService struct {
+	repo           repositories.BonusRepository
+	payrollService PayrollService
+	db             *gorm.DB
+}
+
+// NewBonusService creates a new instance of BonusService.
+func NewBonusService(repo repositories.BonusRepository, payrollService PayrollService) BonusService {
+	return &bonusService{repo: repo, payrollService: payrollService, db: database.DB}
+}
+
+// ListBases retrieves the configured base amounts.
+func (s *bonusService) ListBases() ([]models.BonusBase, error) {
+	return s.repo.ListBases()
+}
+
+// SetBases creates or updates base amounts, each for one employee or one grade band.
+func (s *bonusService) SetBases(hrID uint, inputs []BonusBaseInput) ([]models.BonusBase, error) {
+	if len(inputs) == 0 {
+		return nil, errors.New("奖金基数不能为空")
+	}
+	bases := make([]models.BonusBase, 0, len(inputs))
+	seen := make(map[string]bool)
+	for _, input := range inputs {
+		base, err := newBonusBase(hrID, input)
+		if err != nil {
+			return nil, err
+		}
+		key := bonusBaseKey(base)
+		if seen[key] {
+			return nil, errors.New("同一员工或等级的奖金基数不能重复设置")
+		}
+		seen[key] = true
+		if base.UserID != nil {
+			var count int64
+			if err := s.db.Model(&models.User{}).Where("id = ?", *base.UserID).Count(&count).Error; err != nil {
+				return nil, err
+			}
+			if count == 0 {
+				return nil, errors.New("员工不存在")
+			}
+		}
+		bases = append(bases, *base)
+	}
+	if err := s.repo.SaveBases(bases); err != nil {
+		return nil, err
+	}
+	return bases, nil
+}
+
+// DeleteBase removes a base amount. Runs already calculated keep the base they used.
+func (s *bonusService) DeleteBase(id uint) error {
+	if err := s.repo.DeleteBase(id); err != nil {
+		return errors.New("奖金基数不存在")
+	}
+	return nil
+}
+
+// ImportBases reads base amounts from a csv or xlsx file whose heading row names the columns:
+// 员工ID or 企业微信账号 for an employee, or 等级 for a grade band, and 奖金基数.
+func (s *bonusService) ImportBases(hrID uint, data []byte, dryRun bool) (*BonusBaseImportResult, error) {
+	sheetName, rows, err := readTableFile(data)
+	if err != nil {
+		return nil, errors.New("无法读取文件：" + err.Error())
+	}
+	if len(rows) < 2 {
+		return nil, errors.New("文件中没有奖金基数")
+	}
+	columns := make(map[string]int)
+	for i, heading := range rows[0] {
+		if field, ok := bonusBaseHeadings[strings.TrimSpace(heading)]; ok {
+			columns[field] = i
+		}
+	}
+	if _, ok := columns["amount"]; !ok {
+		return nil, errors.New("文件缺少奖金基数列")
+	}
+
+	var users []models.User
+	if err := s.db.Select("id", "wechat_userid").Find(&users).Error; err != nil {
+		return nil, err
+	}
+	userIDs := make(map[uint]bool, len(users))
+	wechatUsers := make(map[string]uint, len(users))
+	for _, user := range users {
+		userIDs[user.ID] = true
+		wechatUsers[user.WechatUserid] = user.ID
+	}
+
+	result := &BonusBaseImportResult{DryRun: dryRun, Bases: []models.BonusBase{}, Errors: []ImportRowError{}}
+	cell := func(row []string, field string) string {
+		if i, ok := columns[field]; ok && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+	seen := make(map[string]int)
+	for r, row := range rows[1:] {
+		rowNumber := r + 2
+		if strings.TrimSpace(strings.Join(row, "")) == "" {
+			continue
+		}
+		rowError := func(message string) {
+			result.Errors = append(result.Errors, ImportRowError{Sheet: sheetName, Row: rowNumber, Message: message})
+		}
+
+		input := BonusBaseInput{GradeBand: cell(row, "gradeBand")}
+		amount, err := strconv.ParseFloat(strings.ReplaceAll(cell(row, "amount"), ",", ""), 64)
+		if err != nil {
+			rowError("奖金基数必须是数字")
+			continue
+		}
+		input.Amount = amount
+		switch userID, wechatUserID := cell(row, "userId"), cell(row, "wechatUserId"); {
+		case userID != "":
+			id, err := strconv.ParseUint(userID, 10, 32)
+			if err != nil || !userIDs[uint(id)] {
+				rowError("员工ID不存在：" + userID)
+				continue
+			}
+			uid := uint(id)
+			input.UserID = &uid
+			input.GradeBand = ""
+		case wechatUserID != "":
+			id, ok := wechatUsers[wechatUserID]
+			if !ok {
+				rowError("企业微信账号不存在：" + wechatUserID)
+				continue
+			}
+			input.UserID = &id
+			input.GradeBand = ""
+		}
+
+		base, err := newBonusBase(hrID, input)
+		if err != nil {
+			rowError(err.Error())
+			continue
+		}
+		key := bonusBaseKey(base)
+		if first, ok := seen[key]; ok {
+			rowError(fmt.Sprintf("与第%d行重复", first))
+			continue
+		}
+		seen[key] = rowNumber
+		result.Bases = append(result.Bases, *base)
+	}
+
+	if dryRun || len(result.Errors) > 0 {
+		return result, nil
+	}
+	if err := s.repo.SaveBases(result.Bases); err != nil {
+		return nil, err
+	}
+	result.Committed = true
+	return result, nil
+}
+
+// CreateRun calculates the bonuses of a company-wide payroll export batch as base amount × coefficient n.
+// Every employee needs a base amount. The lines of each budgeted department subtree are pro-rated when
+// their total exceeds the budget. The run replaces the pending runs of its period and waits for approval.
+func (s *bonusService) CreateRun(hrID uint, input *BonusRunInput) (*models.BonusRun, error) {
+	batch, err := s.payrollService.GetBatch(input.PayrollBatchID)
+	if err != nil {
+		return nil, err
+	}
+	// Runs replace each other per period, so they must cover the whole company
+	if batch.DepartmentID != nil {
+		return nil, errors.New("奖金只能按全公司的薪酬系数导出批次计算")
+	}
+	reconciliation, err := s.payrollService.ReconcileBatch(batch.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(reconciliation.Changes) > 0 {
+		return nil, fmt.Errorf("薪酬系数导出后考核结果有%d处变化，请重新导出后再计算奖金", len(reconciliation.Changes))
+	}
+
+	// Budgets
+	var departments []models.Department
+	if err := s.db.Find(&departments).Error; err != nil {
+		return nil, err
+	}
+	parents := make(map[uint]*uint, len(departments))
+	names := make(map[uint]string, len(departments))
+	for _, department := range departments {
+		parents[department.ID] = department.ParentID
+		names[department.ID] = department.Name
+	}
+	budgets := make(map[uint]*models.BonusBudget)
+	for _, budgetInput := range input.Budgets {
+		if _, ok := parents[budgetInput.DepartmentID]; !ok {
+			return nil, errors.New("部门不存在")
+		}
+		if budgetInput.Amount < 0 {
+			return nil, errors.New("部门预算不能为负数")
+		}
+		if budgets[budgetInput.DepartmentID] != nil {
+			return nil, errors.New("同一部门的预算不能重复设置")
+		}
+		budgets[budgetInput.DepartmentID] = &models.BonusBudget{DepartmentID: budgetInput.DepartmentID, Amount: round2(budgetInput.Amount), ProrateFactor: 1}
+	}
+	// A line is capped by the nearest budgeted department only, so budgets must not be nested
+	if child, ancestor, nested := nestedBudget(budgets, parents); nested {
+		return nil, fmt.Errorf("%s的上级部门%s已设置预算，部门预算不能嵌套", names[child], names[ancestor])
+	}
+
+	// Base amounts
+	bases, err := s.repo.ListBases()
+	if err != nil {
+		return nil, err
+	}
+	userBases := make(map[uint]float64)
+	bandBases := make(map[string]float64)
+	for _, base := range bases {
+		if base.UserID != nil {
+			userBases[*base.UserID] = base.Amount
+		} else if base.GradeBand != nil {
+			bandBases[*base.GradeBand] = base.Amount
+		}
+	}
+
+	lines := make([]models.BonusLine, 0, len(batch.Lines))
+	var unconfigured []string
+	for _, exported := range batch.Lines {
+		line := models.BonusLine{
+			ReviewID:       exported.ReviewID,
+			UserID:         exported.UserID,
+			EmployeeName:   exported.EmployeeName,
+			DepartmentName: exported.DepartmentName,
+			GradeBand:      exported.GradeBand,
+			GradePoint:     exported.GradePoint,
+			ProrateFactor:  1,
+		}
+		if amount, ok := userBases[exported.UserID]; ok {
+			line.BaseSource, line.BaseAmount = "员工", amount
+		} else if amount, ok := bandBases[exported.GradeBand]; ok {
+			line.BaseSource, line.BaseAmount = "等级", amount
+		} else {
+			unconfigured = append(unconfigured, exported.EmployeeName)
+			continue
+		}
+		line.RequestedAmount = round2(line.BaseAmount * line.GradePoint)
+		line.Amount = line.RequestedAmount
+
+		departmentID := exported.DepartmentID
+		for steps := 0; departmentID != nil && steps <= len(parents); steps++ {
+			if budget, ok := budgets[*departmentID]; ok {
+				line.BudgetDepartmentID = &budget.DepartmentID
+				budget.RequestedAmount = round2(budget.RequestedAmount + line.RequestedAmount)
+				break
+			}
+			departmentID = parents[*departmentID]
+		}
+		lines = append(lines, line)
+	}
+
+	if len(unconfigured) > 0 {
+		return nil, fmt.Errorf("以下%d名员工既没有个人奖金基数也没有所在等级的奖金基数：%s", len(unconfigured), strings.Join(unconfigured, "、"))
+	}
+
+	run := &models.BonusRun{Period: batch.Period, PayrollBatchID: batch.ID, Status: "待审批", CreatedByID: hrID}
+	run.TotalAmount = prorateBonusLines(lines, budgets)
+	for _, budgetInput := range input.Budgets {
+		run.Budgets = append(run.Budgets, *budgets[budgetInput.DepartmentID])
+	}
+	run.Lines = lines
+
+	if err := s.repo.CreateRun(run); err != nil {
+		if errors.Is(err, repositories.ErrBonusPeriodApproved) {
+			return nil, errors.New("该周期的奖金已审批锁定")
+		}
+		return nil, err
+	}
+	return run, nil
+}
+
+// nestedBudget reports a budgeted department with a budgeted ancestor, if any.
+func nestedBudget(budgets map[uint]*models.BonusBudget, parents map[uint]*uint) (child, ancestor uint, nested bool) {
+	for departmentID := range budgets {
+		parentID := parents[departmentID]
+		for steps := 0; parentID != nil && steps <= len(parents); steps++ {
+			if _, ok := budgets[*parentID]; ok {
+				return departmentID, *parentID, true
+			}
+			parentID = parents[*parentID]
+		}
+	}
+	return 0, 0, false
+}
+
+// prorateBonusLines scales down the lines of the departments whose requested amount exceeds their budget,
+// rounding down to the cent so the cap is never exceeded, and returns the total amount of the lines.
+// The budgets' requested amounts must already be summed; their allocated amounts and factors are set.
+func prorateBonusLines(lines []models.BonusLine, budgets map[uint]*models.BonusBudget) float64 {
+	for _, budget := range budgets {
+		if budget.RequestedAmount > budget.Amount {
+			budget.ProrateFactor = budget.Amount / budget.RequestedAmount
+		}
+	}
+	var total float64
+	for i := range lines {
+		if lines[i].BudgetDepartmentID != nil {
+			budget := budgets[*lines[i].BudgetDepartmentID]
+			if budget.ProrateFactor < 1 {
+				lines[i].ProrateFactor = math.Round(budget.ProrateFactor*1e6) / 1e6
+				lines[i].Amount = math.Floor(lines[i].RequestedAmount*budget.ProrateFactor*100+1e-6) / 100
+			}
+			budget.AllocatedAmount = round2(budget.AllocatedAmount + lines[i].Amount)
+		}
+		total = round2(total + lines[i].Amount)
+	}
+	for _, budget := range budgets {
+		budget.ProrateFactor = math.Round(budget.ProrateFactor*1e6) / 1e6
+	}
+	return total
+}
+
+// ListRuns retrieves the calculation runs, optionally filtered by period.
+func (s *bonusService) ListRuns(period string) ([]models.BonusRun, error) {
+	return s.repo.ListRuns(period)
+}
+
+// GetRun retrieves a run with its budgets and lines.
+func (s *bonusService) GetRun(id uint) (*models.BonusRun, error) {
+	run, err := s.repo.GetRun(id)
+	if err != nil {
+		return nil, errors.New("奖金计算不存在")
+	}
+	return run, nil
+}
+
+// ApproveRun approves a pending run, locking its lines and the bonuses of its period.
+// The approver cannot be the person who calculated the run.
+func (s *bonusService) ApproveRun(id uint, approverID uint, comment string) error {
+	return s.reviewRun(id, approverID, "已批准", comment)
+}
+
+// RejectRun rejects a pending run with a reason, so that HR can calculate it again.
+func (s *bonusService) RejectRun(id uint, approverID uint, comment string) error {
+	if comment == "" {
+		return errors.New("驳回时必须填写理由")
+	}
+	return s.reviewRun(id, approverID, "已驳回", comment)
+}
+
+func (s *bonusService) reviewRun(id uint, approverID uint, status string, comment string) error {
+	run, err := s.GetRun(id)
+	if err != nil {
+		return err
+	}
+	if run.CreatedByID == approverID {
+		return errors.New("不能审批自己计算的奖金")
+	}
+	if run.Status != "待审批" {
+		return errors.New("只有待审批的奖金计算才能审批")
+	}
+	if err := s.repo.ReviewRun(id, status, approverID, comment); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("只有待审批的奖金计算才能审批")
+		}
+		return err
+	}
+	return nil
+}
+
+// ExportRun writes the lines of an approved run as csv or xlsx for payroll.
+func (s *bonusService) ExportRun(id uint, format string) (*ExportFile, error) {
+	if format == "" {
+		format = "csv"
+	}
+	if format != "csv" && format != "xlsx" {
+		return nil, errors.New("导出格式只能是csv或xlsx")
+	}
+	run, err := s.GetRun(id)
+	if err != nil {
+		return nil, err
+	}
+	if run.Status != "已批准" {
+		return nil, errors.New("只有已批准的奖金计算才能导出")
+	}
+
+	header := []string{"员工ID", "姓名", "部门", "考核周期", "考核等级", "月度考核系数n", "基数来源", "奖金基数", "计算奖金", "折算系数", "实发奖金"}
+	rows := make([][]xlsx.Cell, 0, len(run.Lines))
+	for _, line := range run.Lines {
+		rows = append(rows, []xlsx.Cell{
+			xlsx.TextCell(strconv.FormatUint(uint64(line.UserID), 10)),
+			xlsx.TextCell(line.EmployeeName),
+			xlsx.TextCell(line.DepartmentName),
+			xlsx.TextCell(run.Period),
+			xlsx.TextCell(line.GradeBand),
+			xlsx.NumberCell(line.GradePoint),
+			xlsx.TextCell(line.BaseSource),
+			xlsx.NumberCell(line.BaseAmount),
+			xlsx.NumberCell(line.RequestedAmount),
+			xlsx.NumberCell(line.ProrateFactor),
+			xlsx.NumberCell(line.Amount),
+		})
+	}
+	file, err := renderTableFile(format, run.Period, header, rows)
+	if err != nil {
+		return nil, err
+	}
+	file.FileName = fmt.Sprintf("奖金_%s_批次%d.%s", run.Period, run.ID, format)
+	return file, nil
+}
+
+// newBonusBase validates a base amount input.
+func newBonusBase(hrID uint, input BonusBaseInput) (*models.BonusBase, error) {
+	if input.Amount < 0 {
+		return nil, errors.New("奖金基数不能为负数")
+	}
+	base := &models.BonusBase{Amount: round2(input.Amount), UpdatedByID: hrID}
+	switch {
+	case input.UserID != nil && input.GradeBand != "":
+		return nil, errors.New("奖金基数只能按员工或按等级设置其中之一")
+	case input.UserID != nil:
+		base.UserID = input.UserID
+	case containsString(gradeBands, input.GradeBand):
+		band := input.GradeBand
+		base.GradeBand = &band
+	case input.GradeBand != "":
+		return nil, errors.New("无效的考核等级：" + input.GradeBand)
+	default:
+		return nil, errors.New("奖金基数必须指定员工或考核等级")
+	}
+	return base, nil
+}
+
+func bonusBaseKey(base *models.BonusBase) string {
+	if base.UserID != nil {
+		return fmt.Sprintf("user:%d", *base.UserID)
+	}
+	return "band:" + *base.GradeBand
+}
+
+// readTableFile reads the first sheet of an xlsx file, or a UTF-8 csv file.
+func readTableFile(data []byte) (string, [][]string, error) {
+	if bytes.HasPrefix(data, []byte("PK")) {
+		sheets, err := xlsx.ReadWorkbook(data)
+		if err != nil {
+			return "", nil, err
+		}
+		if len(sheets) == 0 {
+			return "", nil, errors.New("工作簿中没有工作表")
+		}
+		return sheets[0].Name, sheets[0].Rows, nil
+	}
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\ufeff"))))
+	reader.FieldsPerRecord = -1
+	rows, err := reader.ReadAll()
+	return "", rows, err
+}
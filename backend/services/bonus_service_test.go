@@ -0,0 +1,163 @@
+package services
+
+import (
+	"testing"
+
+	"cepm-backend/models"
+)
+
+func TestProrateBonusLines(t *testing.T) {
+	type line struct {
+		department uint // 0 for a line outside every budget
+		requested  float64
+	}
+	tests := []struct {
+		name       string
+		budgets    map[uint]float64
+		lines      []line
+		amounts    []float64
+		factors    []float64
+		allocated  map[uint]float64
+		budgetRate map[uint]float64
+		total      float64
+	}{
+		{
+			name:       "under budget",
+			budgets:    map[uint]float64{1: 1000},
+			lines:      []line{{1, 300}, {1, 400}},
+			amounts:    []float64{300, 400},
+			factors:    []float64{1, 1},
+			allocated:  map[uint]float64{1: 700},
+			budgetRate: map[uint]float64{1: 1},
+			total:      700,
+		},
+		{
+			name:       "exactly on budget",
+			budgets:    map[uint]float64{1: 700},
+			lines:      []line{{1, 300}, {1, 400}},
+			amounts:    []float64{300, 400},
+			factors:    []float64{1, 1},
+			allocated:  map[uint]float64{1: 700},
+			budgetRate: map[uint]float64{1: 1},
+			total:      700,
+		},
+		{
+			name:       "over budget with an unbudgeted line",
+			budgets:    map[uint]float64{1: 1000},
+			lines:      []line{{1, 600}, {1, 900}, {0, 250}},
+			amounts:    []float64{400, 600, 250},
+			factors:    []float64{0.666667, 0.666667, 1},
+			allocated:  map[uint]float64{1: 1000},
+			budgetRate: map[uint]float64{1: 0.666667},
+			total:      1250,
+		},
+		{
+			name:       "rounds down so the cap is not exceeded",
+			budgets:    map[uint]float64{1: 100},
+			lines:      []line{{1, 33.34}, {1, 33.34}, {1, 33.34}},
+			amounts:    []float64{33.33, 33.33, 33.33},
+			factors:    []float64{0.9998, 0.9998, 0.9998},
+			allocated:  map[uint]float64{1: 99.99},
+			budgetRate: map[uint]float64{1: 0.9998},
+			total:      99.99,
+		},
+		{
+			name:       "zero budget",
+			budgets:    map[uint]float64{1: 0},
+			lines:      []line{{1, 500}},
+			amounts:    []float64{0},
+			factors:    []float64{0},
+			allocated:  map[uint]float64{1: 0},
+			budgetRate: map[uint]float64{1: 0},
+			total:      0,
+		},
+		{
+			name:       "departments are pro-rated independently",
+			budgets:    map[uint]float64{1: 500, 2: 1000},
+			lines:      []line{{1, 1000}, {2, 800}},
+			amounts:    []float64{500, 800},
+			factors:    []float64{0.5, 1},
+			allocated:  map[uint]float64{1: 500, 2: 800},
+			budgetRate: map[uint]float64{1: 0.5, 2: 1},
+			total:      1300,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			budgets := make(map[uint]*models.BonusBudget)
+			for id, amount := range tt.budgets {
+				budgets[id] = &models.BonusBudget{DepartmentID: id, Amount: amount, ProrateFactor: 1}
+			}
+			lines := make([]models.BonusLine, 0, len(tt.lines))
+			for _, l := range tt.lines {
+				bonusLine := models.BonusLine{RequestedAmount: l.requested, Amount: l.requested, ProrateFactor: 1}
+				if l.department != 0 {
+					department := l.department
+					bonusLine.BudgetDepartmentID = &department
+					budgets[department].RequestedAmount = round2(budgets[department].RequestedAmount + l.requested)
+				}
+				lines = append(lines, bonusLine)
+			}
+
+			total := prorateBonusLines(lines, budgets)
+
+			if total != tt.total {
+				t.Errorf("total = %v, want %v", total, tt.total)
+			}
+			for i, l := range lines {
+				if l.Amount != tt.amounts[i] {
+					t.Errorf("line %d amount = %v, want %v", i, l.Amount, tt.amounts[i])
+				}
+				if l.ProrateFactor != tt.factors[i] {
+					t.Errorf("line %d factor = %v, want %v", i, l.ProrateFactor, tt.factors[i])
+				}
+			}
+			for id, budget := range budgets {
+				if budget.AllocatedAmount != tt.allocated[id] {
+					t.Errorf("budget %d allocated = %v, want %v", id, budget.AllocatedAmount, tt.allocated[id])
+				}
+				if budget.AllocatedAmount > budget.Amount {
+					t.Errorf("budget %d allocated %v exceeds its amount %v", id, budget.AllocatedAmount, budget.Amount)
+				}
+				if budget.ProrateFactor != tt.budgetRate[id] {
+					t.Errorf("budget %d factor = %v, want %v", id, budget.ProrateFactor, tt.budgetRate[id])
+				}
+			}
+		})
+	}
+}
+
+func TestNestedBudget(t *testing.T) {
+	id := func(value uint) *uint { return &value }
+	// 1 ─ 2 ─ 3, and 4 on its own
+	parents := map[uint]*uint{1: nil, 2: id(1), 3: id(2), 4: nil}
+	budget := func(ids ...uint) map[uint]*models.BonusBudget {
+		budgets := make(map[uint]*models.BonusBudget)
+		for _, departmentID := range ids {
+			budgets[departmentID] = &models.BonusBudget{DepartmentID: departmentID}
+		}
+		return budgets
+	}
+
+	tests := []struct {
+		name     string
+		budgets  map[uint]*models.BonusBudget
+		child    uint
+		ancestor uint
+		nested   bool
+	}{
+		{"no budgets", budget(), 0, 0, false},
+		{"siblings and unrelated departments", budget(3, 4), 0, 0, false},
+		{"direct parent", budget(2, 3), 3, 2, true},
+		{"grandparent", budget(1, 3), 3, 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			child, ancestor, nested := nestedBudget(tt.budgets, parents)
+			if child != tt.child || ancestor != tt.ancestor || nested != tt.nested {
+				t.Errorf("nestedBudget = %d, %d, %v, want %d, %d, %v", child, ancestor, nested, tt.child, tt.ancestor, tt.nested)
+			}
+		})
+	}
+}
@@ -0,0 +1,293 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+
+	"cepm-backend/database"
+	"cepm-backend/models"
+	"cepm-backend/repositories"
+
+	"gorm.io/gorm"
+)
+
+// SettingCalibrationQuotas is the SystemSetting key holding the default grade band quotas as a JSON array of GradeQuota.
+const SettingCalibrationQuotas = "calibration_quotas"
+
+// calibratableStatuses are the statuses of scored reviews that can be calibrated.
+var calibratableStatuses = []string{"已完成", "待人事确认"}
+
+// GradeQuota limits the share of reviews in a grade band, in percent.
+type GradeQuota struct {
+	Band       string   `json:"band"`
+	MinPercent *float64 `json:"minPercent,omitempty"`
+	MaxPercent *float64 `json:"maxPercent,omitempty"`
+}
+
+// CalibrationSessionInput defines the structure for opening a calibration session.
+type CalibrationSessionInput struct {
+	Period       string       `json:"period"`
+	DepartmentID uint         `json:"departmentId"`
+	Quotas       []GradeQuota `json:"quotas"` // Optional; defaults to the calibration_quotas setting
+}
+
+// CalibrationAdjustmentInput defines the structure for adjusting a review's final score.
+type CalibrationAdjustmentInput struct {
+	ReviewID      uint    `json:"reviewId"`
+	TotalScore    float64 `json:"totalScore"`
+	Justification string  `json:"justification"`
+}
+
+// GradeBandStat is the number and share of reviews in a grade band, compared with its quota.
+type GradeBandStat struct {
+	Band        string   `json:"band"`
+	Count       int      `json:"count"`
+	Percent     float64  `json:"percent"`
+	MinPercent  *float64 `json:"minPercent,omitempty"`
+	MaxPercent  *float64 `json:"maxPercent,omitempty"`
+	WithinQuota bool     `json:"withinQuota"`
+}
+
+// CalibrationReviewRow is a review under calibration with its grade band.
+type CalibrationReviewRow struct {
+	ReviewSummary
+	GradePoint *float64 `json:"gradePoint"`
+	GradeBand  string   `json:"gradeBand"`
+}
+
+// CalibrationReport is a session with the current grade distribution of its reviews.
+type CalibrationReport struct {
+	Session      *models.CalibrationSession `json:"session"`
+	Quotas       []GradeQuota               `json:"quotas"`
+	Distribution []GradeBandStat            `json:"distribution"`
+	Reviews      []CalibrationReviewRow     `json:"reviews"`
+}
+
+// CalibrationService defines the interface for HR calibration sessions.
+type CalibrationService interface {
+	OpenSession(hrID uint, input *CalibrationSessionInput) (*models.CalibrationSession, error)
+	ListSessions(period string) ([]models.CalibrationSession, error)
+	GetReport(id uint) (*CalibrationReport, error)
+	AdjustScore(sessionID uint, hrID uint, input *CalibrationAdjustmentInput) error
+	CloseSession(id uint) error
+}
+
+type calibrationService struct {
+	repo                 repositories.CalibrationRepository
+	reviewRepo           repositories.PerformanceReviewRepository
+	improvementPlans     ImprovementPlanService
+	systemSettingService *SystemSettingService
+	db                   *gorm.DB
+}
+
+// NewCalibrationService creates a new instance of CalibrationService.
+func NewCalibrationService(repo repositories.CalibrationRepository, reviewRepo repositories.PerformanceReviewRepository, improvementPlans ImprovementPlanService, systemSettingService *SystemSettingService) CalibrationService {
+	return &calibrationService{repo: repo, reviewRepo: reviewRepo, improvementPlans: improvementPlans, systemSettingService: systemSettingService, db: database.DB}
+}
+
+// OpenSession opens a calibration session for a period and department subtree,
+// locking the scored reviews it covers.
+func (s *calibrationService) OpenSession(hrID uint, input *CalibrationSessionInput) (*models.CalibrationSession, error) {
+	if input.Period == "" || input.DepartmentID == 0 {
+		return nil, errors.New("校准周期和部门均不能为空")
+	}
+
+	quotas := input.Quotas
+	if len(quotas) == 0 {
+		quotas = s.defaultQuotas()
+	}
+	quotasJSON, err := json.Marshal(quotas)
+	if err != nil {
+		return nil, err
+	}
+
+	departmentIDs, err := departmentSubtreeIDs(s.db, input.DepartmentID)
+	if err != nil {
+		return nil, err
+	}
+	reviews, err := s.repo.FindReviewsForCalibration(input.Period, departmentIDs, calibratableStatuses)
+	if err != nil {
+		return nil, err
+	}
+	if len(reviews) == 0 {
+		return nil, errors.New("该部门在此周期内没有可校准的绩效评估")
+	}
+	reviewIDs := make([]uint, 0, len(reviews))
+	for _, review := range reviews {
+		reviewIDs = append(reviewIDs, review.ID)
+	}
+
+	session := &models.CalibrationSession{
+		Period:       input.Period,
+		DepartmentID: input.DepartmentID,
+		Status:       "进行中",
+		Quotas:       string(quotasJSON),
+		CreatedByID:  hrID,
+	}
+	if err := s.repo.CreateAndLockReviews(session, reviewIDs); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// ListSessions retrieves calibration sessions, optionally filtered by period.
+func (s *calibrationService) ListSessions(period string) ([]models.CalibrationSession, error) {
+	return s.repo.ListByPeriod(period)
+}
+
+// GetReport returns a session with its reviews and their grade distribution against the session's quotas.
+func (s *calibrationService) GetReport(id uint) (*CalibrationReport, error) {
+	session, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, errors.New("校准会话不存在")
+	}
+
+	var quotas []GradeQuota
+	if session.Quotas != "" {
+		if err := json.Unmarshal([]byte(session.Quotas), &quotas); err != nil {
+			return nil, err
+		}
+	}
+
+	reviews, err := s.repo.ListLockedReviews(id)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]CalibrationReviewRow, 0, len(reviews))
+	var scores []float64
+	for i := range reviews {
+		row := CalibrationReviewRow{ReviewSummary: newReviewSummary(&reviews[i]), GradePoint: reviews[i].GradePoint}
+		if reviews[i].TotalScore != nil {
+			row.GradeBand = gradeBand(*reviews[i].TotalScore)
+			scores = append(scores, *reviews[i].TotalScore)
+		}
+		rows = append(rows, row)
+	}
+
+	return &CalibrationReport{
+		Session:      session,
+		Quotas:       quotas,
+		Distribution: gradeDistribution(scores, quotas),
+		Reviews:      rows,
+	}, nil
+}
+
+// AdjustScore sets a calibrated final score on a review locked by the session and audits the change.
+func (s *calibrationService) AdjustScore(sessionID uint, hrID uint, input *CalibrationAdjustmentInput) error {
+	session, err := s.repo.GetByID(sessionID)
+	if err != nil {
+		return errors.New("校准会话不存在")
+	}
+	if session.Status != "进行中" {
+		return errors.New("校准会话已结束")
+	}
+	if input.Justification == "" {
+		return errors.New("调整分数必须填写理由")
+	}
+	if input.TotalScore < 0 || input.TotalScore > 120 {
+		return errors.New("总分必须在0到120之间")
+	}
+
+	review, err := s.reviewRepo.GetByID(input.ReviewID)
+	if err != nil {
+		return errors.New("绩效评估不存在")
+	}
+	if review.CalibrationSessionID == nil || *review.CalibrationSessionID != sessionID {
+		return errors.New("该绩效评估不在此校准会话中")
+	}
+
+	// Keep the adjustment as a delta on the computed score, so it survives later recomputes, and derive
+	// the result through recomputeResult like every other path (normalization included)
+	itemsTotal := calculateTotalScore(review.Items)
+	uncalibrated := *review
+	uncalibrated.CalibrationDelta = nil
+	computed, err := recomputeResult(s.db, s.systemSettingService, &uncalibrated, itemsTotal)
+	if err != nil {
+		return err
+	}
+	delta := math.Round((input.TotalScore-computed.TotalScore)*100) / 100
+	review.CalibrationDelta = &delta
+	result, err := recomputeResult(s.db, s.systemSettingService, review, itemsTotal)
+	if err != nil {
+		return err
+	}
+	updates := result.updates()
+	updates["calibration_delta"] = delta
+
+	if err := s.repo.AddAdjustment(&models.CalibrationAdjustment{
+		SessionID:          sessionID,
+		ReviewID:           review.ID,
+		AdjusterID:         hrID,
+		OriginalScore:      review.TotalScore,
+		AdjustedScore:      result.TotalScore,
+		OriginalGradePoint: review.GradePoint,
+		AdjustedGradePoint: result.GradePoint,
+		ScoreDelta:         delta,
+		Justification:      input.Justification,
+	}, updates); err != nil {
+		return err
+	}
+	reevaluateResults(s.improvementPlans, review.ID)
+	return nil
+}
+
+// CloseSession ends a session and unlocks its reviews.
+func (s *calibrationService) CloseSession(id uint) error {
+	session, err := s.repo.GetByID(id)
+	if err != nil {
+		return errors.New("校准会话不存在")
+	}
+	if session.Status != "进行中" {
+		return errors.New("校准会话已结束")
+	}
+	return s.repo.CloseAndUnlockReviews(id)
+}
+
+// defaultQuotas reads the quotas from settings, falling back to 优秀 ≤ 20% and 不合格 ≥ 5%.
+func (s *calibrationService) defaultQuotas() []GradeQuota {
+	if setting, err := s.systemSettingService.GetSetting(SettingCalibrationQuotas); err == nil && setting.Value != "" {
+		var quotas []GradeQuota
+		if err := json.Unmarshal([]byte(setting.Value), &quotas); err == nil {
+			return quotas
+		}
+	}
+	maxExcellent, minFailing := 20.0, 5.0
+	return []GradeQuota{
+		{Band: "优秀", MaxPercent: &maxExcellent},
+		{Band: "不合格", MinPercent: &minFailing},
+	}
+}
+
+// gradeDistribution counts scores per grade band and checks each band against its quota.
+func gradeDistribution(scores []float64, quotas []GradeQuota) []GradeBandStat {
+	counts := make(map[string]int)
+	for _, score := range scores {
+		counts[gradeBand(score)]++
+	}
+	quotaMap := make(map[string]GradeQuota)
+	for _, quota := range quotas {
+		quotaMap[quota.Band] = quota
+	}
+
+	stats := make([]GradeBandStat, 0, len(gradeBands))
+	for _, band := range gradeBands {
+		stat := GradeBandStat{Band: band, Count: counts[band], WithinQuota: true}
+		if len(scores) > 0 {
+			stat.Percent = float64(stat.Count) * 100 / float64(len(scores))
+		}
+		if quota, ok := quotaMap[band]; ok {
+			stat.MinPercent = quota.MinPercent
+			stat.MaxPercent = quota.MaxPercent
+			if quota.MinPercent != nil && stat.Percent < *quota.MinPercent {
+				stat.WithinQuota = false
+			}
+			if quota.MaxPercent != nil && stat.Percent > *quota.MaxPercent {
+				stat.WithinQuota = false
+			}
+		}
+		stats = append(stats, stat)
+	}
+	return stats
+}
@@ -0,0 +1,129 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"cepm-backend/models"
+	"cepm-backend/repositories"
+)
+
+// CheckInInput defines the structure for posting progress on a review item.
+type CheckInInput struct {
+	CheckInDate     string `json:"checkInDate"` // Format: YYYY-MM-DD; defaults to today
+	PercentComplete int    `json:"percentComplete"`
+	RAGStatus       string `json:"ragStatus"` // red, amber, green
+	Note            string `json:"note"`
+}
+
+// CheckInService defines the interface for mid-period progress check-ins.
+type CheckInService interface {
+	CreateCheckIn(reviewID uint, itemID uint, userID uint, input *CheckInInput) (*models.ItemCheckIn, error)
+	ListCheckIns(reviewID uint, itemID *uint, viewer *models.User) ([]models.ItemCheckIn, error)
+	ReplyCheckIn(checkInID uint, actor *models.User, reply string) error
+}
+
+type checkInService struct {
+	repo       repositories.CheckInRepository
+	reviewRepo repositories.PerformanceReviewRepository
+}
+
+// NewCheckInService creates a new instance of CheckInService.
+func NewCheckInService(repo repositories.CheckInRepository, reviewRepo repositories.PerformanceReviewRepository) CheckInService {
+	return &checkInService{repo: repo, reviewRepo: reviewRepo}
+}
+
+// CreateCheckIn records the employee's progress on one item of their approved plan.
+func (s *checkInService) CreateCheckIn(reviewID uint, itemID uint, userID uint, input *CheckInInput) (*models.ItemCheckIn, error) {
+	review, err := s.reviewRepo.GetByID(reviewID)
+	if err != nil {
+		return nil, errors.New("绩效评估不存在")
+	}
+	if review.UserID != userID {
+		return nil, errors.New("只能更新自己的绩效进度")
+	}
+	if review.Status != "待打分" {
+		return nil, errors.New("只有待打分状态的绩效评估才能更新进度")
+	}
+	found := false
+	for _, item := range review.Items {
+		if item.ID == itemID {
+			found = true
+		}
+	}
+	if !found {
+		return nil, errors.New("无效的绩效项ID")
+	}
+
+	if input.PercentComplete < 0 || input.PercentComplete > 100 {
+		return nil, errors.New("完成百分比必须在0到100之间")
+	}
+	if input.RAGStatus != "red" && input.RAGStatus != "amber" && input.RAGStatus != "green" {
+		return nil, errors.New("进度状态必须为red、amber或green")
+	}
+	today := time.Now()
+	checkInDate := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.Local)
+	if input.CheckInDate != "" {
+		if checkInDate, err = time.ParseInLocation("2006-01-02", input.CheckInDate, time.Local); err != nil {
+			return nil, errors.New("日期格式错误，应为YYYY-MM-DD")
+		}
+		if checkInDate.After(today) {
+			return nil, errors.New("不能填写未来日期的进度")
+		}
+	}
+
+	checkIn := &models.ItemCheckIn{
+		ReviewID:        reviewID,
+		ItemID:          itemID,
+		AuthorID:        userID,
+		CheckInDate:     checkInDate,
+		PercentComplete: input.PercentComplete,
+		RAGStatus:       input.RAGStatus,
+		Note:            input.Note,
+	}
+	if err := s.repo.Create(checkIn); err != nil {
+		return nil, err
+	}
+	return checkIn, nil
+}
+
+// ListCheckIns retrieves the check-ins of a review. They are visible to the employee, their direct manager and HR.
+func (s *checkInService) ListCheckIns(reviewID uint, itemID *uint, viewer *models.User) ([]models.ItemCheckIn, error) {
+	review, err := s.reviewRepo.GetByID(reviewID)
+	if err != nil {
+		return nil, errors.New("绩效评估不存在")
+	}
+	if !canFollowReview(review, viewer) {
+		return nil, errors.New("您无权查看此绩效进度")
+	}
+	return s.repo.ListByReviewID(reviewID, itemID)
+}
+
+// ReplyCheckIn stores the manager's reply to a check-in, replacing any earlier reply.
+func (s *checkInService) ReplyCheckIn(checkInID uint, actor *models.User, reply string) error {
+	checkIn, err := s.repo.GetByID(checkInID)
+	if err != nil {
+		return errors.New("进度记录不存在")
+	}
+	review, err := s.reviewRepo.GetByID(checkIn.ReviewID)
+	if err != nil {
+		return errors.New("绩效评估不存在")
+	}
+	if review.UserID == actor.ID || !canFollowReview(review, actor) {
+		return errors.New("只有直属上级或人事可以回复")
+	}
+	if reply == "" {
+		return errors.New("回复内容不能为空")
+	}
+
+	now := time.Now()
+	checkIn.ManagerReply = reply
+	checkIn.RepliedByID = &actor.ID
+	checkIn.RepliedAt = &now
+	return s.repo.Reply(checkIn)
+}
+
+// canFollowReview reports whether a user is the review's employee, their direct manager or HR.
+func canFollowReview(review *models.PerformanceReview, user *models.User) bool {
+	return review.UserID == user.ID || isHRUser(user) || (review.User.ManagerID != nil && *review.User.ManagerID == user.ID)
+}
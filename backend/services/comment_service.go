@@ -0,0 +1,291 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"cepm-backend/database"
+	"cepm-backend/models"
+	"cepm-backend/repositories"
+
+	"gorm.io/gorm"
+)
+
+// Comment visibilities.
+const (
+	CommentVisibilityShared  = "shared"  // Visible to the employee, their manager and HR
+	CommentVisibilityPrivate = "private" // Visible to the manager and HR only
+)
+
+// CommentInput defines the structure for posting a comment.
+type CommentInput struct {
+	Content    string `json:"content"`
+	ItemID     *uint  `json:"itemId"`
+	ParentID   *uint  `json:"parentId"`
+	Visibility string `json:"visibility"` // shared, private; replies inherit their thread's visibility
+	MentionIDs []uint `json:"mentionIds"`
+}
+
+// CommentUpdateInput defines the structure for editing a comment.
+type CommentUpdateInput struct {
+	Content    string `json:"content"`
+	MentionIDs []uint `json:"mentionIds"`
+}
+
+// CommentThread is a comment with its replies.
+type CommentThread struct {
+	models.ReviewComment
+	Replies []*CommentThread `json:"replies"`
+}
+
+// CommentService defines the interface for threaded review comments.
+type CommentService interface {
+	CreateComment(reviewID uint, author *models.User, input *CommentInput) (*models.ReviewComment, error)
+	ListThreads(reviewID uint, itemID *uint, viewer *models.User) ([]*CommentThread, error)
+	UpdateComment(id uint, actor *models.User, input *CommentUpdateInput) (*models.ReviewComment, error)
+	DeleteComment(id uint, actor *models.User) error
+	GetEditHistory(id uint, viewer *models.User) ([]models.ReviewCommentEdit, error)
+}
+
+type commentService struct {
+	repo                repositories.CommentRepository
+	reviewRepo          repositories.PerformanceReviewRepository
+	notificationService NotificationService
+	db                  *gorm.DB
+}
+
+// NewCommentService creates a new instance of CommentService.
+func NewCommentService(repo repositories.CommentRepository, reviewRepo repositories.PerformanceReviewRepository, notificationService NotificationService) CommentService {
+	return &commentService{repo: repo, reviewRepo: reviewRepo, notificationService: notificationService, db: database.DB}
+}
+
+// CreateComment posts a comment or reply on a review or one of its items and notifies mentioned users.
+func (s *commentService) CreateComment(reviewID uint, author *models.User, input *CommentInput) (*models.ReviewComment, error) {
+	review, err := s.reviewRepo.GetByID(reviewID)
+	if err != nil {
+		return nil, errors.New("绩效评估不存在")
+	}
+	if !canFollowReview(review, author) {
+		return nil, errors.New("您无权评论此绩效评估")
+	}
+	if input.Content == "" {
+		return nil, errors.New("评论内容不能为空")
+	}
+
+	comment := &models.ReviewComment{ReviewID: reviewID, AuthorID: author.ID, Content: input.Content, Visibility: input.Visibility}
+	if comment.Visibility == "" {
+		comment.Visibility = CommentVisibilityShared
+	}
+	if comment.Visibility != CommentVisibilityShared && comment.Visibility != CommentVisibilityPrivate {
+		return nil, errors.New("无效的评论可见范围")
+	}
+
+	// 1. Replies stay on their parent's item and inherit its visibility
+	if input.ParentID != nil {
+		parent, err := s.repo.GetByID(*input.ParentID)
+		if err != nil || parent.ReviewID != reviewID {
+			return nil, errors.New("回复的评论不存在")
+		}
+		if !canSeeComment(review, parent, author) {
+			return nil, errors.New("您无权回复此评论")
+		}
+		comment.ParentID = &parent.ID
+		comment.ItemID = parent.ItemID
+		comment.Visibility = parent.Visibility
+	} else if input.ItemID != nil {
+		found := false
+		for _, item := range review.Items {
+			if item.ID == *input.ItemID {
+				found = true
+			}
+		}
+		if !found {
+			return nil, errors.New("无效的绩效项ID")
+		}
+		comment.ItemID = input.ItemID
+	}
+	if comment.Visibility == CommentVisibilityPrivate && review.UserID == author.ID {
+		return nil, errors.New("员工不能发表仅上级和人事可见的评论")
+	}
+
+	// 2. Mentions
+	if comment.Mentions, err = s.loadMentions(review, comment, input.MentionIDs); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Create(comment); err != nil {
+		return nil, err
+	}
+	s.notifyMentions(review, author, comment, comment.Mentions)
+	return s.repo.GetByID(comment.ID)
+}
+
+// ListThreads returns the comments of a review visible to the viewer, arranged into threads.
+func (s *commentService) ListThreads(reviewID uint, itemID *uint, viewer *models.User) ([]*CommentThread, error) {
+	review, err := s.reviewRepo.GetByID(reviewID)
+	if err != nil {
+		return nil, errors.New("绩效评估不存在")
+	}
+	if !canFollowReview(review, viewer) {
+		return nil, errors.New("您无权查看此绩效评估的评论")
+	}
+	visibilities := []string{CommentVisibilityShared}
+	if review.UserID != viewer.ID {
+		visibilities = append(visibilities, CommentVisibilityPrivate)
+	}
+	comments, err := s.repo.ListByReviewID(reviewID, itemID, visibilities)
+	if err != nil {
+		return nil, err
+	}
+
+	threads := make(map[uint]*CommentThread, len(comments))
+	var roots []*CommentThread
+	for _, comment := range comments {
+		if comment.DeletedAt != nil {
+			comment.Content = "该评论已删除"
+			comment.Mentions = nil
+		}
+		threads[comment.ID] = &CommentThread{ReviewComment: comment, Replies: []*CommentThread{}}
+	}
+	for _, comment := range comments {
+		thread := threads[comment.ID]
+		if comment.ParentID != nil {
+			if parent, ok := threads[*comment.ParentID]; ok {
+				parent.Replies = append(parent.Replies, thread)
+				continue
+			}
+		}
+		roots = append(roots, thread)
+	}
+	return roots, nil
+}
+
+// UpdateComment edits a comment's content and mentions. Only the author can edit, and the previous
+// content is kept in the edit history. Newly mentioned users are notified.
+func (s *commentService) UpdateComment(id uint, actor *models.User, input *CommentUpdateInput) (*models.ReviewComment, error) {
+	comment, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, errors.New("评论不存在")
+	}
+	if comment.AuthorID != actor.ID {
+		return nil, errors.New("只能修改自己的评论")
+	}
+	if comment.DeletedAt != nil {
+		return nil, errors.New("评论已删除")
+	}
+	if input.Content == "" {
+		return nil, errors.New("评论内容不能为空")
+	}
+	review, err := s.reviewRepo.GetByID(comment.ReviewID)
+	if err != nil {
+		return nil, errors.New("绩效评估不存在")
+	}
+
+	alreadyMentioned := make(map[uint]bool)
+	for _, user := range comment.Mentions {
+		alreadyMentioned[user.ID] = true
+	}
+	mentions, err := s.loadMentions(review, comment, input.MentionIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	edit := &models.ReviewCommentEdit{CommentID: comment.ID, PreviousContent: comment.Content, EditedByID: actor.ID}
+	now := time.Now()
+	comment.Content = input.Content
+	comment.EditedAt = &now
+	comment.Mentions = mentions
+	if err := s.repo.UpdateContent(comment, edit); err != nil {
+		return nil, err
+	}
+
+	var newMentions []models.User
+	for _, user := range mentions {
+		if !alreadyMentioned[user.ID] {
+			newMentions = append(newMentions, user)
+		}
+	}
+	s.notifyMentions(review, actor, comment, newMentions)
+	return s.repo.GetByID(id)
+}
+
+// DeleteComment soft-deletes a comment. The author and HR can delete.
+func (s *commentService) DeleteComment(id uint, actor *models.User) error {
+	comment, err := s.repo.GetByID(id)
+	if err != nil {
+		return errors.New("评论不存在")
+	}
+	if comment.AuthorID != actor.ID && !isHRUser(actor) {
+		return errors.New("只能删除自己的评论")
+	}
+	if comment.DeletedAt != nil {
+		return errors.New("评论已删除")
+	}
+	return s.repo.SoftDelete(id, actor.ID)
+}
+
+// GetEditHistory retrieves the previous versions of a comment visible to the viewer.
+func (s *commentService) GetEditHistory(id uint, viewer *models.User) ([]models.ReviewCommentEdit, error) {
+	comment, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, errors.New("评论不存在")
+	}
+	review, err := s.reviewRepo.GetByID(comment.ReviewID)
+	if err != nil {
+		return nil, errors.New("绩效评估不存在")
+	}
+	if !canSeeComment(review, comment, viewer) || comment.DeletedAt != nil {
+		return nil, errors.New("您无权查看此评论")
+	}
+	return comment.Edits, nil
+}
+
+// loadMentions loads the mentioned users, who must be able to see the comment.
+func (s *commentService) loadMentions(review *models.PerformanceReview, comment *models.ReviewComment, mentionIDs []uint) ([]models.User, error) {
+	// The same user may be mentioned more than once; they are stored and notified once
+	var uniqueIDs []uint
+	for _, id := range mentionIDs {
+		if !containsUint(uniqueIDs, id) {
+			uniqueIDs = append(uniqueIDs, id)
+		}
+	}
+	if len(uniqueIDs) == 0 {
+		return nil, nil
+	}
+	var users []models.User
+	if err := s.db.Preload("Role").Where("id IN ?", uniqueIDs).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	if len(users) != len(uniqueIDs) {
+		return nil, errors.New("被提及的用户不存在")
+	}
+	for i := range users {
+		if !canSeeComment(review, comment, &users[i]) {
+			return nil, fmt.Errorf("%s无权查看此评论，不能提及", users[i].Name)
+		}
+	}
+	return users, nil
+}
+
+// notifyMentions notifies mentioned users. Failures are logged and do not fail the comment.
+func (s *commentService) notifyMentions(review *models.PerformanceReview, author *models.User, comment *models.ReviewComment, mentions []models.User) {
+	for _, user := range mentions {
+		if user.ID == author.ID {
+			continue
+		}
+		content := fmt.Sprintf("%s 在 %s 的 %s 绩效评估中提到了您：%s", author.Name, review.User.Name, review.Period, comment.Content)
+		if err := s.notificationService.Notify(user.ID, content); err != nil {
+			log.Printf("Failed to notify user %d of mention in comment %d: %v", user.ID, comment.ID, err)
+		}
+	}
+}
+
+// canSeeComment reports whether a user can see a comment: private comments are hidden from the employee.
+func canSeeComment(review *models.PerformanceReview, comment *models.ReviewComment, user *models.User) bool {
+	if !canFollowReview(review, user) {
+		return false
+	}
+	return comment.Visibility != CommentVisibilityPrivate || review.UserID != user.ID
+}
@@ -0,0 +1,306 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+
+	"cepm-backend/database"
+	"cepm-backend/models"
+	"cepm-backend/repositories"
+
+	"gorm.io/gorm"
+)
+
+// Settings for 述职答辩 scoring.
+const (
+	SettingDefenseRubric      = "defense_rubric"       // JSON array of DefenseCriterion
+	SettingDefenseTrimCount   = "defense_trim_count"   // Number of highest and lowest panel scores dropped, default 1
+	SettingDefenseScoreWeight = "defense_score_weight" // Percentage weight of the defense score in the review total, default 0
+)
+
+// DefenseCriterion is one line of the 述职答辩 rubric. A panelist's score is the sum over all criteria.
+type DefenseCriterion struct {
+	Name     string  `json:"name"`
+	MaxScore float64 `json:"maxScore"`
+}
+
+// defaultDefenseRubric is used when the defense_rubric setting is missing or invalid. It sums to 100.
+var defaultDefenseRubric = []DefenseCriterion{
+	{Name: "工作成果", MaxScore: 40},
+	{Name: "专业能力", MaxScore: 30},
+	{Name: "述职表达", MaxScore: 20},
+	{Name: "改进计划", MaxScore: 10},
+}
+
+// DefenseSessionInput defines the structure for scheduling a defense session.
+type DefenseSessionInput struct {
+	Title        string `json:"title"`
+	Period       string `json:"period"`
+	ScheduledAt  string `json:"scheduledAt"` // Format: YYYY-MM-DD HH:mm
+	Location     string `json:"location"`
+	PanelistIDs  []uint `json:"panelistIds"`
+	CandidateIDs []uint `json:"candidateIds"`
+}
+
+// DefenseScoreInput defines the structure for a panelist's rubric scores for one candidate.
+type DefenseScoreInput struct {
+	Scores  map[string]float64 `json:"scores"` // Criterion name -> score
+	Comment string             `json:"comment"`
+}
+
+// DefenseService defines the interface for 述职答辩 sessions.
+type DefenseService interface {
+	ScheduleSession(hrID uint, input *DefenseSessionInput) (*models.DefenseSession, error)
+	ListSessions(period string) ([]models.DefenseSession, error)
+	ListPanelSessions(panelistID uint) ([]models.DefenseSession, error)
+	GetSession(id uint, viewer *models.User) (*models.DefenseSession, error)
+	SubmitScore(sessionID uint, candidateID uint, panelistID uint, input *DefenseScoreInput) (*models.DefenseScore, error)
+	CloseSession(id uint) (*models.DefenseSession, error)
+}
+
+type defenseService struct {
+	repo                 repositories.DefenseRepository
+	reviewRepo           repositories.PerformanceReviewRepository
+	improvementPlans     ImprovementPlanService
+	systemSettingService *SystemSettingService
+	db                   *gorm.DB
+}
+
+// NewDefenseService creates a new instance of DefenseService.
+func NewDefenseService(repo repositories.DefenseRepository, reviewRepo repositories.PerformanceReviewRepository, improvementPlans ImprovementPlanService, systemSettingService *SystemSettingService) DefenseService {
+	return &defenseService{repo: repo, reviewRepo: reviewRepo, improvementPlans: improvementPlans, systemSettingService: systemSettingService, db: database.DB}
+}
+
+// ScheduleSession schedules a defense session for the selected employees with a panel of evaluators.
+// The current rubric is copied into the session so later setting changes do not affect it.
+func (s *defenseService) ScheduleSession(hrID uint, input *DefenseSessionInput) (*models.DefenseSession, error) {
+	// 1. Basic validation
+	if input.Title == "" || input.Period == "" {
+		return nil, errors.New("答辩名称和考核周期不能为空")
+	}
+	scheduledAt, err := time.ParseInLocation("2006-01-02 15:04", input.ScheduledAt, time.Local)
+	if err != nil {
+		return nil, errors.New("答辩时间格式错误，应为YYYY-MM-DD HH:mm")
+	}
+	if len(input.PanelistIDs) == 0 || len(input.CandidateIDs) == 0 {
+		return nil, errors.New("请至少选择一名评委和一名答辩人")
+	}
+
+	// 2. Panel and candidates
+	var panelists []models.User
+	if err := s.db.Where("id IN ?", input.PanelistIDs).Find(&panelists).Error; err != nil {
+		return nil, err
+	}
+	if len(panelists) != len(input.PanelistIDs) {
+		return nil, errors.New("部分评委不存在")
+	}
+	var candidateCount int64
+	if err := s.db.Model(&models.User{}).Where("id IN ?", input.CandidateIDs).Count(&candidateCount).Error; err != nil {
+		return nil, err
+	}
+	if int(candidateCount) != len(input.CandidateIDs) {
+		return nil, errors.New("部分答辩人不存在")
+	}
+	candidates := make([]models.DefenseCandidate, 0, len(input.CandidateIDs))
+	for _, userID := range input.CandidateIDs {
+		candidates = append(candidates, models.DefenseCandidate{UserID: userID})
+	}
+
+	rubric, _ := json.Marshal(s.rubric())
+	session := &models.DefenseSession{
+		Title:       input.Title,
+		Period:      input.Period,
+		ScheduledAt: scheduledAt,
+		Location:    input.Location,
+		Rubric:      string(rubric),
+		Status:      "待答辩",
+		Panelists:   panelists,
+		Candidates:  candidates,
+		CreatedByID: hrID,
+	}
+	if err := s.repo.Create(session); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(session.ID)
+}
+
+// ListSessions retrieves all sessions, optionally filtered by period.
+func (s *defenseService) ListSessions(period string) ([]models.DefenseSession, error) {
+	return s.repo.List(period)
+}
+
+// ListPanelSessions retrieves the sessions a user sits on the panel of.
+func (s *defenseService) ListPanelSessions(panelistID uint) ([]models.DefenseSession, error) {
+	return s.repo.ListByPanelistID(panelistID)
+}
+
+// GetSession retrieves a session. Only HR and the session's panelists can view it.
+func (s *defenseService) GetSession(id uint, viewer *models.User) (*models.DefenseSession, error) {
+	session, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, errors.New("答辩场次不存在")
+	}
+	if !isHRUser(viewer) && !isPanelist(session, viewer.ID) {
+		return nil, errors.New("您无权查看此答辩场次")
+	}
+	return session, nil
+}
+
+// SubmitScore records or replaces a panelist's rubric scores for a candidate.
+func (s *defenseService) SubmitScore(sessionID uint, candidateID uint, panelistID uint, input *DefenseScoreInput) (*models.DefenseScore, error) {
+	session, err := s.repo.GetByID(sessionID)
+	if err != nil {
+		return nil, errors.New("答辩场次不存在")
+	}
+	if session.Status != "待答辩" {
+		return nil, errors.New("答辩场次已结束，不能再评分")
+	}
+	if !isPanelist(session, panelistID) {
+		return nil, errors.New("只有答辩评委才能评分")
+	}
+	var candidate *models.DefenseCandidate
+	for i := range session.Candidates {
+		if session.Candidates[i].ID == candidateID {
+			candidate = &session.Candidates[i]
+		}
+	}
+	if candidate == nil {
+		return nil, errors.New("答辩人不存在")
+	}
+	if candidate.UserID == panelistID {
+		return nil, errors.New("评委不能给自己评分")
+	}
+
+	// Every rubric criterion must be scored within its range
+	var rubric []DefenseCriterion
+	if err := json.Unmarshal([]byte(session.Rubric), &rubric); err != nil {
+		return nil, err
+	}
+	if len(input.Scores) != len(rubric) {
+		return nil, errors.New("请为每一项评分标准打分")
+	}
+	var total float64
+	for _, criterion := range rubric {
+		score, ok := input.Scores[criterion.Name]
+		if !ok {
+			return nil, errors.New("缺少评分标准：" + criterion.Name)
+		}
+		if score < 0 || score > criterion.MaxScore {
+			return nil, errors.New("评分超出范围：" + criterion.Name)
+		}
+		total += score
+	}
+
+	criteriaScores, _ := json.Marshal(input.Scores)
+	score := &models.DefenseScore{
+		CandidateID:    candidateID,
+		PanelistID:     panelistID,
+		CriteriaScores: string(criteriaScores),
+		TotalScore:     round2(total),
+		Comment:        input.Comment,
+	}
+	if err := s.repo.SaveScore(score); err != nil {
+		return nil, err
+	}
+	return score, nil
+}
+
+// CloseSession closes a session, computes each candidate's trimmed mean and attaches it to the
+// candidate's review for the session's period. If the defense score counts toward the total and
+// the review is scored but not yet acknowledged, the total and grade point are recomputed.
+func (s *defenseService) CloseSession(id uint) (*models.DefenseSession, error) {
+	session, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, errors.New("答辩场次不存在")
+	}
+	if session.Status != "待答辩" {
+		return nil, errors.New("答辩场次已结束")
+	}
+
+	trim := settingInt(s.systemSettingService, SettingDefenseTrimCount, 1)
+	weighted := settingInt(s.systemSettingService, SettingDefenseScoreWeight, 0) > 0
+	finalScores := make(map[uint]*float64)
+	reviewUpdates := make(map[uint]map[string]interface{})
+	for _, candidate := range session.Candidates {
+		var finalScore *float64
+		if len(candidate.Scores) > 0 {
+			scores := make([]float64, 0, len(candidate.Scores))
+			for _, score := range candidate.Scores {
+				scores = append(scores, score.TotalScore)
+			}
+			value := round2(trimmedMean(scores, trim))
+			finalScore = &value
+		}
+		finalScores[candidate.ID] = finalScore
+		if finalScore == nil {
+			continue
+		}
+
+		review, err := s.reviewRepo.GetByUserIDAndPeriod(candidate.UserID, session.Period)
+		if err != nil {
+			continue // No review yet; the result stays on the candidate record
+		}
+		updates := map[string]interface{}{
+			"defense_score":        *finalScore,
+			"defense_candidate_id": candidate.ID,
+		}
+		// Acknowledged, confirmed and appealed results are left alone. The recompute reproduces the
+		// current score from the items, with any calibration and normalization re-applied, and blends in
+		// the defense score on top.
+		if weighted && review.TotalScore != nil && review.Status == "已完成" && review.CalibrationSessionID == nil {
+			review.DefenseScore = finalScore
+			result, err := recomputeResult(s.db, s.systemSettingService, review, calculateTotalScore(review.Items))
+			if err != nil {
+				return nil, err
+			}
+			for column, value := range result.updates() {
+				updates[column] = value
+			}
+		}
+		reviewUpdates[review.ID] = updates
+	}
+
+	if err := s.repo.Close(id, finalScores, reviewUpdates); err != nil {
+		return nil, err
+	}
+	for reviewID, updates := range reviewUpdates {
+		if _, ok := updates["total_score"]; ok {
+			reevaluateResults(s.improvementPlans, reviewID)
+		}
+	}
+	return s.repo.GetByID(id)
+}
+
+// rubric reads the rubric from settings, falling back to the default.
+func (s *defenseService) rubric() []DefenseCriterion {
+	if setting, err := s.systemSettingService.GetSetting(SettingDefenseRubric); err == nil && setting.Value != "" {
+		var rubric []DefenseCriterion
+		if err := json.Unmarshal([]byte(setting.Value), &rubric); err == nil && len(rubric) > 0 {
+			return rubric
+		}
+	}
+	return defaultDefenseRubric
+}
+
+// isPanelist reports whether a user sits on a session's panel.
+func isPanelist(session *models.DefenseSession, userID uint) bool {
+	for _, panelist := range session.Panelists {
+		if panelist.ID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// trimmedMean drops the trim highest and lowest scores before averaging.
+// Nothing is dropped unless at least one score would remain.
+func trimmedMean(scores []float64, trim int) float64 {
+	sorted := append([]float64(nil), scores...)
+	sort.Float64s(sorted)
+	if len(sorted) > 2*trim {
+		sorted = sorted[trim : len(sorted)-trim]
+	}
+	return scoreStats(sorted).Mean
+}
@@ -0,0 +1,170 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"cepm-backend/database"
+	"cepm-backend/models"
+	"cepm-backend/repositories"
+
+	"gorm.io/gorm"
+)
+
+// DelegationInput defines the structure for creating an approval delegation from the API.
+type DelegationInput struct {
+	DelegateID   uint   `json:"delegateId"`
+	StartDate    string `json:"startDate"` // Format: YYYY-MM-DD
+	EndDate      string `json:"endDate"`   // Format: YYYY-MM-DD, inclusive
+	ScopeType    string `json:"scopeType"` // all, department, users
+	DepartmentID *uint  `json:"departmentId"`
+	ReportIDs    []uint `json:"reportIds"`
+	Reason       string `json:"reason"`
+}
+
+// DelegationList groups the delegations a user has granted and received.
+type DelegationList struct {
+	Granted  []models.ApprovalDelegation `json:"granted"`
+	Received []models.ApprovalDelegation `json:"received"`
+}
+
+// DelegationService defines the interface for approval delegation services.
+type DelegationService interface {
+	CreateDelegation(delegatorID uint, input *DelegationInput) (*models.ApprovalDelegation, error)
+	ListDelegations(userID uint) (*DelegationList, error)
+	RevokeDelegation(id uint, userID uint) error
+}
+
+type delegationService struct {
+	repo repositories.DelegationRepository
+	db   *gorm.DB
+}
+
+// NewDelegationService creates a new instance of DelegationService.
+func NewDelegationService(repo repositories.DelegationRepository) DelegationService {
+	return &delegationService{repo: repo, db: database.DB}
+}
+
+// CreateDelegation validates and stores a new delegation from the given delegator.
+func (s *delegationService) CreateDelegation(delegatorID uint, input *DelegationInput) (*models.ApprovalDelegation, error) {
+	// 1. Basic validation
+	if input.DelegateID == 0 || input.DelegateID == delegatorID {
+		return nil, errors.New("请选择除自己以外的被委托人")
+	}
+	var delegate models.User
+	if err := s.db.First(&delegate, input.DelegateID).Error; err != nil {
+		return nil, errors.New("被委托人不存在")
+	}
+
+	startDate, err := time.ParseInLocation("2006-01-02", input.StartDate, time.Local)
+	if err != nil {
+		return nil, errors.New("开始日期格式错误，应为YYYY-MM-DD")
+	}
+	endDate, err := time.ParseInLocation("2006-01-02", input.EndDate, time.Local)
+	if err != nil {
+		return nil, errors.New("结束日期格式错误，应为YYYY-MM-DD")
+	}
+	if endDate.Before(startDate) {
+		return nil, errors.New("结束日期不能早于开始日期")
+	}
+
+	delegation := &models.ApprovalDelegation{
+		DelegatorID: delegatorID,
+		DelegateID:  input.DelegateID,
+		StartDate:   startDate,
+		// The end date is inclusive, so the delegation lasts until the end of that day.
+		EndDate:   endDate.Add(24*time.Hour - time.Second),
+		ScopeType: input.ScopeType,
+		Reason:    input.Reason,
+	}
+
+	// 2. Scope validation
+	switch input.ScopeType {
+	case "", "all":
+		delegation.ScopeType = "all"
+	case "department":
+		if input.DepartmentID == nil {
+			return nil, errors.New("按部门委托时必须指定部门")
+		}
+		// The delegator may only hand over reviews within their own part of the org chart.
+		var delegator models.User
+		if err := s.db.First(&delegator, delegatorID).Error; err != nil {
+			return nil, errors.New("委托人不存在")
+		}
+		if delegator.DepartmentID == nil {
+			return nil, errors.New("您未归属任何部门，无法按部门委托")
+		}
+		subtree, err := departmentSubtreeIDs(s.db, *delegator.DepartmentID)
+		if err != nil {
+			return nil, err
+		}
+		if !containsUint(subtree, *input.DepartmentID) {
+			return nil, errors.New("只能委托本部门及下级部门")
+		}
+		delegation.DepartmentID = input.DepartmentID
+	case "users":
+		if len(input.ReportIDs) == 0 {
+			return nil, errors.New("按人员委托时必须指定至少一名下属")
+		}
+		var reports []models.User
+		if err := s.db.Where("id IN ? AND manager_id = ?", input.ReportIDs, delegatorID).Find(&reports).Error; err != nil {
+			return nil, err
+		}
+		if len(reports) != len(input.ReportIDs) {
+			return nil, errors.New("只能委托自己的直属下属")
+		}
+		delegation.Reports = reports
+	default:
+		return nil, errors.New("无效的委托范围")
+	}
+
+	if err := s.repo.Create(delegation); err != nil {
+		return nil, err
+	}
+	return delegation, nil
+}
+
+// ListDelegations retrieves the delegations a user has granted and received.
+func (s *delegationService) ListDelegations(userID uint) (*DelegationList, error) {
+	granted, err := s.repo.ListByDelegatorID(userID)
+	if err != nil {
+		return nil, err
+	}
+	received, err := s.repo.ListByDelegateID(userID)
+	if err != nil {
+		return nil, err
+	}
+	return &DelegationList{Granted: granted, Received: received}, nil
+}
+
+// ErrDelegationForbidden is returned when a user tries to revoke a delegation they did not grant.
+var ErrDelegationForbidden = errors.New("您无权撤销此委托")
+
+// RevokeDelegation revokes a delegation. Only the delegator can revoke it.
+func (s *delegationService) RevokeDelegation(id uint, userID uint) error {
+	delegation, err := s.repo.GetByID(id)
+	if err != nil {
+		return errors.New("委托记录不存在")
+	}
+	if delegation.DelegatorID != userID {
+		return ErrDelegationForbidden
+	}
+	return s.repo.Revoke(id)
+}
+
+// delegationCovers reports whether a delegation's scope includes the given reviewee.
+func delegationCovers(delegation models.ApprovalDelegation, reviewee *models.User) bool {
+	switch delegation.ScopeType {
+	case "department":
+		return delegation.DepartmentID != nil && reviewee.DepartmentID != nil && *delegation.DepartmentID == *reviewee.DepartmentID
+	case "users":
+		for _, report := range delegation.Reports {
+			if report.ID == reviewee.ID {
+				return true
+			}
+		}
+		return false
+	default:
+		return true
+	}
+}
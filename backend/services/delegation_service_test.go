@@ -0,0 +1,38 @@
+package services
+
+import (
+	"testing"
+
+	"cepm-backend/models"
+)
+
+func TestDelegationCovers(t *testing.T) {
+	id := func(value uint) *uint { return &value }
+	inDepartment := &models.User{ID: 10, DepartmentID: id(1)}
+	otherDepartment := &models.User{ID: 11, DepartmentID: id(2)}
+	noDepartment := &models.User{ID: 12}
+
+	tests := []struct {
+		name       string
+		delegation models.ApprovalDelegation
+		reviewee   *models.User
+		want       bool
+	}{
+		{"all covers every report", models.ApprovalDelegation{ScopeType: "all"}, otherDepartment, true},
+		{"empty scope covers every report", models.ApprovalDelegation{}, noDepartment, true},
+		{"department covers its members", models.ApprovalDelegation{ScopeType: "department", DepartmentID: id(1)}, inDepartment, true},
+		{"department excludes other departments", models.ApprovalDelegation{ScopeType: "department", DepartmentID: id(1)}, otherDepartment, false},
+		{"department excludes reports without a department", models.ApprovalDelegation{ScopeType: "department", DepartmentID: id(1)}, noDepartment, false},
+		{"department without a department covers nobody", models.ApprovalDelegation{ScopeType: "department"}, inDepartment, false},
+		{"users covers the listed reports", models.ApprovalDelegation{ScopeType: "users", Reports: []models.User{{ID: 11}, {ID: 12}}}, noDepartment, true},
+		{"users excludes unlisted reports", models.ApprovalDelegation{ScopeType: "users", Reports: []models.User{{ID: 11}}}, inDepartment, false},
+		{"users without reports covers nobody", models.ApprovalDelegation{ScopeType: "users"}, inDepartment, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := delegationCovers(tt.delegation, tt.reviewee); got != tt.want {
+				t.Errorf("delegationCovers = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
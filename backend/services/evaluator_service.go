@@ -0,0 +1,153 @@
+package services
+
+import (
+	"errors"
+	"math"
+
+	"cepm-backend/database"
+	"cepm-backend/models"
+	"cepm-backend/repositories"
+
+	"gorm.io/gorm"
+)
+
+// assignableStatuses are the review statuses in which evaluators can still be changed.
+var assignableStatuses = []string{"草稿", "待审批", "已驳回", "待打分"}
+
+// EvaluatorInput defines one evaluator assignment, e.g. the line manager at 70% and a project lead at 30%.
+type EvaluatorInput struct {
+	EvaluatorID uint    `json:"evaluatorId"`
+	Role        string  `json:"role"`
+	Weight      float64 `json:"weight"`
+}
+
+// EvaluatorService defines the interface for managing the evaluators of multi-rater reviews.
+type EvaluatorService interface {
+	SetEvaluators(reviewID uint, actor *models.User, inputs []EvaluatorInput) ([]models.ReviewEvaluator, error)
+	ListEvaluators(reviewID uint, viewer *models.User) ([]models.ReviewEvaluator, error)
+	ListPendingEvaluations(evaluatorID uint) ([]models.ReviewEvaluator, error)
+}
+
+type evaluatorService struct {
+	repo       repositories.EvaluatorRepository
+	reviewRepo repositories.PerformanceReviewRepository
+	db         *gorm.DB
+}
+
+// NewEvaluatorService creates a new instance of EvaluatorService.
+func NewEvaluatorService(repo repositories.EvaluatorRepository, reviewRepo repositories.PerformanceReviewRepository) EvaluatorService {
+	return &evaluatorService{repo: repo, reviewRepo: reviewRepo, db: database.DB}
+}
+
+// SetEvaluators replaces the evaluators of a review. Only the employee's direct manager and HR can
+// manage assignments, and only until the first evaluator has submitted. An empty list returns the
+// review to single-scorer mode.
+func (s *evaluatorService) SetEvaluators(reviewID uint, actor *models.User, inputs []EvaluatorInput) ([]models.ReviewEvaluator, error) {
+	// 1. Permission and status checks
+	review, err := s.reviewRepo.GetByID(reviewID)
+	if err != nil {
+		return nil, errors.New("绩效评估不存在")
+	}
+	if !isHRUser(actor) && (review.User.ManagerID == nil || *review.User.ManagerID != actor.ID) {
+		return nil, errors.New("只有直属上级或人事可以设置考核人")
+	}
+	assignable := false
+	for _, status := range assignableStatuses {
+		if review.Status == status {
+			assignable = true
+		}
+	}
+	if !assignable {
+		return nil, errors.New("绩效评估已完成打分，不能再修改考核人")
+	}
+	existing, err := s.repo.ListByReviewID(reviewID)
+	if err != nil {
+		return nil, err
+	}
+	for _, evaluator := range existing {
+		if evaluator.SubmittedAt != nil {
+			return nil, errors.New("已有考核人提交评分，不能再修改考核人")
+		}
+	}
+
+	// 2. Validate the assignments
+	var totalWeight float64
+	seen := make(map[uint]bool)
+	evaluators := make([]models.ReviewEvaluator, 0, len(inputs))
+	for _, input := range inputs {
+		if input.EvaluatorID == review.UserID {
+			return nil, errors.New("被考核人不能作为自己的考核人")
+		}
+		if seen[input.EvaluatorID] {
+			return nil, errors.New("考核人不能重复")
+		}
+		seen[input.EvaluatorID] = true
+		if input.Weight <= 0 {
+			return nil, errors.New("考核人权重必须大于0")
+		}
+		var evaluator models.User
+		if err := s.db.First(&evaluator, input.EvaluatorID).Error; err != nil {
+			return nil, errors.New("考核人不存在")
+		}
+		totalWeight += input.Weight
+		evaluators = append(evaluators, models.ReviewEvaluator{
+			ReviewID:     reviewID,
+			EvaluatorID:  input.EvaluatorID,
+			Role:         input.Role,
+			Weight:       input.Weight,
+			AssignedByID: actor.ID,
+		})
+	}
+	if len(evaluators) > 0 && math.Abs(totalWeight-100) > 0.001 {
+		return nil, errors.New("考核人权重之和必须等于100%")
+	}
+
+	if err := s.repo.ReplaceForReview(reviewID, evaluators); err != nil {
+		return nil, err
+	}
+	return s.repo.ListByReviewID(reviewID)
+}
+
+// ListEvaluators retrieves the evaluators of a review with their submissions. It is visible to the
+// employee, their direct manager, the evaluators themselves and HR. The submitted scores are
+// only shown once every evaluator has submitted.
+func (s *evaluatorService) ListEvaluators(reviewID uint, viewer *models.User) ([]models.ReviewEvaluator, error) {
+	review, err := s.reviewRepo.GetByID(reviewID)
+	if err != nil {
+		return nil, errors.New("绩效评估不存在")
+	}
+	evaluators, err := s.repo.ListByReviewID(reviewID)
+	if err != nil {
+		return nil, err
+	}
+
+	allowed := isHRUser(viewer) || review.UserID == viewer.ID || (review.User.ManagerID != nil && *review.User.ManagerID == viewer.ID)
+	for _, evaluator := range evaluators {
+		if evaluator.EvaluatorID == viewer.ID {
+			allowed = true
+		}
+	}
+	if !allowed {
+		return nil, errors.New("您无权查看此绩效评估的考核人")
+	}
+
+	// Evaluators score independently: until everyone has submitted, only submission status is shown
+	// for the others, and each evaluator sees just their own scores.
+	for _, evaluator := range evaluators {
+		if evaluator.SubmittedAt == nil {
+			for i := range evaluators {
+				if evaluators[i].EvaluatorID != viewer.ID {
+					evaluators[i].Scores = nil
+					evaluators[i].FinalComment = ""
+				}
+			}
+			break
+		}
+	}
+	return evaluators, nil
+}
+
+// ListPendingEvaluations retrieves the reviews awaiting the given evaluator's scores.
+func (s *evaluatorService) ListPendingEvaluations(evaluatorID uint) ([]models.ReviewEvaluator, error) {
+	return s.repo.ListPendingByEvaluatorID(evaluatorID)
+}
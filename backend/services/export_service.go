@@ -0,0 +1,400 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"cepm-backend/database"
+	"cepm-backend/models"
+	"cepm-backend/pdf"
+	"cepm-backend/repositories"
+	"cepm-backend/xlsx"
+
+	"gorm.io/gorm"
+)
+
+// xlsxContentType is the MIME type of Excel workbooks.
+const xlsxContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+// formCategories are the 考核指标类别 in the order the 月度绩效考核表 lists them.
+var formCategories = []string{"工作业绩", llmCategory, "价值观"}
+
+// ExportFile is a generated document ready to download.
+type ExportFile struct {
+	FileName    string
+	ContentType string
+	Data        []byte
+}
+
+// renderTableFile writes a heading row and data rows as a csv or xlsx file.
+func renderTableFile(format string, sheetName string, header []string, rows [][]xlsx.Cell) (*ExportFile, error) {
+	var buf bytes.Buffer
+	if format == "xlsx" {
+		if err := xlsx.WriteTable(&buf, sheetName, header, rows); err != nil {
+			return nil, err
+		}
+		return &ExportFile{ContentType: xlsxContentType, Data: buf.Bytes()}, nil
+	}
+
+	// The byte order mark lets Excel open the UTF-8 file with the Chinese headings intact
+	buf.WriteString("\ufeff")
+	w := csv.NewWriter(&buf)
+	if err := w.Write(header); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		record := make([]string, 0, len(row))
+		for _, cell := range row {
+			if cell.Number != nil {
+				record = append(record, formatNumber(*cell.Number))
+			} else {
+				record = append(record, cell.Text)
+			}
+		}
+		if err := w.Write(record); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+	return &ExportFile{ContentType: "text/csv; charset=utf-8", Data: buf.Bytes()}, nil
+}
+
+// ExportService defines the interface for exporting reviews as official documents.
+type ExportService interface {
+	ExportReviewWorkbook(reviewID uint, viewer *models.User) (*ExportFile, error)
+	ExportDepartmentWorkbook(departmentID uint, period string) (*ExportFile, error)
+	ExportReviewPDF(reviewID uint, viewer *models.User) (*ExportFile, error)
+	ExportDepartmentPDFs(departmentID uint, period string) (*ExportFile, error)
+}
+
+type exportService struct {
+	repo repositories.ExportRepository
+	db   *gorm.DB
+}
+
+// NewExportService creates a new instance of ExportService.
+func NewExportService(repo repositories.ExportRepository) ExportService {
+	return &exportService{repo: repo, db: database.DB}
+}
+
+// ExportReviewWorkbook renders a review as a 月度绩效考核表 workbook. It is available to the employee,
+// their direct manager and HR.
+func (s *exportService) ExportReviewWorkbook(reviewID uint, viewer *models.User) (*ExportFile, error) {
+	review, err := s.repo.GetReview(reviewID)
+	if err != nil {
+		return nil, errors.New("绩效评估不存在")
+	}
+	if !canFollowReview(review, viewer) {
+		return nil, errors.New("您无权导出此绩效评估")
+	}
+
+	var buf bytes.Buffer
+	if err := xlsx.WriteReviewForms(&buf, []xlsx.ReviewForm{newReviewForm(review)}); err != nil {
+		return nil, err
+	}
+	return &ExportFile{
+		FileName:    fmt.Sprintf("月度绩效考核表_%s_%s.xlsx", review.User.Name, review.Period),
+		ContentType: xlsxContentType,
+		Data:        buf.Bytes(),
+	}, nil
+}
+
+// ExportDepartmentWorkbook renders the reviews of a department subtree for a period into one workbook,
+// with a sheet per employee.
+func (s *exportService) ExportDepartmentWorkbook(departmentID uint, period string) (*ExportFile, error) {
+	department, reviews, err := s.departmentReviews(departmentID, period)
+	if err != nil {
+		return nil, err
+	}
+
+	forms := make([]xlsx.ReviewForm, 0, len(reviews))
+	for i := range reviews {
+		forms = append(forms, newReviewForm(&reviews[i]))
+	}
+	var buf bytes.Buffer
+	if err := xlsx.WriteReviewForms(&buf, forms); err != nil {
+		return nil, err
+	}
+	return &ExportFile{
+		FileName:    fmt.Sprintf("月度绩效考核表_%s_%s.xlsx", department.Name, period),
+		ContentType: xlsxContentType,
+		Data:        buf.Bytes(),
+	}, nil
+}
+
+// ExportReviewPDF renders a review as a 月度绩效考核表 PDF with its approval trail and signature lines.
+// It is available to the employee, their direct manager and HR.
+func (s *exportService) ExportReviewPDF(reviewID uint, viewer *models.User) (*ExportFile, error) {
+	review, err := s.repo.GetReview(reviewID)
+	if err != nil {
+		return nil, errors.New("绩效评估不存在")
+	}
+	if !canFollowReview(review, viewer) {
+		return nil, errors.New("您无权导出此绩效评估")
+	}
+
+	data, err := renderReviewPDF(review)
+	if err != nil {
+		return nil, err
+	}
+	return &ExportFile{
+		FileName:    fmt.Sprintf("月度绩效考核表_%s_%s.pdf", review.User.Name, review.Period),
+		ContentType: "application/pdf",
+		Data:        data,
+	}, nil
+}
+
+// ExportDepartmentPDFs renders the reviews of a department subtree for a period as PDFs, one per employee,
+// in a zip archive.
+func (s *exportService) ExportDepartmentPDFs(departmentID uint, period string) (*ExportFile, error) {
+	department, reviews, err := s.departmentReviews(departmentID, period)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	names := make(map[string]bool)
+	for i := range reviews {
+		data, err := renderReviewPDF(&reviews[i])
+		if err != nil {
+			return nil, err
+		}
+		// Employees with the same name are told apart by the review ID
+		name := fmt.Sprintf("%s_%s.pdf", reviews[i].User.Name, period)
+		if names[name] {
+			name = fmt.Sprintf("%s_%s_%d.pdf", reviews[i].User.Name, period, reviews[i].ID)
+		}
+		names[name] = true
+
+		entry, err := archive.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: time.Now()})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := entry.Write(data); err != nil {
+			return nil, err
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+	return &ExportFile{
+		FileName:    fmt.Sprintf("月度绩效考核表_%s_%s.zip", department.Name, period),
+		ContentType: "application/zip",
+		Data:        buf.Bytes(),
+	}, nil
+}
+
+// departmentReviews retrieves a department and the non-draft reviews of its subtree for a period.
+func (s *exportService) departmentReviews(departmentID uint, period string) (*models.Department, []models.PerformanceReview, error) {
+	if period == "" {
+		return nil, nil, errors.New("考核周期不能为空")
+	}
+	var department models.Department
+	if err := s.db.First(&department, departmentID).Error; err != nil {
+		return nil, nil, errors.New("部门不存在")
+	}
+	departmentIDs, err := departmentSubtreeIDs(s.db, departmentID)
+	if err != nil {
+		return nil, nil, err
+	}
+	reviews, err := s.repo.ListReviews(period, departmentIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(reviews) == 0 {
+		return nil, nil, errors.New("该部门在此周期内没有绩效评估")
+	}
+	return &department, reviews, nil
+}
+
+// renderReviewPDF lays out the 月度绩效考核表 of a review, followed by the result, the approval trail,
+// the confirmations and lines for handwritten signatures.
+func renderReviewPDF(review *models.PerformanceReview) ([]byte, error) {
+	form := newReviewForm(review)
+	doc, err := pdf.NewDocument()
+	if errors.Is(err, pdf.ErrNoFont) {
+		return nil, errors.New("服务器未配置PDF中文字体，暂时无法导出PDF，请联系管理员")
+	} else if err != nil {
+		return nil, err
+	}
+	doc.Title(form.Title, 16)
+	doc.Space(6)
+
+	hireDate := ""
+	if form.HireDate != nil {
+		hireDate = form.HireDate.Format("2006-01-02")
+	}
+	doc.Table([]float64{1, 2, 1, 2, 1, 2}, nil, [][]string{
+		{"被考核人", form.EmployeeName, "部门", form.EmployeeDepartment, "岗位", form.EmployeePosition},
+		{"考核人", form.ReviewerName, "部门", form.ReviewerDepartment, "岗位", form.ReviewerPosition},
+		{"入职日期", hireDate, "考核周期", review.Period, "状态", review.Status},
+	})
+	doc.Space(10)
+
+	var rows [][]string
+	var totalWeight, totalScore float64
+	scored := false
+	for _, category := range form.Categories {
+		for _, item := range category.Items {
+			score := ""
+			if item.Score != nil {
+				score = formatNumber(*item.Score)
+				totalScore += *item.Score
+				scored = true
+			}
+			totalWeight += item.Weight * 100
+			rows = append(rows, []string{category.Label, item.Title, item.Standard, formatNumber(round2(item.Weight*100)) + "%", item.Completion, score})
+		}
+	}
+	total := ""
+	if scored {
+		total = formatNumber(round2(totalScore))
+	}
+	rows = append(rows, []string{"合计", "", "", formatNumber(round2(totalWeight)) + "%", "", total})
+	doc.Table([]float64{1.5, 2, 2.8, 0.9, 2.8, 1.5}, []string{"考核指标类别", "考核指标", "考核标准", "权重", "完成情况", "考核人评分"}, rows)
+	doc.Space(10)
+
+	result := "考核结果：尚未评分"
+	if review.TotalScore != nil {
+		result = fmt.Sprintf("考核结果：绩效得分 M = %s，等级：%s", formatNumber(*review.TotalScore), gradeBand(*review.TotalScore))
+		if review.GradePoint != nil {
+			result += fmt.Sprintf("，绩效系数 n = %s", formatNumber(*review.GradePoint))
+		}
+		if review.DefenseScore != nil {
+			result += fmt.Sprintf("（含述职答辩得分 %s）", formatNumber(*review.DefenseScore))
+		}
+	}
+	doc.Paragraph(result, 10)
+	if review.FinalComment != "" {
+		doc.Paragraph("考核评语："+review.FinalComment, 10)
+	}
+	doc.Space(10)
+
+	doc.Paragraph("审批记录", 11)
+	if len(review.Approvals) == 0 {
+		doc.Paragraph("暂无审批记录", 10)
+	} else {
+		approvals := make([][]string, 0, len(review.Approvals))
+		for _, approval := range review.Approvals {
+			approver := approval.Approver.Name
+			if approval.OnBehalfOf != nil {
+				approver += "（代 " + approval.OnBehalfOf.Name + "）"
+			}
+			approvals = append(approvals, []string{approval.CreatedAt.Format("2006-01-02 15:04"), approver, approval.Status, approval.Comment})
+		}
+		doc.Table([]float64{1.6, 1.6, 1.2, 4}, []string{"时间", "操作人", "状态", "意见"}, approvals)
+	}
+	doc.Space(10)
+
+	acknowledged := "员工确认：未确认"
+	if review.AcknowledgedAt != nil {
+		acknowledged = "员工确认：" + review.AcknowledgedAt.Format("2006-01-02 15:04")
+		if review.AutoAcknowledged {
+			acknowledged += "（超时自动确认）"
+		}
+	}
+	doc.Paragraph(acknowledged, 10)
+	confirmed := "人事确认：未确认"
+	if review.HRConfirmedAt != nil {
+		confirmed = "人事确认：" + review.HRConfirmedAt.Format("2006-01-02 15:04")
+		if review.HRConfirmedBy != nil {
+			confirmed += "（" + review.HRConfirmedBy.Name + "）"
+		}
+	}
+	doc.Paragraph(confirmed, 10)
+	doc.Space(10)
+
+	doc.SignatureLines([]string{"被考核人签字", "考核人签字", "人事部门签字"}, 10)
+	return doc.Bytes()
+}
+
+// newReviewForm maps a review onto the 月度绩效考核表 layout. The reviewer is the employee's direct manager,
+// and each item's 考核人评分 is its weighted contribution so that the 合计 row adds up to M.
+func newReviewForm(review *models.PerformanceReview) xlsx.ReviewForm {
+	user := review.User
+	form := xlsx.ReviewForm{
+		SheetName:          user.EnglishName,
+		Title:              formTitle(review.Period),
+		EmployeeName:       user.Name,
+		EmployeeDepartment: user.Department.Name,
+		EmployeePosition:   user.Position,
+		HireDate:           user.HireDate,
+		DefenseScore:       review.DefenseScore,
+		TotalScore:         review.TotalScore,
+		GradePoint:         review.GradePoint,
+	}
+	if form.SheetName == "" {
+		form.SheetName = user.Name
+	}
+	if user.Manager != nil {
+		form.ReviewerName = user.Manager.Name
+		form.ReviewerDepartment = user.Manager.Department.Name
+		form.ReviewerPosition = user.Manager.Position
+	}
+
+	categories := append([]string{}, formCategories...)
+	byCategory := make(map[string][]models.PerformanceItem)
+	for _, item := range review.Items {
+		if !containsString(categories, item.Category) {
+			categories = append(categories, item.Category)
+		}
+		byCategory[item.Category] = append(byCategory[item.Category], item)
+	}
+	for _, category := range categories {
+		var weight float64
+		formCategory := xlsx.FormCategory{}
+		for _, item := range byCategory[category] {
+			weight += item.Weight
+			formItem := xlsx.FormItem{
+				Title:      item.Title,
+				Standard:   item.Target,
+				Weight:     item.Weight / 100,
+				Completion: item.CompletionDetails,
+			}
+			if formItem.Standard == "" {
+				formItem.Standard = item.Description
+			}
+			if item.Score != nil {
+				contribution := round2(item.Weight / 100 * *item.Score)
+				formItem.Score = &contribution
+			}
+			formCategory.Items = append(formCategory.Items, formItem)
+		}
+		formCategory.Label = category + "\n" + strconv.FormatFloat(weight, 'f', -1, 64) + "%"
+		form.Categories = append(form.Categories, formCategory)
+	}
+	return form
+}
+
+// formTitle renders the form title, e.g. "2025年 7 月    月度绩效考核表" for the period 2025-07.
+func formTitle(period string) string {
+	if monthlyPeriodPattern.MatchString(period) {
+		month, _ := strconv.Atoi(period[5:])
+		return fmt.Sprintf("%s年 %d 月    月度绩效考核表", period[:4], month)
+	}
+	return strings.TrimSpace(period) + "    绩效考核表"
+}
+
+// formatNumber renders a score or weight without trailing zeros.
+func formatNumber(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,589 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"cepm-backend/database"
+	"cepm-backend/models"
+	"cepm-backend/repositories"
+	"cepm-backend/xlsx"
+
+	"gorm.io/gorm"
+)
+
+// formTitlePattern extracts the period from a 月度绩效考核表 title such as "2025年 7 月    月度绩效考核表".
+var formTitlePattern = regexp.MustCompile(`(\d{4})\s*年\s*(\d{1,2})\s*月`)
+
+// flatColumnNames maps the accepted headers of the flat layout, compared case-insensitively, to column keys.
+var flatColumnNames = map[string]string{
+	"邮箱": "email", "email": "email",
+	"企业微信id": "wechat", "企业微信userid": "wechat", "wechatuserid": "wechat", "wechat_userid": "wechat", "userid": "wechat",
+	"周期": "period", "考核周期": "period", "period": "period",
+	"考核指标类别": "category", "类别": "category", "category": "category",
+	"考核指标": "title", "指标": "title", "title": "title",
+	"考核标准": "target", "标准": "target", "target": "target",
+	"描述": "description", "description": "description",
+	"权重": "weight", "weight": "weight",
+	"完成情况": "completion", "completiondetails": "completion",
+	"考核人评分": "score", "评分": "score", "score": "score",
+}
+
+// ImportRowError is a problem found in an imported workbook. Row is 1-based, or 0 for a whole sheet.
+type ImportRowError struct {
+	Sheet   string `json:"sheet"`
+	Row     int    `json:"row"`
+	Message string `json:"message"`
+}
+
+// ImportedReview summarizes a review that an import creates or updates.
+type ImportedReview struct {
+	Sheet      string   `json:"sheet"`
+	Row        int      `json:"row"`
+	UserID     uint     `json:"userId"`
+	UserName   string   `json:"userName"`
+	Period     string   `json:"period"`
+	Action     string   `json:"action"` // create, replace (the items of a draft or rejected review), score (an approved plan)
+	Status     string   `json:"status"`
+	ItemCount  int      `json:"itemCount"`
+	TotalScore *float64 `json:"totalScore,omitempty"`
+	ReviewID   uint     `json:"reviewId,omitempty"` // Set once committed
+}
+
+// ImportResult is the outcome of a dry run or a commit. A commit saves nothing if there are any errors.
+type ImportResult struct {
+	DryRun    bool             `json:"dryRun"`
+	Committed bool             `json:"committed"`
+	Reviews   []ImportedReview `json:"reviews"`
+	Errors    []ImportRowError `json:"errors"`
+}
+
+// ImportService defines the interface for importing plans and scores from Excel workbooks.
+type ImportService interface {
+	ImportWorkbook(actorID uint, data []byte, defaultPeriod string, dryRun bool) (*ImportResult, error)
+}
+
+type importService struct {
+	repo                 repositories.ImportRepository
+	reviewRepo           repositories.PerformanceReviewRepository
+	cycleRepo            repositories.ReviewCycleRepository
+	improvementPlans     ImprovementPlanService
+	systemSettingService *SystemSettingService
+	db                   *gorm.DB
+}
+
+// NewImportService creates a new instance of ImportService.
+func NewImportService(repo repositories.ImportRepository, reviewRepo repositories.PerformanceReviewRepository, cycleRepo repositories.ReviewCycleRepository, improvementPlans ImprovementPlanService, systemSettingService *SystemSettingService) ImportService {
+	return &importService{repo: repo, reviewRepo: reviewRepo, cycleRepo: cycleRepo, improvementPlans: improvementPlans, systemSettingService: systemSettingService, db: database.DB}
+}
+
+// importedPlan is one employee's plan for a period as read from a workbook.
+type importedPlan struct {
+	sheet       string
+	row         int
+	identifiers []string // Email or WeChat user ID candidates
+	name        string   // 附件1 layout: 被考核人姓名, matched together with department when no identifier matches
+	department  string   // 附件1 layout: 被考核人部门
+	period      string
+	items       []importedItem
+}
+
+type importedItem struct {
+	row         int
+	category    string
+	title       string
+	target      string
+	description string
+	completion  string
+	weight      float64 // Percentage
+	score       *float64
+}
+
+// pendingImport is a validated plan ready to be committed.
+type pendingImport struct {
+	summary ImportedReview
+	cycle   *models.ReviewCycle
+	save    repositories.ReviewImport
+}
+
+// ImportWorkbook reads plans and scores from a workbook in the 附件1 layout (one sheet per employee) or a flat
+// layout (one row per item, with a header row) and validates them. Unless dryRun is set and when there are
+// no errors, the reviews are created or updated in a single transaction.
+//
+// Imported plans are taken as approved (待打分); plans with every item scored are completed (已完成).
+//
+// In the 附件1 layout the employee is matched by the sheet name or the 被考核人姓名 cell holding their email
+// or WeChat user ID, or else by 被考核人姓名 and 被考核人部门 as in the export; the period comes from the title, and 考核人评分 is the weighted contribution as in the
+// export. In the flat layout 评分 is the item score out of 120.
+func (s *importService) ImportWorkbook(actorID uint, data []byte, defaultPeriod string, dryRun bool) (*ImportResult, error) {
+	sheets, err := xlsx.ReadWorkbook(data)
+	if err != nil {
+		return nil, err
+	}
+
+	// 1. Parse every sheet in the layout it uses
+	result := &ImportResult{DryRun: dryRun, Reviews: []ImportedReview{}, Errors: []ImportRowError{}}
+	var plans []*importedPlan
+	for i := range sheets {
+		sheet := &sheets[i]
+		var sheetPlans []*importedPlan
+		var sheetErrors []ImportRowError
+		if headerRow := formHeaderRow(sheet); headerRow >= 0 {
+			sheetPlans, sheetErrors = parseFormSheet(sheet, headerRow)
+		} else {
+			sheetPlans, sheetErrors = parseFlatSheet(sheet)
+		}
+		plans = append(plans, sheetPlans...)
+		result.Errors = append(result.Errors, sheetErrors...)
+	}
+	if len(plans) == 0 && len(result.Errors) == 0 {
+		result.Errors = append(result.Errors, ImportRowError{Message: "工作簿中没有可导入的绩效计划"})
+	}
+
+	// 2. Validate each plan against the employees, cycles and existing reviews
+	var pending []*pendingImport
+	seen := make(map[string]bool)
+	for _, plan := range plans {
+		p, errs := s.validatePlan(plan, defaultPeriod, actorID)
+		if p != nil {
+			key := fmt.Sprintf("%d|%s", p.summary.UserID, p.summary.Period)
+			if seen[key] {
+				errs = append(errs, ImportRowError{Sheet: plan.sheet, Row: plan.row, Message: "同一员工同一周期的计划重复出现"})
+			}
+			seen[key] = true
+		}
+		if len(errs) > 0 {
+			result.Errors = append(result.Errors, errs...)
+			continue
+		}
+		pending = append(pending, p)
+		result.Reviews = append(result.Reviews, p.summary)
+	}
+	if dryRun || len(result.Errors) > 0 {
+		return result, nil
+	}
+
+	// 3. Commit: save everything at once, creating the monthly cycles that do not exist yet
+	saves := make([]repositories.ReviewImport, 0, len(pending))
+	for _, p := range pending {
+		p.save.Cycle = p.cycle
+		saves = append(saves, p.save)
+	}
+	if err := s.repo.Commit(saves); err != nil {
+		return nil, err
+	}
+
+	result.Committed = true
+	for i, p := range pending {
+		result.Reviews[i].ReviewID = p.save.Review.ID
+		if p.save.Review.TotalScore != nil {
+			// A failure here must not undo the import
+			if _, err := s.improvementPlans.EvaluateReview(p.save.Review.ID); err != nil {
+				log.Printf("PIP trigger check failed for review %d: %v", p.save.Review.ID, err)
+			}
+		}
+	}
+	return result, nil
+}
+
+// validatePlan checks a plan and prepares the review to save. It returns the row errors found.
+func (s *importService) validatePlan(plan *importedPlan, defaultPeriod string, actorID uint) (*pendingImport, []ImportRowError) {
+	fail := func(row int, message string) []ImportRowError {
+		return []ImportRowError{{Sheet: plan.sheet, Row: row, Message: message}}
+	}
+
+	// Employee
+	user, err := s.findUser(plan)
+	if err != nil {
+		return nil, fail(plan.row, err.Error())
+	}
+
+	// Cycle; a missing monthly cycle is only created on commit
+	period := plan.period
+	if period == "" {
+		period = strings.TrimSpace(defaultPeriod)
+	}
+	if period == "" {
+		return nil, fail(plan.row, "无法确定考核周期，请在表格或请求中提供")
+	}
+	cycle, err := findCycleByCode(s.cycleRepo, period, false)
+	if err != nil {
+		return nil, fail(plan.row, err.Error()+": "+period)
+	}
+	if err := checkCycleOpenFor(s.db, cycle, user.ID); err != nil {
+		return nil, fail(plan.row, err.Error())
+	}
+
+	// Items
+	errs := validateImportedItems(plan)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	scored := plan.items[0].score != nil
+	items := make([]models.PerformanceItem, 0, len(plan.items))
+	for _, item := range plan.items {
+		items = append(items, models.PerformanceItem{
+			Category:          item.category,
+			Title:             item.title,
+			Description:       item.description,
+			Target:            item.target,
+			Weight:            item.weight,
+			CompletionDetails: item.completion,
+			Score:             item.score,
+		})
+	}
+
+	p := &pendingImport{
+		cycle: cycle,
+		summary: ImportedReview{
+			Sheet:     plan.sheet,
+			Row:       plan.row,
+			UserID:    user.ID,
+			UserName:  user.Name,
+			Period:    cycle.Code,
+			ItemCount: len(items),
+		},
+	}
+
+	// Existing review: drafts and rejected plans are replaced, approved plans can only be scored
+	review, err := s.reviewRepo.GetByUserIDAndPeriod(user.ID, cycle.Code)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		review = &models.PerformanceReview{UserID: user.ID, Period: cycle.Code, Items: items}
+		p.summary.Action = "create"
+	case err != nil:
+		return nil, fail(plan.row, err.Error())
+	case review.CalibrationSessionID != nil:
+		return nil, fail(plan.row, "该员工此周期的绩效评估正在人事校准中")
+	case review.Status == "草稿" || review.Status == "已驳回":
+		review.Items = items
+		p.summary.Action = "replace"
+		p.save.ReplaceItems = true
+	case review.Status == "待打分":
+		if !scored {
+			return nil, fail(plan.row, "该员工此周期的计划已审批，只能导入评分")
+		}
+		// Reviews with assigned evaluators are scored by each evaluator, not by a single imported score
+		var evaluatorCount int64
+		if err := s.db.Model(&models.ReviewEvaluator{}).Where("review_id = ?", review.ID).Count(&evaluatorCount).Error; err != nil {
+			return nil, fail(plan.row, err.Error())
+		}
+		if evaluatorCount > 0 {
+			return nil, fail(plan.row, "该员工此周期的绩效评估已指定多位考核人，需由考核人分别打分，不能导入评分")
+		}
+		matched, errs := matchApprovedItems(plan, review.Items, items)
+		if len(errs) > 0 {
+			return nil, errs
+		}
+		review.Items = matched
+		p.summary.Action = "score"
+	default:
+		return nil, fail(plan.row, "该员工此周期的绩效评估已进入"+review.Status+"状态，不能通过导入修改")
+	}
+	comment := "Excel导入计划"
+	review.Status = "待打分"
+	if scored {
+		result, err := recomputeResult(s.db, s.systemSettingService, review, calculateTotalScore(items))
+		if err != nil {
+			return nil, fail(plan.row, err.Error())
+		}
+		totalScore := result.TotalScore
+		scoredAt := time.Now()
+		review.TotalScore = &totalScore
+		review.GradePoint = &result.GradePoint
+		review.NormalizedScore = result.NormalizedScore
+		review.ScoredAt = &scoredAt
+		review.Status = "已完成"
+		comment = "Excel导入评分"
+		p.summary.TotalScore = &totalScore
+	}
+	p.summary.Status = review.Status
+	p.save.Review = review
+	p.save.Approval = &models.ApprovalHistory{ApproverID: actorID, Status: review.Status, Comment: comment}
+	return p, nil
+}
+
+// findUser matches an employee by email (case-insensitive) or WeChat user ID, or else by name and
+// department as written by the 月度绩效考核表 export.
+func (s *importService) findUser(plan *importedPlan) (*models.User, error) {
+	for _, identifier := range plan.identifiers {
+		if identifier == "" {
+			continue
+		}
+		var user models.User
+		if err := s.db.Where("LOWER(email) = LOWER(?) OR wechat_userid = ?", identifier, identifier).First(&user).Error; err == nil {
+			return &user, nil
+		}
+	}
+	if plan.name == "" || plan.department == "" {
+		return nil, fmt.Errorf("找不到邮箱或企业微信ID为%s的员工", strings.Join(plan.identifiers, "/"))
+	}
+
+	var users []models.User
+	if err := s.db.Joins("JOIN departments ON departments.id = users.department_id").
+		Where("users.name = ? AND departments.name = ?", plan.name, plan.department).Limit(2).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	switch len(users) {
+	case 0:
+		return nil, fmt.Errorf("找不到%s部门姓名为%s的员工", plan.department, plan.name)
+	case 1:
+		return &users[0], nil
+	default:
+		return nil, fmt.Errorf("%s部门有多名姓名为%s的员工，请在被考核人姓名处填写邮箱或企业微信ID", plan.department, plan.name)
+	}
+}
+
+// validateImportedItems applies the category and weight rules of the 月度绩效考核表: known categories,
+// complete items, 工作业绩 at 80%, a 100% total, and either every item scored (0-120) or none.
+func validateImportedItems(plan *importedPlan) []ImportRowError {
+	var errs []ImportRowError
+	fail := func(row int, message string) {
+		errs = append(errs, ImportRowError{Sheet: plan.sheet, Row: row, Message: message})
+	}
+	if len(plan.items) == 0 {
+		fail(plan.row, "没有绩效项")
+		return errs
+	}
+
+	var workWeight, totalWeight float64
+	scoredCount := 0
+	for _, item := range plan.items {
+		if !containsString(formCategories, item.category) {
+			fail(item.row, fmt.Sprintf("无效的考核指标类别“%s”，应为工作业绩、大模型或价值观", item.category))
+		}
+		if item.title == "" || item.target == "" {
+			fail(item.row, "考核指标和考核标准均不能为空")
+		}
+		if item.weight <= 0 {
+			fail(item.row, "权重必须大于0")
+		}
+		if item.score != nil {
+			scoredCount++
+			if *item.score < 0 || *item.score > 120 {
+				fail(item.row, "单项分数必须在0到120之间")
+			}
+		}
+		if item.category == "工作业绩" {
+			workWeight += item.weight
+		}
+		totalWeight += item.weight
+	}
+	if round2(workWeight) != 80 {
+		fail(plan.row, "“工作业绩”部分的总权重必须等于80%")
+	}
+	if round2(totalWeight) != 100 {
+		fail(plan.row, "所有绩效项的总权重必须等于100%")
+	}
+	if scoredCount > 0 && scoredCount < len(plan.items) {
+		fail(plan.row, "评分不完整：要么所有绩效项都有评分，要么都没有")
+	}
+	return errs
+}
+
+// matchApprovedItems maps imported scores onto the items of an approved plan by category and title.
+// The plan itself cannot change, so every item must match.
+func matchApprovedItems(plan *importedPlan, existing []models.PerformanceItem, imported []models.PerformanceItem) ([]models.PerformanceItem, []ImportRowError) {
+	var errs []ImportRowError
+	byKey := make(map[string]models.PerformanceItem)
+	for _, item := range existing {
+		byKey[item.Category+"|"+item.Title] = item
+	}
+	matched := make([]models.PerformanceItem, 0, len(imported))
+	for i, item := range imported {
+		existingItem, ok := byKey[item.Category+"|"+item.Title]
+		if !ok || existingItem.Weight != item.Weight {
+			errs = append(errs, ImportRowError{Sheet: plan.sheet, Row: plan.items[i].row, Message: "与已审批计划中的绩效项或权重不一致: " + item.Title})
+			continue
+		}
+		delete(byKey, item.Category+"|"+item.Title)
+		existingItem.CompletionDetails = item.CompletionDetails
+		existingItem.Score = item.Score
+		matched = append(matched, existingItem)
+	}
+	if len(errs) == 0 && len(byKey) > 0 {
+		errs = append(errs, ImportRowError{Sheet: plan.sheet, Row: plan.row, Message: "缺少已审批计划中的部分绩效项"})
+	}
+	return matched, errs
+}
+
+// formHeaderRow returns the zero-based row of the 考核指标类别 heading of the 附件1 layout, or -1.
+func formHeaderRow(sheet *xlsx.Sheet) int {
+	for row := 0; row < len(sheet.Rows) && row < 10; row++ {
+		if strings.TrimSpace(sheet.Cell(row, 0)) == "考核指标类别" {
+			return row
+		}
+	}
+	return -1
+}
+
+// parseFormSheet reads a sheet in the 附件1 layout. Columns: A 类别, B 考核指标, C 考核标准, E 权重,
+// F 完成情况, H 考核人评分 (the weighted contribution). The template's placeholder rows are skipped.
+func parseFormSheet(sheet *xlsx.Sheet, headerRow int) ([]*importedPlan, []ImportRowError) {
+	plan := &importedPlan{
+		sheet:       sheet.Name,
+		row:         1,
+		identifiers: []string{strings.TrimSpace(sheet.Name), strings.TrimSpace(sheet.Cell(1, 1))},
+		name:        strings.TrimSpace(sheet.Cell(1, 1)),
+		department:  strings.TrimSpace(sheet.Cell(1, 3)),
+	}
+	if match := formTitlePattern.FindStringSubmatch(sheet.Cell(0, 0)); match != nil {
+		month, _ := strconv.Atoi(match[2])
+		plan.period = fmt.Sprintf("%s-%02d", match[1], month)
+	}
+
+	var errs []ImportRowError
+	for row := headerRow + 1; row < len(sheet.Rows); row++ {
+		if strings.TrimSpace(sheet.Cell(row, 0)) == "合计" {
+			break
+		}
+		title := strings.TrimSpace(sheet.Cell(row, 1))
+		weightText := strings.TrimSpace(sheet.Cell(row, 4))
+		if title == "" || (strings.HasSuffix(title, "---") && weightText == "") {
+			continue
+		}
+		item := importedItem{
+			row:        row + 1,
+			category:   normalizeCategory(sheet.Cell(row, 0)),
+			title:      title,
+			target:     strings.TrimSpace(sheet.Cell(row, 2)),
+			completion: strings.TrimSpace(sheet.Cell(row, 5)),
+		}
+		item.description = item.target
+		weight, err := parseWeight(weightText)
+		if err != nil {
+			errs = append(errs, ImportRowError{Sheet: sheet.Name, Row: row + 1, Message: err.Error()})
+			continue
+		}
+		item.weight = weight
+		if contribution, err := parseOptionalNumber(sheet.Cell(row, 7)); err != nil {
+			errs = append(errs, ImportRowError{Sheet: sheet.Name, Row: row + 1, Message: "考核人评分不是有效的数字"})
+			continue
+		} else if contribution != nil && weight > 0 {
+			score := round2(*contribution * 100 / weight)
+			item.score = &score
+		}
+		plan.items = append(plan.items, item)
+	}
+	return []*importedPlan{plan}, errs
+}
+
+// parseFlatSheet reads a sheet with a header row and one item per row, grouped into plans by employee and period.
+// Empty sheets are ignored.
+func parseFlatSheet(sheet *xlsx.Sheet) ([]*importedPlan, []ImportRowError) {
+	if len(sheet.Rows) == 0 {
+		return nil, nil
+	}
+	columns := make(map[string]int)
+	for col, header := range sheet.Rows[0] {
+		if key, ok := flatColumnNames[strings.ToLower(strings.TrimSpace(header))]; ok {
+			if _, exists := columns[key]; !exists {
+				columns[key] = col
+			}
+		}
+	}
+	_, hasEmail := columns["email"]
+	_, hasWechat := columns["wechat"]
+	_, hasTitle := columns["title"]
+	_, hasCategory := columns["category"]
+	_, hasWeight := columns["weight"]
+	if !(hasEmail || hasWechat) || !hasTitle || !hasCategory || !hasWeight {
+		return nil, []ImportRowError{{Sheet: sheet.Name, Row: 1, Message: "无法识别表格格式：需要附件1格式，或包含邮箱/企业微信ID、考核指标类别、考核指标、权重列的表头"}}
+	}
+	cell := func(row int, key string) string {
+		col, ok := columns[key]
+		if !ok {
+			return ""
+		}
+		return strings.TrimSpace(sheet.Cell(row, col))
+	}
+
+	var plans []*importedPlan
+	var errs []ImportRowError
+	byKey := make(map[string]*importedPlan)
+	for row := 1; row < len(sheet.Rows); row++ {
+		if strings.TrimSpace(strings.Join(sheet.Rows[row], "")) == "" {
+			continue
+		}
+		identifiers := []string{cell(row, "email"), cell(row, "wechat")}
+		if identifiers[0] == "" && identifiers[1] == "" {
+			errs = append(errs, ImportRowError{Sheet: sheet.Name, Row: row + 1, Message: "邮箱和企业微信ID不能同时为空"})
+			continue
+		}
+		period := cell(row, "period")
+		key := strings.ToLower(identifiers[0]) + "|" + identifiers[1] + "|" + period
+		plan, ok := byKey[key]
+		if !ok {
+			plan = &importedPlan{sheet: sheet.Name, row: row + 1, identifiers: identifiers, period: period}
+			byKey[key] = plan
+			plans = append(plans, plan)
+		}
+
+		item := importedItem{
+			row:         row + 1,
+			category:    normalizeCategory(cell(row, "category")),
+			title:       cell(row, "title"),
+			target:      cell(row, "target"),
+			description: cell(row, "description"),
+			completion:  cell(row, "completion"),
+		}
+		if item.description == "" {
+			item.description = item.target
+		}
+		weight, err := parseWeight(cell(row, "weight"))
+		if err != nil {
+			errs = append(errs, ImportRowError{Sheet: sheet.Name, Row: row + 1, Message: err.Error()})
+			continue
+		}
+		item.weight = weight
+		if item.score, err = parseOptionalNumber(cell(row, "score")); err != nil {
+			errs = append(errs, ImportRowError{Sheet: sheet.Name, Row: row + 1, Message: "评分不是有效的数字"})
+			continue
+		}
+		plan.items = append(plan.items, item)
+	}
+	return plans, errs
+}
+
+// normalizeCategory maps a category cell such as "工作业绩\n80%" to its category name.
+func normalizeCategory(value string) string {
+	value = strings.Join(strings.Fields(value), "")
+	for _, category := range formCategories {
+		if strings.HasPrefix(value, category) {
+			return category
+		}
+	}
+	return value
+}
+
+// parseWeight reads a weight as a percentage. "50%" and 50 are 50%, and values up to 1 are fractions
+// as in the template, so 0.5 is also 50%.
+func parseWeight(value string) (float64, error) {
+	value = strings.TrimSpace(value)
+	percent := strings.HasSuffix(value, "%")
+	weight, err := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+	if err != nil {
+		return 0, errors.New("权重不是有效的数字: " + value)
+	}
+	if !percent && weight <= 1 {
+		weight *= 100
+	}
+	return round2(weight), nil
+}
+
+func parseOptionalNumber(value string) (*float64, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &number, nil
+}
@@ -0,0 +1,192 @@
+package services
+
+import (
+	"bytes"
+	"testing"
+
+	"cepm-backend/xlsx"
+)
+
+func TestParseWeight(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    float64
+		wantErr bool
+	}{
+		{"50%", 50, false},
+		{" 80 % ", 0, true},
+		{"80%", 80, false},
+		{"50", 50, false},
+		{"0.5", 50, false},
+		{"1", 100, false},
+		{"0.125", 12.5, false},
+		{"12.5%", 12.5, false},
+		{"", 0, true},
+		{"abc", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseWeight(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseWeight(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("parseWeight(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFormSheet(t *testing.T) {
+	sheet := &xlsx.Sheet{
+		Name: "zhangsan",
+		Rows: [][]string{
+			{"2025年 7 月    月度绩效考核表"},
+			{"被考核人姓名", "zhangsan@example.com", "被考核人部门", "研发部"},
+			{"考核指标类别", "考核指标", "考核标准", "", "权重", "完成情况", "", "考核人评分"},
+			{"工作业绩\n80%", "指标A", "标准A", "", "50%", "已完成", "", "45"},
+			{"工作业绩", "指标B", "标准B", "", "0.3", "", "", "24"},
+			{"工作业绩", "示例---", "", "", "", "", "", ""},
+			{"大模型", "案例", "标准C", "", "10%", "", "", ""},
+			{"价值观", "价值观", "标准D", "", "权重", "", "", ""},
+			{"合计", "", "", "", "100%"},
+			{"工作业绩", "合计之后", "标准E", "", "10%"},
+		},
+	}
+
+	headerRow := formHeaderRow(sheet)
+	if headerRow != 2 {
+		t.Fatalf("formHeaderRow = %d, want 2", headerRow)
+	}
+	plans, errs := parseFormSheet(sheet, headerRow)
+	if len(plans) != 1 {
+		t.Fatalf("got %d plans, want 1", len(plans))
+	}
+	plan := plans[0]
+	if plan.period != "2025-07" {
+		t.Errorf("period = %q, want 2025-07", plan.period)
+	}
+	if len(plan.identifiers) != 2 || plan.identifiers[0] != "zhangsan" || plan.identifiers[1] != "zhangsan@example.com" {
+		t.Errorf("identifiers = %v", plan.identifiers)
+	}
+	if plan.name != "zhangsan@example.com" || plan.department != "研发部" {
+		t.Errorf("name = %q, department = %q", plan.name, plan.department)
+	}
+	if len(errs) != 1 || errs[0].Row != 8 {
+		t.Errorf("errors = %+v, want one error on row 8", errs)
+	}
+
+	want := []struct {
+		row      int
+		category string
+		title    string
+		weight   float64
+		score    *float64
+	}{
+		{4, "工作业绩", "指标A", 50, floatPtr(90)},
+		{5, "工作业绩", "指标B", 30, floatPtr(80)},
+		{7, "大模型", "案例", 10, nil},
+	}
+	if len(plan.items) != len(want) {
+		t.Fatalf("got %d items, want %d", len(plan.items), len(want))
+	}
+	for i, w := range want {
+		item := plan.items[i]
+		if item.row != w.row || item.category != w.category || item.title != w.title || item.weight != w.weight {
+			t.Errorf("item %d = %+v, want %+v", i, item, w)
+		}
+		switch {
+		case w.score == nil && item.score != nil:
+			t.Errorf("item %d score = %v, want none", i, *item.score)
+		case w.score != nil && (item.score == nil || *item.score != *w.score):
+			t.Errorf("item %d score = %v, want %v", i, item.score, *w.score)
+		}
+	}
+}
+
+func TestParseFlatSheet(t *testing.T) {
+	header := []string{"邮箱", "企业微信ID", "周期", "类别", "指标", "标准", "权重", "评分"}
+	rows := [][]xlsx.Cell{
+		textRow("a@example.com", "", "2025-07", "工作业绩", "指标A", "标准A", "80%", "100"),
+		textRow("A@example.com", "", "2025-07", "价值观", "价值观", "标准B", "20%", ""),
+		textRow("", "lisi", "2025-07", "工作业绩", "指标C", "标准C", "0.8", "95.5"),
+		textRow("", "", "2025-07", "工作业绩", "指标D", "标准D", "80%", ""),
+		textRow("", "lisi", "2025-07", "大模型", "指标E", "标准E", "十", ""),
+		textRow("", "lisi", "2025-07", "价值观", "指标F", "标准F", "20%", "优"),
+		textRow("a@example.com", "", "2025-08", "工作业绩", "指标G", "标准G", "100%", ""),
+	}
+	var buf bytes.Buffer
+	if err := xlsx.WriteTable(&buf, "导入", header, rows); err != nil {
+		t.Fatal(err)
+	}
+	sheets, err := xlsx.ReadWorkbook(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sheets) != 1 || formHeaderRow(&sheets[0]) != -1 {
+		t.Fatalf("expected a single sheet in the flat layout")
+	}
+
+	plans, errs := parseFlatSheet(&sheets[0])
+
+	wantErrRows := []int{5, 6, 7}
+	if len(errs) != len(wantErrRows) {
+		t.Fatalf("errors = %+v, want rows %v", errs, wantErrRows)
+	}
+	for i, row := range wantErrRows {
+		if errs[i].Row != row {
+			t.Errorf("error %d on row %d, want %d", i, errs[i].Row, row)
+		}
+	}
+
+	wantPlans := []struct {
+		row    int
+		period string
+		titles []string
+	}{
+		{2, "2025-07", []string{"指标A", "价值观"}},
+		{4, "2025-07", []string{"指标C"}},
+		{8, "2025-08", []string{"指标G"}},
+	}
+	if len(plans) != len(wantPlans) {
+		t.Fatalf("got %d plans, want %d", len(plans), len(wantPlans))
+	}
+	for i, w := range wantPlans {
+		plan := plans[i]
+		if plan.row != w.row || plan.period != w.period || len(plan.items) != len(w.titles) {
+			t.Errorf("plan %d = row %d period %s with %d items, want %+v", i, plan.row, plan.period, len(plan.items), w)
+			continue
+		}
+		for j, title := range w.titles {
+			if plan.items[j].title != title {
+				t.Errorf("plan %d item %d = %q, want %q", i, j, plan.items[j].title, title)
+			}
+		}
+	}
+	if first := plans[0].items[0]; first.weight != 80 || first.score == nil || *first.score != 100 || first.description != "标准A" {
+		t.Errorf("first item = %+v", first)
+	}
+	if second := plans[1].items[0]; second.weight != 80 || second.score == nil || *second.score != 95.5 {
+		t.Errorf("second plan item = %+v", second)
+	}
+}
+
+func TestParseFlatSheetRequiresHeadings(t *testing.T) {
+	sheet := &xlsx.Sheet{Name: "Sheet1", Rows: [][]string{{"姓名", "分数"}, {"张三", "90"}}}
+	plans, errs := parseFlatSheet(sheet)
+	if len(plans) != 0 || len(errs) != 1 || errs[0].Row != 1 {
+		t.Errorf("plans = %v, errors = %+v, want a single heading error", plans, errs)
+	}
+}
+
+func textRow(values ...string) []xlsx.Cell {
+	row := make([]xlsx.Cell, 0, len(values))
+	for _, value := range values {
+		row = append(row, xlsx.TextCell(value))
+	}
+	return row
+}
+
+func floatPtr(value float64) *float64 {
+	return &value
+}
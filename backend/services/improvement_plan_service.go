@@ -0,0 +1,457 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"cepm-backend/database"
+	"cepm-backend/models"
+	"cepm-backend/repositories"
+
+	"gorm.io/gorm"
+)
+
+// SettingPIPTriggerRule is the SystemSetting key holding the PIPTriggerRule as JSON.
+const SettingPIPTriggerRule = "pip_trigger_rule"
+
+// PIPTriggerRule configures when a scored review automatically opens a draft improvement plan.
+type PIPTriggerRule struct {
+	OnUnqualified              bool `json:"onUnqualified"`              // A single 不合格 result (coefficient 0)
+	ConsecutiveQualifiedMonths int  `json:"consecutiveQualifiedMonths"` // This many consecutive months at 合格 or below; 0 disables
+}
+
+// resultStatuses are the statuses of reviews with a final score.
+var resultStatuses = []string{"已完成", "待人事确认", "申诉中", "已归档"}
+
+// defaultPIPTriggerRule opens a plan on any 不合格 result or three consecutive months at 合格.
+var defaultPIPTriggerRule = PIPTriggerRule{OnUnqualified: true, ConsecutiveQualifiedMonths: 3}
+
+// ImprovementGoalInput defines one goal of an improvement plan.
+type ImprovementGoalInput struct {
+	Description     string `json:"description"`
+	SuccessCriteria string `json:"successCriteria"`
+}
+
+// ImprovementPlanInput defines the editable fields of a draft improvement plan.
+type ImprovementPlanInput struct {
+	UserID       uint                   `json:"userId"`  // Only used when HR creates a plan manually
+	OwnerID      uint                   `json:"ownerId"` // Defaults to the employee's direct manager
+	StartDate    string                 `json:"startDate"`
+	EndDate      string                 `json:"endDate"`
+	Goals        []ImprovementGoalInput `json:"goals"`
+	CheckInDates []string               `json:"checkInDates"` // Format: YYYY-MM-DD
+}
+
+// ImprovementPlanCloseInput defines the outcome of a closed plan.
+type ImprovementPlanCloseInput struct {
+	Passed   bool          `json:"passed"`
+	GoalsMet map[uint]bool `json:"goalsMet"` // Goal ID -> met
+	Comment  string        `json:"comment"`
+}
+
+// ImprovementPlanService defines the interface for performance improvement plans.
+type ImprovementPlanService interface {
+	EvaluateReview(reviewID uint) (*models.ImprovementPlan, error)
+	CreatePlan(hrID uint, input *ImprovementPlanInput) (*models.ImprovementPlan, error)
+	ListPlans(viewer *models.User, status string) ([]models.ImprovementPlan, error)
+	GetPlan(id uint, viewer *models.User) (*models.ImprovementPlan, error)
+	UpdatePlan(id uint, actor *models.User, input *ImprovementPlanInput) (*models.ImprovementPlan, error)
+	StartPlan(id uint, actor *models.User) error
+	CompleteCheckIn(id uint, checkInID uint, actor *models.User, notes string) error
+	ClosePlan(id uint, actor *models.User, input *ImprovementPlanCloseInput) error
+	CancelPlan(id uint, actor *models.User, comment string) error
+}
+
+type improvementPlanService struct {
+	repo                 repositories.ImprovementPlanRepository
+	reviewRepo           repositories.PerformanceReviewRepository
+	systemSettingService *SystemSettingService
+	db                   *gorm.DB
+}
+
+// NewImprovementPlanService creates a new instance of ImprovementPlanService.
+func NewImprovementPlanService(repo repositories.ImprovementPlanRepository, reviewRepo repositories.PerformanceReviewRepository, systemSettingService *SystemSettingService) ImprovementPlanService {
+	return &improvementPlanService{repo: repo, reviewRepo: reviewRepo, systemSettingService: systemSettingService, db: database.DB}
+}
+
+// EvaluateReview applies the PIP trigger rule to a scored review and opens a draft plan if it
+// matches. It is run again whenever the result changes; a draft plan this review triggered is
+// cancelled if the new result no longer matches. It returns nil when no plan was opened,
+// including when the employee already has an open plan.
+func (s *improvementPlanService) EvaluateReview(reviewID uint) (*models.ImprovementPlan, error) {
+	review, err := s.reviewRepo.GetByID(reviewID)
+	if err != nil {
+		return nil, err
+	}
+	if review.TotalScore == nil || review.GradePoint == nil {
+		return nil, nil
+	}
+
+	// 1. Apply the rule to the coefficient, which reflects any calibration and normalization
+	rule := s.rule()
+	var reason, comment string
+	triggers := []models.PerformanceReview{*review}
+	switch {
+	case rule.OnUnqualified && *review.GradePoint == 0:
+		reason = "unqualified"
+		comment = fmt.Sprintf("%s 考核结果为不合格，自动创建改进计划", review.Period)
+	case rule.ConsecutiveQualifiedMonths > 0 && s.isLowResult(review):
+		streak, err := s.lowResultStreak(review, rule.ConsecutiveQualifiedMonths)
+		if err != nil {
+			return nil, err
+		}
+		if streak != nil {
+			reason = "consecutive_qualified"
+			comment = fmt.Sprintf("连续%d个月考核结果为合格及以下，自动创建改进计划", rule.ConsecutiveQualifiedMonths)
+			triggers = streak
+		}
+	}
+
+	open, err := s.repo.FindOpenByUserID(review.UserID)
+	if err == nil {
+		if reason == "" {
+			return nil, s.cancelOverturnedDraft(open.ID, review)
+		}
+		return nil, nil
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if reason == "" {
+		return nil, nil
+	}
+
+	// 2. Open a draft owned by the direct manager, or HR if there is none
+	ownerID, err := s.defaultOwnerID(&review.User)
+	if err != nil {
+		return nil, err
+	}
+	plan := &models.ImprovementPlan{
+		UserID:         review.UserID,
+		OwnerID:        ownerID,
+		Status:         "草稿",
+		TriggerReason:  reason,
+		TriggerReviews: triggers,
+	}
+	event := &models.ImprovementPlanEvent{ReviewID: &review.ID, Status: "草稿", Comment: comment}
+	if err := s.repo.Create(plan, event); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// CreatePlan lets HR open a draft plan manually.
+func (s *improvementPlanService) CreatePlan(hrID uint, input *ImprovementPlanInput) (*models.ImprovementPlan, error) {
+	var user models.User
+	if err := s.db.First(&user, input.UserID).Error; err != nil {
+		return nil, errors.New("员工不存在")
+	}
+	if _, err := s.repo.FindOpenByUserID(user.ID); err == nil {
+		return nil, errors.New("该员工已有未结束的改进计划")
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	ownerID, err := s.defaultOwnerID(&user)
+	if err != nil {
+		return nil, err
+	}
+	plan := &models.ImprovementPlan{UserID: user.ID, OwnerID: ownerID, Status: "草稿", TriggerReason: "manual"}
+	if err := s.applyInput(plan, input); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(plan, &models.ImprovementPlanEvent{ActorID: &hrID, Status: "草稿", Comment: "人事手动创建改进计划"}); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(plan.ID)
+}
+
+// ListPlans retrieves plans visible to the viewer: all for HR, otherwise those they own or are subject to.
+func (s *improvementPlanService) ListPlans(viewer *models.User, status string) ([]models.ImprovementPlan, error) {
+	if isHRUser(viewer) {
+		return s.repo.List(status, nil, nil)
+	}
+	owned, err := s.repo.List(status, &viewer.ID, nil)
+	if err != nil {
+		return nil, err
+	}
+	own, err := s.repo.List(status, nil, &viewer.ID)
+	if err != nil {
+		return nil, err
+	}
+	return append(owned, own...), nil
+}
+
+// GetPlan retrieves a plan. It is visible to HR, its owner and the employee.
+func (s *improvementPlanService) GetPlan(id uint, viewer *models.User) (*models.ImprovementPlan, error) {
+	plan, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, errors.New("改进计划不存在")
+	}
+	if !isHRUser(viewer) && plan.OwnerID != viewer.ID && plan.UserID != viewer.ID {
+		return nil, errors.New("您无权查看此改进计划")
+	}
+	return plan, nil
+}
+
+// UpdatePlan edits a draft plan's owner, dates, goals and check-in dates. Only HR and the owner can edit.
+func (s *improvementPlanService) UpdatePlan(id uint, actor *models.User, input *ImprovementPlanInput) (*models.ImprovementPlan, error) {
+	plan, err := s.getManaged(id, actor)
+	if err != nil {
+		return nil, err
+	}
+	if plan.Status != "草稿" {
+		return nil, errors.New("只有草稿状态的改进计划才能修改")
+	}
+	if err := s.applyInput(plan, input); err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdateDraft(plan); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(id)
+}
+
+// StartPlan moves a complete draft plan to 进行中.
+func (s *improvementPlanService) StartPlan(id uint, actor *models.User) error {
+	plan, err := s.getManaged(id, actor)
+	if err != nil {
+		return err
+	}
+	if plan.Status != "草稿" {
+		return errors.New("只有草稿状态的改进计划才能启动")
+	}
+	if plan.StartDate == nil || plan.EndDate == nil || len(plan.Goals) == 0 || len(plan.CheckIns) == 0 {
+		return errors.New("请先填写改进计划的起止日期、改进目标和跟进日期")
+	}
+	plan.Status = "进行中"
+	return s.repo.UpdateStatus(plan, &models.ImprovementPlanEvent{ActorID: &actor.ID, Status: plan.Status, Comment: "启动改进计划"})
+}
+
+// CompleteCheckIn records the notes of a check-in held during an active plan.
+func (s *improvementPlanService) CompleteCheckIn(id uint, checkInID uint, actor *models.User, notes string) error {
+	plan, err := s.getManaged(id, actor)
+	if err != nil {
+		return err
+	}
+	if plan.Status != "进行中" {
+		return errors.New("只有进行中的改进计划才能记录跟进")
+	}
+	for _, checkIn := range plan.CheckIns {
+		if checkIn.ID == checkInID {
+			now := time.Now()
+			checkIn.CompletedAt = &now
+			checkIn.Notes = notes
+			return s.repo.CompleteCheckIn(&checkIn)
+		}
+	}
+	return errors.New("跟进记录不存在")
+}
+
+// ClosePlan records the outcome of an active plan: 已通过 or 未通过.
+func (s *improvementPlanService) ClosePlan(id uint, actor *models.User, input *ImprovementPlanCloseInput) error {
+	plan, err := s.getManaged(id, actor)
+	if err != nil {
+		return err
+	}
+	if plan.Status != "进行中" {
+		return errors.New("只有进行中的改进计划才能结束")
+	}
+	for i := range plan.Goals {
+		if met, ok := input.GoalsMet[plan.Goals[i].ID]; ok {
+			plan.Goals[i].IsMet = &met
+		}
+	}
+	plan.Status = "未通过"
+	if input.Passed {
+		plan.Status = "已通过"
+	}
+	plan.OutcomeComment = input.Comment
+	return s.repo.UpdateStatus(plan, &models.ImprovementPlanEvent{ActorID: &actor.ID, Status: plan.Status, Comment: input.Comment})
+}
+
+// CancelPlan cancels a draft or active plan, e.g. an automatically opened draft HR decides not to pursue.
+func (s *improvementPlanService) CancelPlan(id uint, actor *models.User, comment string) error {
+	plan, err := s.getManaged(id, actor)
+	if err != nil {
+		return err
+	}
+	if plan.Status != "草稿" && plan.Status != "进行中" {
+		return errors.New("改进计划已结束")
+	}
+	plan.Status = "已取消"
+	plan.OutcomeComment = comment
+	plan.Goals = nil
+	return s.repo.UpdateStatus(plan, &models.ImprovementPlanEvent{ActorID: &actor.ID, Status: plan.Status, Comment: comment})
+}
+
+// getManaged retrieves a plan that the actor (HR or the plan's owner) may manage.
+func (s *improvementPlanService) getManaged(id uint, actor *models.User) (*models.ImprovementPlan, error) {
+	plan, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, errors.New("改进计划不存在")
+	}
+	if !isHRUser(actor) && plan.OwnerID != actor.ID {
+		return nil, errors.New("只有人事或改进计划负责人可以操作")
+	}
+	return plan, nil
+}
+
+// applyInput validates the editable fields of a draft plan and copies them onto it.
+func (s *improvementPlanService) applyInput(plan *models.ImprovementPlan, input *ImprovementPlanInput) error {
+	if input.OwnerID != 0 {
+		var owner models.User
+		if err := s.db.First(&owner, input.OwnerID).Error; err != nil {
+			return errors.New("负责人不存在")
+		}
+		if owner.ID == plan.UserID {
+			return errors.New("员工不能作为自己改进计划的负责人")
+		}
+		plan.OwnerID = owner.ID
+	}
+
+	plan.StartDate, plan.EndDate = nil, nil
+	if input.StartDate != "" || input.EndDate != "" {
+		startDate, err := time.ParseInLocation("2006-01-02", input.StartDate, time.Local)
+		if err != nil {
+			return errors.New("开始日期格式错误，应为YYYY-MM-DD")
+		}
+		endDate, err := time.ParseInLocation("2006-01-02", input.EndDate, time.Local)
+		if err != nil {
+			return errors.New("结束日期格式错误，应为YYYY-MM-DD")
+		}
+		if endDate.Before(startDate) {
+			return errors.New("结束日期不能早于开始日期")
+		}
+		plan.StartDate, plan.EndDate = &startDate, &endDate
+	}
+
+	plan.Goals = nil
+	for _, goal := range input.Goals {
+		if goal.Description == "" {
+			return errors.New("改进目标不能为空")
+		}
+		plan.Goals = append(plan.Goals, models.ImprovementGoal{Description: goal.Description, SuccessCriteria: goal.SuccessCriteria})
+	}
+	plan.CheckIns = nil
+	for _, dateStr := range input.CheckInDates {
+		date, err := time.ParseInLocation("2006-01-02", dateStr, time.Local)
+		if err != nil {
+			return errors.New("跟进日期格式错误，应为YYYY-MM-DD")
+		}
+		if plan.StartDate != nil && (date.Before(*plan.StartDate) || date.After(*plan.EndDate)) {
+			return errors.New("跟进日期必须在改进计划期间内")
+		}
+		plan.CheckIns = append(plan.CheckIns, models.ImprovementCheckIn{ScheduledDate: date})
+	}
+	return nil
+}
+
+// lowResultStreak returns the review and the months before it if the employee has been at 合格 or
+// below for the given number of consecutive months ending with the review, or nil otherwise.
+func (s *improvementPlanService) lowResultStreak(review *models.PerformanceReview, months int) ([]models.PerformanceReview, error) {
+	if !monthlyPeriodPattern.MatchString(review.Period) {
+		return nil, nil
+	}
+	reviews, err := s.reviewRepo.ListByUserIDAndStatuses(review.UserID, resultStatuses)
+	if err != nil {
+		return nil, err
+	}
+	return monthlyStreak(review, reviews, months, s.isLowResult), nil
+}
+
+// monthlyStreak returns the review and the reviews of the months before it, newest first, if all of the
+// given number of consecutive months have a review matching low, or nil otherwise. The review replaces
+// any stored copy of its own month.
+func monthlyStreak(review *models.PerformanceReview, reviews []models.PerformanceReview, months int, low func(*models.PerformanceReview) bool) []models.PerformanceReview {
+	byPeriod := make(map[string]models.PerformanceReview)
+	for _, r := range reviews {
+		byPeriod[r.Period] = r
+	}
+	byPeriod[review.Period] = *review
+
+	month, err := time.Parse("2006-01", review.Period)
+	if err != nil {
+		return nil
+	}
+	var streak []models.PerformanceReview
+	for i := 0; i < months; i++ {
+		r, ok := byPeriod[month.AddDate(0, -i, 0).Format("2006-01")]
+		if !ok || !low(&r) {
+			return nil
+		}
+		streak = append(streak, r)
+	}
+	return streak
+}
+
+// reevaluateResults re-applies the PIP trigger rule to reviews whose results changed. A failure is
+// logged and must not undo the change itself.
+func reevaluateResults(improvementPlans ImprovementPlanService, reviewIDs ...uint) {
+	for _, reviewID := range reviewIDs {
+		if _, err := improvementPlans.EvaluateReview(reviewID); err != nil {
+			log.Printf("PIP trigger check failed for review %d: %v", reviewID, err)
+		}
+	}
+}
+
+// isLowResult reports whether a result is 合格 or below: a coefficient of 0, or a 合格 band for the
+// score the coefficient was computed from (the normalized score if the run used it).
+func (s *improvementPlanService) isLowResult(review *models.PerformanceReview) bool {
+	if review.TotalScore == nil || review.GradePoint == nil {
+		return false
+	}
+	if *review.GradePoint == 0 {
+		return true
+	}
+	return gradeBand(coefficientScore(s.db, review)) == "合格"
+}
+
+// cancelOverturnedDraft cancels a draft plan that was opened automatically because of the review,
+// once the review's result no longer matches the trigger rule (for example after an appeal).
+func (s *improvementPlanService) cancelOverturnedDraft(planID uint, review *models.PerformanceReview) error {
+	plan, err := s.repo.GetByID(planID)
+	if err != nil {
+		return err
+	}
+	if plan.Status != "草稿" || plan.TriggerReason == "manual" {
+		return nil
+	}
+	triggered := false
+	for _, trigger := range plan.TriggerReviews {
+		if trigger.ID == review.ID {
+			triggered = true
+		}
+	}
+	if !triggered {
+		return nil
+	}
+	plan.Status = "已取消"
+	plan.OutcomeComment = fmt.Sprintf("%s 考核结果已变更，不再满足改进计划触发条件", review.Period)
+	plan.Goals = nil
+	return s.repo.UpdateStatus(plan, &models.ImprovementPlanEvent{ReviewID: &review.ID, Status: plan.Status, Comment: plan.OutcomeComment})
+}
+
+// defaultOwnerID returns the employee's direct manager, or an HR user if they have none.
+func (s *improvementPlanService) defaultOwnerID(user *models.User) (uint, error) {
+	if user.ManagerID != nil {
+		return *user.ManagerID, nil
+	}
+	hr, err := findHRUser(s.db)
+	if err != nil {
+		return 0, errors.New("找不到改进计划负责人")
+	}
+	return hr.ID, nil
+}
+
+// rule reads the PIP trigger rule from settings, falling back to the default.
+func (s *improvementPlanService) rule() PIPTriggerRule {
+	if setting, err := s.systemSettingService.GetSetting(SettingPIPTriggerRule); err == nil && setting.Value != "" {
+		var rule PIPTriggerRule
+		if err := json.Unmarshal([]byte(setting.Value), &rule); err == nil {
+			return rule
+		}
+	}
+	return defaultPIPTriggerRule
+}
@@ -0,0 +1,51 @@
+package services
+
+import (
+	"testing"
+
+	"cepm-backend/models"
+)
+
+func TestMonthlyStreak(t *testing.T) {
+	score := func(value float64) *float64 { return &value }
+	result := func(id uint, period string, total float64) models.PerformanceReview {
+		return models.PerformanceReview{ID: id, Period: period, TotalScore: score(total)}
+	}
+	low := func(review *models.PerformanceReview) bool { return *review.TotalScore < 80 }
+
+	history := []models.PerformanceReview{
+		result(1, "2024-11", 75),
+		result(2, "2024-12", 70),
+		result(3, "2025-01", 78),
+		result(4, "2025-02", 92),
+		result(5, "2025-04", 72),
+	}
+
+	tests := []struct {
+		name   string
+		review models.PerformanceReview
+		months int
+		want   []uint
+	}{
+		{"three low months across the year end", result(10, "2025-01", 78), 3, []uint{10, 2, 1}},
+		{"a good month breaks the streak", result(11, "2025-03", 65), 3, nil},
+		{"two low months", result(12, "2025-05", 60), 2, []uint{12, 5}},
+		{"a missing month breaks the streak", result(13, "2025-05", 60), 3, nil},
+		{"the current result replaces its stored copy", result(14, "2025-02", 79), 4, []uint{14, 3, 2, 1}},
+		{"a good current result is no streak", result(15, "2025-01", 85), 3, nil},
+		{"a single month", result(16, "2025-06", 50), 1, []uint{16}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			streak := monthlyStreak(&tt.review, history, tt.months, low)
+			if len(streak) != len(tt.want) {
+				t.Fatalf("streak = %d reviews, want %v", len(streak), tt.want)
+			}
+			for i, id := range tt.want {
+				if streak[i].ID != id {
+					t.Errorf("streak[%d] = review %d, want %d", i, streak[i].ID, id)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,289 @@
+package services
+
+import (
+	"sort"
+	"time"
+
+	"cepm-backend/models"
+	"cepm-backend/repositories"
+)
+
+// Inbox action types, in priority order.
+const (
+	InboxActionApprovePlan      = "approve_plan"
+	InboxActionScoreReview      = "score_review"
+	InboxActionSubmitEvaluation = "submit_evaluation"
+	InboxActionHandleAppeal     = "handle_appeal"
+	InboxActionHRConfirm        = "hr_confirm"
+	InboxActionPeerFeedback     = "peer_feedback"
+	InboxActionAcknowledge      = "acknowledge_result"
+	InboxActionFixRejected      = "fix_rejected"
+	InboxActionSubmitDraft      = "submit_draft"
+)
+
+var inboxActionLabels = map[string]string{
+	InboxActionApprovePlan:      "审批绩效计划",
+	InboxActionScoreReview:      "绩效打分",
+	InboxActionSubmitEvaluation: "提交考核人评分",
+	InboxActionHandleAppeal:     "处理申诉",
+	InboxActionHRConfirm:        "人事确认",
+	InboxActionPeerFeedback:     "填写同事反馈",
+	InboxActionAcknowledge:      "确认考核结果",
+	InboxActionFixRejected:      "修改被驳回的绩效",
+	InboxActionSubmitDraft:      "提交绩效草稿",
+}
+
+var inboxActionPriorities = map[string]int{
+	InboxActionApprovePlan:      1,
+	InboxActionScoreReview:      2,
+	InboxActionSubmitEvaluation: 3,
+	InboxActionHandleAppeal:     4,
+	InboxActionHRConfirm:        5,
+	InboxActionPeerFeedback:     6,
+	InboxActionAcknowledge:      7,
+	InboxActionFixRejected:      8,
+	InboxActionSubmitDraft:      9,
+}
+
+// ReviewSummary is a compact view of a performance review for lists.
+type ReviewSummary struct {
+	ID         uint     `json:"id"`
+	UserID     uint     `json:"userId"`
+	UserName   string   `json:"userName"`
+	Department string   `json:"department"`
+	Period     string   `json:"period"`
+	Status     string   `json:"status"`
+	TotalScore *float64 `json:"totalScore"`
+}
+
+// InboxItem is a single pending action for the current user.
+type InboxItem struct {
+	Action                string        `json:"action"`
+	ActionLabel           string        `json:"actionLabel"`
+	Priority              int           `json:"priority"`
+	Review                ReviewSummary `json:"review"`
+	WaitingSince          time.Time     `json:"waitingSince"`
+	WaitingDays           int           `json:"waitingDays"`
+	DueAt                 *time.Time    `json:"dueAt,omitempty"`
+	IsOverdue             bool          `json:"isOverdue"`
+	OnBehalfOfID          *uint         `json:"onBehalfOfId,omitempty"`          // Set when the action comes from a delegation or escalation
+	AppealID              *uint         `json:"appealId,omitempty"`              // Set for handle_appeal
+	PeerFeedbackRequestID *uint         `json:"peerFeedbackRequestId,omitempty"` // Set for peer_feedback
+}
+
+// InboxService defines the interface for building a user's pending action inbox.
+type InboxService interface {
+	GetInbox(user *models.User) ([]InboxItem, error)
+}
+
+type inboxService struct {
+	repo                 repositories.PerformanceReviewRepository
+	delegationRepo       repositories.DelegationRepository
+	appealRepo           repositories.AppealRepository
+	evaluatorRepo        repositories.EvaluatorRepository
+	peerFeedbackRepo     repositories.PeerFeedbackRepository
+	systemSettingService *SystemSettingService
+}
+
+// NewInboxService creates a new instance of InboxService.
+func NewInboxService(repo repositories.PerformanceReviewRepository, delegationRepo repositories.DelegationRepository, appealRepo repositories.AppealRepository, evaluatorRepo repositories.EvaluatorRepository, peerFeedbackRepo repositories.PeerFeedbackRepository, systemSettingService *SystemSettingService) InboxService {
+	return &inboxService{repo: repo, delegationRepo: delegationRepo, appealRepo: appealRepo, evaluatorRepo: evaluatorRepo, peerFeedbackRepo: peerFeedbackRepo, systemSettingService: systemSettingService}
+}
+
+// GetInbox returns the current user's pending actions, overdue ones first, then by action priority and waiting time.
+func (s *inboxService) GetInbox(user *models.User) ([]InboxItem, error) {
+	now := time.Now()
+	items := []InboxItem{}
+	seen := make(map[uint]bool)
+	add := func(review *models.PerformanceReview, action string, onBehalfOfID *uint) {
+		if seen[review.ID] {
+			return
+		}
+		seen[review.ID] = true
+		items = append(items, s.newInboxItem(review, action, onBehalfOfID, statusEnteredAt(review), now))
+	}
+	managerActions := map[string]string{"待审批": InboxActionApprovePlan, "待打分": InboxActionScoreReview}
+	managerStatuses := []string{"待审批", "待打分"}
+
+	// 1. Reviews of the user's direct reports
+	reviews, err := s.repo.ListByManagerIDsAndStatuses([]uint{user.ID}, managerStatuses)
+	if err == nil {
+		reviews, err = s.withoutMultiRaterScoring(reviews)
+	}
+	if err != nil {
+		return nil, err
+	}
+	for i := range reviews {
+		add(&reviews[i], managerActions[reviews[i].Status], nil)
+	}
+
+	// 2. Reviews the user can act on through active delegations
+	delegations, err := s.delegationRepo.FindActiveByDelegateID(user.ID, now)
+	if err != nil {
+		return nil, err
+	}
+	for _, delegation := range delegations {
+		reviews, err := s.repo.ListByManagerIDsAndStatuses([]uint{delegation.DelegatorID}, managerStatuses)
+		if err == nil {
+			reviews, err = s.withoutMultiRaterScoring(reviews)
+		}
+		if err != nil {
+			return nil, err
+		}
+		for i := range reviews {
+			if delegationCovers(delegation, &reviews[i].User) {
+				add(&reviews[i], managerActions[reviews[i].Status], reviews[i].User.ManagerID)
+			}
+		}
+	}
+
+	// 3. Reviews escalated to the user by the SLA scheduler
+	reviews, err = s.repo.ListEscalatedTo(user.ID, managerStatuses)
+	if err == nil {
+		reviews, err = s.withoutMultiRaterScoring(reviews)
+	}
+	if err != nil {
+		return nil, err
+	}
+	for i := range reviews {
+		if escalatedTo(&reviews[i], user.ID) {
+			add(&reviews[i], managerActions[reviews[i].Status], reviews[i].User.ManagerID)
+		}
+	}
+
+	// 4. Multi-rater reviews awaiting the user's scores as an evaluator
+	evaluations, err := s.evaluatorRepo.ListPendingByEvaluatorID(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range evaluations {
+		if evaluations[i].Review == nil || seen[evaluations[i].ReviewID] {
+			continue
+		}
+		seen[evaluations[i].ReviewID] = true
+		items = append(items, s.newInboxItem(evaluations[i].Review, InboxActionSubmitEvaluation, nil, evaluations[i].CreatedAt, now))
+	}
+
+	// 5. Appeals routed to the user
+	appeals, err := s.appealRepo.ListPendingByHandlerID(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range appeals {
+		if seen[appeals[i].ReviewID] {
+			continue
+		}
+		seen[appeals[i].ReviewID] = true
+		item := s.newInboxItem(&appeals[i].Review, InboxActionHandleAppeal, nil, appeals[i].CreatedAt, now)
+		item.AppealID = &appeals[i].ID
+		items = append(items, item)
+	}
+
+	// 6. Peer feedback requested from the user; these are separate tasks even if the user also acts on the review
+	requests, err := s.peerFeedbackRepo.ListPendingByPeerID(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range requests {
+		if requests[i].Review == nil || requests[i].Review.Status != "待打分" {
+			continue // Feedback can only be given while the review awaits scoring
+		}
+		item := s.newInboxItem(requests[i].Review, InboxActionPeerFeedback, nil, requests[i].CreatedAt, now)
+		item.PeerFeedbackRequestID = &requests[i].ID
+		items = append(items, item)
+	}
+
+	// 7. HR confirmations
+	if isHRUser(user) {
+		reviews, err := s.repo.ListByStatuses([]string{"待人事确认"})
+		if err != nil {
+			return nil, err
+		}
+		for i := range reviews {
+			add(&reviews[i], InboxActionHRConfirm, nil)
+		}
+	}
+
+	// 8. The user's own scored results to acknowledge, rejected reviews and unsubmitted drafts
+	ownActions := map[string]string{"已完成": InboxActionAcknowledge, "已驳回": InboxActionFixRejected, "草稿": InboxActionSubmitDraft}
+	reviews, err = s.repo.ListByUserIDAndStatuses(user.ID, []string{"已完成", "已驳回", "草稿"})
+	if err != nil {
+		return nil, err
+	}
+	for i := range reviews {
+		if reviews[i].Status == "已完成" && reviews[i].CalibrationSessionID != nil {
+			continue // Locked by HR calibration until the session closes
+		}
+		add(&reviews[i], ownActions[reviews[i].Status], nil)
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].IsOverdue != items[j].IsOverdue {
+			return items[i].IsOverdue
+		}
+		if items[i].Priority != items[j].Priority {
+			return items[i].Priority < items[j].Priority
+		}
+		return items[i].WaitingSince.Before(items[j].WaitingSince)
+	})
+	return items, nil
+}
+
+// withoutMultiRaterScoring drops the reviews awaiting scoring that have evaluators assigned; those are
+// scored by each evaluator instead of the manager.
+func (s *inboxService) withoutMultiRaterScoring(reviews []models.PerformanceReview) ([]models.PerformanceReview, error) {
+	var reviewIDs []uint
+	for _, review := range reviews {
+		if review.Status == "待打分" {
+			reviewIDs = append(reviewIDs, review.ID)
+		}
+	}
+	multiRater, err := s.evaluatorRepo.ReviewIDsWithEvaluators(reviewIDs)
+	if err != nil {
+		return nil, err
+	}
+	kept := reviews[:0]
+	for _, review := range reviews {
+		if !multiRater[review.ID] {
+			kept = append(kept, review)
+		}
+	}
+	return kept, nil
+}
+
+// newInboxItem builds an inbox entry, using the escalation SLA of the review's status as its deadline,
+// or the auto-acknowledge timeout for acknowledging a result.
+func (s *inboxService) newInboxItem(review *models.PerformanceReview, action string, onBehalfOfID *uint, waitingSince time.Time, now time.Time) InboxItem {
+	item := InboxItem{
+		Action:       action,
+		ActionLabel:  inboxActionLabels[action],
+		Priority:     inboxActionPriorities[action],
+		Review:       newReviewSummary(review),
+		WaitingSince: waitingSince,
+		WaitingDays:  int(now.Sub(waitingSince).Hours() / 24),
+		OnBehalfOfID: onBehalfOfID,
+	}
+	if action == InboxActionAcknowledge {
+		dueAt := waitingSince.AddDate(0, 0, settingInt(s.systemSettingService, SettingAutoAcknowledgeDays, 7))
+		item.DueAt = &dueAt
+		item.IsOverdue = now.After(dueAt)
+	} else if rule, ok := slaRuleFor(review.Status); ok {
+		dueAt := waitingSince.AddDate(0, 0, settingInt(s.systemSettingService, rule.escalateKey, rule.defaultEscalateDays))
+		item.DueAt = &dueAt
+		item.IsOverdue = now.After(dueAt)
+	}
+	return item
+}
+
+// newReviewSummary builds a ReviewSummary. The review's User.Department should be preloaded.
+func newReviewSummary(review *models.PerformanceReview) ReviewSummary {
+	return ReviewSummary{
+		ID:         review.ID,
+		UserID:     review.UserID,
+		UserName:   review.User.Name,
+		Department: review.User.Department.Name,
+		Period:     review.Period,
+		Status:     review.Status,
+		TotalScore: review.TotalScore,
+	}
+}
@@ -0,0 +1,394 @@
+package services
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"cepm-backend/database"
+	"cepm-backend/models"
+	"cepm-backend/repositories"
+
+	"gorm.io/gorm"
+)
+
+// llmCategory is the PerformanceItem category that LLM cases are attached to.
+const llmCategory = "大模型"
+
+// caseEditableStatuses are the review statuses in which the employee can still add or change cases.
+var caseEditableStatuses = []string{"草稿", "已驳回", "待打分"}
+
+// caseLibraryStatuses are the review statuses in which a case is published to the case library:
+// the review has been approved by the manager or has progressed further.
+var caseLibraryStatuses = []string{"待打分", "已完成", "待人事确认", "申诉中", "已归档"}
+
+// LLMCaseInput defines the structure for recording a 大模型 usage case.
+type LLMCaseInput struct {
+	Scenario       string  `json:"scenario"`
+	Tool           string  `json:"tool"`
+	TimeSavedHours float64 `json:"timeSavedHours"`
+	Outcome        string  `json:"outcome"`
+	Reflection     string  `json:"reflection"`
+}
+
+// LLMCaseView is a case with whether the viewer has liked it.
+type LLMCaseView struct {
+	models.LLMCase
+	LikedByMe bool `json:"likedByMe"`
+}
+
+// LLMCasePage is a page of the case library.
+type LLMCasePage struct {
+	Total int64         `json:"total"`
+	Cases []LLMCaseView `json:"cases"`
+}
+
+// ToolUsage is the number of cases recorded with a tool.
+type ToolUsage struct {
+	Tool      string `json:"tool"`
+	CaseCount int    `json:"caseCount"`
+}
+
+// DepartmentAdoption summarizes a department's LLM adoption.
+type DepartmentAdoption struct {
+	DepartmentID   uint        `json:"departmentId"`
+	DepartmentName string      `json:"departmentName"`
+	Headcount      int         `json:"headcount"`
+	Adopters       int         `json:"adopters"`     // Employees with at least one case
+	AdoptionRate   float64     `json:"adoptionRate"` // Percent of headcount
+	CaseCount      int         `json:"caseCount"`
+	TimeSavedHours float64     `json:"timeSavedHours"`
+	TotalLikes     int         `json:"totalLikes"`
+	TopTools       []ToolUsage `json:"topTools"`
+}
+
+// LLMAdoptionReport summarizes LLM adoption per department and tool.
+type LLMAdoptionReport struct {
+	Period         string               `json:"period"`
+	CaseCount      int                  `json:"caseCount"`
+	Adopters       int                  `json:"adopters"`
+	TimeSavedHours float64              `json:"timeSavedHours"`
+	Tools          []ToolUsage          `json:"tools"`
+	Departments    []DepartmentAdoption `json:"departments"`
+}
+
+// LLMCaseService defines the interface for 大模型 usage cases and the company-wide case library.
+type LLMCaseService interface {
+	CreateCase(reviewID uint, itemID uint, userID uint, input *LLMCaseInput) (*models.LLMCase, error)
+	UpdateCase(id uint, userID uint, input *LLMCaseInput) (*models.LLMCase, error)
+	DeleteCase(id uint, userID uint) error
+	ListReviewCases(reviewID uint, viewer *models.User) ([]models.LLMCase, error)
+	GetCase(id uint, viewer *models.User) (*LLMCaseView, error)
+	SearchCases(filter *repositories.LLMCaseFilter, viewer *models.User) (*LLMCasePage, error)
+	LikeCase(id uint, userID uint) error
+	UnlikeCase(id uint, userID uint) error
+	GetAdoptionReport(period string) (*LLMAdoptionReport, error)
+}
+
+type llmCaseService struct {
+	repo       repositories.LLMCaseRepository
+	reviewRepo repositories.PerformanceReviewRepository
+	db         *gorm.DB
+}
+
+// NewLLMCaseService creates a new instance of LLMCaseService.
+func NewLLMCaseService(repo repositories.LLMCaseRepository, reviewRepo repositories.PerformanceReviewRepository) LLMCaseService {
+	return &llmCaseService{repo: repo, reviewRepo: reviewRepo, db: database.DB}
+}
+
+// CreateCase attaches a case to the employee's own 大模型 item.
+func (s *llmCaseService) CreateCase(reviewID uint, itemID uint, userID uint, input *LLMCaseInput) (*models.LLMCase, error) {
+	review, err := s.reviewRepo.GetByID(reviewID)
+	if err != nil {
+		return nil, errors.New("绩效评估不存在")
+	}
+	if err := checkCaseEditable(review, userID); err != nil {
+		return nil, err
+	}
+	var item *models.PerformanceItem
+	for i := range review.Items {
+		if review.Items[i].ID == itemID {
+			item = &review.Items[i]
+		}
+	}
+	if item == nil {
+		return nil, errors.New("无效的绩效项ID")
+	}
+	if item.Category != llmCategory {
+		return nil, errors.New("案例只能关联到大模型类别的绩效项")
+	}
+	if err := validateLLMCase(input); err != nil {
+		return nil, err
+	}
+
+	llmCase := &models.LLMCase{
+		ReviewID:       reviewID,
+		ItemID:         itemID,
+		AuthorID:       userID,
+		DepartmentID:   review.User.DepartmentID,
+		Period:         review.Period,
+		Scenario:       strings.TrimSpace(input.Scenario),
+		Tool:           strings.TrimSpace(input.Tool),
+		TimeSavedHours: round2(input.TimeSavedHours),
+		Outcome:        input.Outcome,
+		Reflection:     input.Reflection,
+	}
+	if err := s.repo.Create(llmCase); err != nil {
+		return nil, err
+	}
+	return llmCase, nil
+}
+
+// UpdateCase lets the author change a case while the review has not been scored.
+func (s *llmCaseService) UpdateCase(id uint, userID uint, input *LLMCaseInput) (*models.LLMCase, error) {
+	llmCase, err := s.editableCase(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateLLMCase(input); err != nil {
+		return nil, err
+	}
+
+	llmCase.Scenario = strings.TrimSpace(input.Scenario)
+	llmCase.Tool = strings.TrimSpace(input.Tool)
+	llmCase.TimeSavedHours = round2(input.TimeSavedHours)
+	llmCase.Outcome = input.Outcome
+	llmCase.Reflection = input.Reflection
+	if err := s.repo.Update(llmCase); err != nil {
+		return nil, err
+	}
+	return llmCase, nil
+}
+
+// DeleteCase lets the author delete a case while the review has not been scored.
+func (s *llmCaseService) DeleteCase(id uint, userID uint) error {
+	if _, err := s.editableCase(id, userID); err != nil {
+		return err
+	}
+	return s.repo.Delete(id)
+}
+
+// ListReviewCases retrieves the cases attached to a review for the employee, their direct manager and HR.
+func (s *llmCaseService) ListReviewCases(reviewID uint, viewer *models.User) ([]models.LLMCase, error) {
+	review, err := s.reviewRepo.GetByID(reviewID)
+	if err != nil {
+		return nil, errors.New("绩效评估不存在")
+	}
+	if !canFollowReview(review, viewer) {
+		return nil, errors.New("您无权查看此绩效评估的案例")
+	}
+	return s.repo.ListByReviewID(reviewID)
+}
+
+// GetCase retrieves a single case from the library. Cases of reviews that have not been approved yet are
+// only visible to those who can follow the review.
+func (s *llmCaseService) GetCase(id uint, viewer *models.User) (*LLMCaseView, error) {
+	llmCase, err := s.visibleCase(id, viewer)
+	if err != nil {
+		return nil, err
+	}
+	liked, err := s.repo.LikedCaseIDs(viewer.ID, []uint{id})
+	if err != nil {
+		return nil, err
+	}
+	return &LLMCaseView{LLMCase: *llmCase, LikedByMe: liked[id]}, nil
+}
+
+// SearchCases searches the company-wide case library, which only holds cases of approved reviews.
+func (s *llmCaseService) SearchCases(filter *repositories.LLMCaseFilter, viewer *models.User) (*LLMCasePage, error) {
+	filter.Keyword = strings.TrimSpace(filter.Keyword)
+	filter.ReviewStatuses = caseLibraryStatuses
+	if filter.Limit <= 0 || filter.Limit > 100 {
+		filter.Limit = 20
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+	cases, total, err := s.repo.Search(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	caseIDs := make([]uint, 0, len(cases))
+	for _, llmCase := range cases {
+		caseIDs = append(caseIDs, llmCase.ID)
+	}
+	liked, err := s.repo.LikedCaseIDs(viewer.ID, caseIDs)
+	if err != nil {
+		return nil, err
+	}
+	views := make([]LLMCaseView, 0, len(cases))
+	for _, llmCase := range cases {
+		views = append(views, LLMCaseView{LLMCase: llmCase, LikedByMe: liked[llmCase.ID]})
+	}
+	return &LLMCasePage{Total: total, Cases: views}, nil
+}
+
+// LikeCase records the user's like on a case in the library.
+func (s *llmCaseService) LikeCase(id uint, userID uint) error {
+	llmCase, err := s.repo.GetByID(id)
+	if err != nil {
+		return errors.New("案例不存在")
+	}
+	review, err := s.reviewRepo.GetByID(llmCase.ReviewID)
+	if err != nil || !containsString(caseLibraryStatuses, review.Status) {
+		return errors.New("案例不存在")
+	}
+	if llmCase.AuthorID == userID {
+		return errors.New("不能给自己的案例点赞")
+	}
+	return s.repo.Like(id, userID)
+}
+
+// visibleCase loads a case the viewer may see: any case in the library, or a case of a review they can follow.
+func (s *llmCaseService) visibleCase(id uint, viewer *models.User) (*models.LLMCase, error) {
+	llmCase, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, errors.New("案例不存在")
+	}
+	review, err := s.reviewRepo.GetByID(llmCase.ReviewID)
+	if err != nil {
+		return nil, errors.New("案例不存在")
+	}
+	if !containsString(caseLibraryStatuses, review.Status) && !canFollowReview(review, viewer) {
+		return nil, errors.New("案例不存在")
+	}
+	return llmCase, nil
+}
+
+// UnlikeCase removes the user's like on a case.
+func (s *llmCaseService) UnlikeCase(id uint, userID uint) error {
+	if _, err := s.repo.GetByID(id); err != nil {
+		return errors.New("案例不存在")
+	}
+	return s.repo.Unlike(id, userID)
+}
+
+// GetAdoptionReport summarizes LLM adoption per department for a period, or across all periods when it is empty.
+// Only cases published to the case library are counted, by the authors' departments when the cases were recorded.
+func (s *llmCaseService) GetAdoptionReport(period string) (*LLMAdoptionReport, error) {
+	var headcounts []struct {
+		DepartmentID uint
+		Count        int
+	}
+	if err := s.db.Model(&models.User{}).Select("department_id, count(*) as count").
+		Where("department_id IS NOT NULL AND is_active = ?", true).Group("department_id").Scan(&headcounts).Error; err != nil {
+		return nil, err
+	}
+	var departments []models.Department
+	if err := s.db.Order("id").Find(&departments).Error; err != nil {
+		return nil, err
+	}
+	cases, err := s.repo.ListByPeriod(period, caseLibraryStatuses)
+	if err != nil {
+		return nil, err
+	}
+
+	headcountMap := make(map[uint]int)
+	for _, headcount := range headcounts {
+		headcountMap[headcount.DepartmentID] = headcount.Count
+	}
+	byDepartment := make(map[uint][]models.LLMCase)
+	for _, llmCase := range cases {
+		if llmCase.DepartmentID != nil {
+			byDepartment[*llmCase.DepartmentID] = append(byDepartment[*llmCase.DepartmentID], llmCase)
+		}
+	}
+
+	report := &LLMAdoptionReport{Period: period, CaseCount: len(cases), Tools: toolUsage(cases)}
+	adopters := make(map[uint]bool)
+	for _, llmCase := range cases {
+		adopters[llmCase.AuthorID] = true
+		report.TimeSavedHours += llmCase.TimeSavedHours
+	}
+	report.Adopters = len(adopters)
+	report.TimeSavedHours = round2(report.TimeSavedHours)
+
+	for _, department := range departments {
+		departmentCases := byDepartment[department.ID]
+		stat := DepartmentAdoption{
+			DepartmentID:   department.ID,
+			DepartmentName: department.Name,
+			Headcount:      headcountMap[department.ID],
+			CaseCount:      len(departmentCases),
+			TopTools:       toolUsage(departmentCases),
+		}
+		if stat.Headcount == 0 && stat.CaseCount == 0 {
+			continue
+		}
+		departmentAdopters := make(map[uint]bool)
+		for _, llmCase := range departmentCases {
+			departmentAdopters[llmCase.AuthorID] = true
+			stat.TimeSavedHours += llmCase.TimeSavedHours
+			stat.TotalLikes += llmCase.LikeCount
+		}
+		stat.Adopters = len(departmentAdopters)
+		stat.TimeSavedHours = round2(stat.TimeSavedHours)
+		if stat.Headcount > 0 {
+			stat.AdoptionRate = round2(float64(stat.Adopters) * 100 / float64(stat.Headcount))
+		}
+		if len(stat.TopTools) > 3 {
+			stat.TopTools = stat.TopTools[:3]
+		}
+		report.Departments = append(report.Departments, stat)
+	}
+	return report, nil
+}
+
+// editableCase returns a case the user can still change.
+func (s *llmCaseService) editableCase(id uint, userID uint) (*models.LLMCase, error) {
+	llmCase, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, errors.New("案例不存在")
+	}
+	review, err := s.reviewRepo.GetByID(llmCase.ReviewID)
+	if err != nil {
+		return nil, errors.New("绩效评估不存在")
+	}
+	if err := checkCaseEditable(review, userID); err != nil {
+		return nil, err
+	}
+	return llmCase, nil
+}
+
+// checkCaseEditable checks that the user owns the review and that it has not been scored yet.
+func checkCaseEditable(review *models.PerformanceReview, userID uint) error {
+	if review.UserID != userID {
+		return errors.New("只能维护自己的大模型案例")
+	}
+	for _, status := range caseEditableStatuses {
+		if review.Status == status {
+			return nil
+		}
+	}
+	return errors.New("绩效评估已进入打分流程，不能再修改案例")
+}
+
+func validateLLMCase(input *LLMCaseInput) error {
+	if strings.TrimSpace(input.Scenario) == "" || strings.TrimSpace(input.Tool) == "" {
+		return errors.New("应用场景和使用工具均不能为空")
+	}
+	if input.TimeSavedHours < 0 {
+		return errors.New("节省时间不能为负数")
+	}
+	return nil
+}
+
+// toolUsage counts cases per tool, most used first.
+func toolUsage(cases []models.LLMCase) []ToolUsage {
+	counts := make(map[string]int)
+	for _, llmCase := range cases {
+		counts[llmCase.Tool]++
+	}
+	usage := make([]ToolUsage, 0, len(counts))
+	for tool, count := range counts {
+		usage = append(usage, ToolUsage{Tool: tool, CaseCount: count})
+	}
+	sort.Slice(usage, func(i, j int) bool {
+		if usage[i].CaseCount != usage[j].CaseCount {
+			return usage[i].CaseCount > usage[j].CaseCount
+		}
+		return usage[i].Tool < usage[j].Tool
+	})
+	return usage
+}
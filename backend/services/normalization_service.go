@@ -0,0 +1,324 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"sort"
+
+	"cepm-backend/models"
+	"cepm-backend/repositories"
+
+	"gorm.io/gorm"
+)
+
+// SystemSetting keys for cross-department normalization.
+const (
+	// SettingCoefficientScoreSource decides which score the coefficient n is computed from: "raw" (default) or "normalized".
+	SettingCoefficientScoreSource = "coefficient_score_source"
+	// SettingNormalizationMinGroupSize is the smallest department that is normalized; smaller ones keep their raw scores.
+	SettingNormalizationMinGroupSize = "normalization_min_group_size"
+)
+
+// NormalizationInput defines the structure for previewing or applying a normalization.
+type NormalizationInput struct {
+	Period string `json:"period"`
+	Method string `json:"method"` // zscore, percentile
+}
+
+// NormalizedReview is the raw and normalized score of one review.
+type NormalizedReview struct {
+	ReviewSummary
+	DepartmentID    *uint   `json:"departmentId"`
+	RawScore        float64 `json:"rawScore"`
+	NormalizedScore float64 `json:"normalizedScore"`
+	RawBand         string  `json:"rawBand"`
+	NormalizedBand  string  `json:"normalizedBand"`
+}
+
+// DepartmentNormalizationStats compares a department's raw and normalized score statistics.
+type DepartmentNormalizationStats struct {
+	DepartmentID   *uint      `json:"departmentId"`
+	DepartmentName string     `json:"departmentName"`
+	Raw            ScoreStats `json:"raw"`
+	Normalized     ScoreStats `json:"normalized"`
+	Skipped        bool       `json:"skipped"` // True if the department was too small to normalize
+}
+
+// NormalizationPreview is the result of a normalization before (or as) it is applied.
+type NormalizationPreview struct {
+	Period      string                         `json:"period"`
+	Method      string                         `json:"method"`
+	ScoreSource string                         `json:"scoreSource"`
+	Company     ScoreStats                     `json:"company"`
+	Departments []DepartmentNormalizationStats `json:"departments"`
+	Reviews     []NormalizedReview             `json:"reviews"`
+}
+
+// NormalizationService defines the interface for cross-department score normalization.
+type NormalizationService interface {
+	Preview(input *NormalizationInput) (*NormalizationPreview, error)
+	Apply(input *NormalizationInput, hrID uint) (*models.NormalizationRun, error)
+	ListRuns(period string) ([]models.NormalizationRun, error)
+}
+
+type normalizationService struct {
+	repo                 repositories.NormalizationRepository
+	reviewRepo           repositories.PerformanceReviewRepository
+	improvementPlans     ImprovementPlanService
+	systemSettingService *SystemSettingService
+}
+
+// NewNormalizationService creates a new instance of NormalizationService.
+func NewNormalizationService(repo repositories.NormalizationRepository, reviewRepo repositories.PerformanceReviewRepository, improvementPlans ImprovementPlanService, systemSettingService *SystemSettingService) NormalizationService {
+	return &normalizationService{repo: repo, reviewRepo: reviewRepo, improvementPlans: improvementPlans, systemSettingService: systemSettingService}
+}
+
+// Preview computes normalized scores for a period without saving them.
+func (s *normalizationService) Preview(input *NormalizationInput) (*NormalizationPreview, error) {
+	preview, _, _, err := s.compute(input)
+	return preview, err
+}
+
+// Apply computes and stores normalized scores for a period next to the raw scores.
+// If the coefficient rule uses normalized scores, the grade points are recomputed from them.
+// Only results that are not yet archived or under appeal are normalized.
+func (s *normalizationService) Apply(input *NormalizationInput, hrID uint) (*models.NormalizationRun, error) {
+	preview, reviews, model, err := s.compute(input)
+	if err != nil {
+		return nil, err
+	}
+	for i := range reviews {
+		if err := ensureNotCalibrating(&reviews[i]); err != nil {
+			return nil, errors.New("该周期有绩效评估正在人事校准中，请先结束校准会话")
+		}
+	}
+
+	reviewUpdates := make(map[uint]map[string]interface{})
+	for _, result := range preview.Reviews {
+		updates := map[string]interface{}{"normalized_score": result.NormalizedScore}
+		if preview.ScoreSource == "normalized" {
+			updates["grade_point"] = calculateGradePoint(result.NormalizedScore)
+		}
+		reviewUpdates[result.ID] = updates
+	}
+
+	parameters, err := json.Marshal(model)
+	if err != nil {
+		return nil, err
+	}
+	run := &models.NormalizationRun{
+		Period:      preview.Period,
+		Method:      preview.Method,
+		ScoreSource: preview.ScoreSource,
+		ReviewCount: len(preview.Reviews),
+		Parameters:  string(parameters),
+		AppliedByID: hrID,
+	}
+	if err := s.repo.ApplyRun(run, reviewUpdates); err != nil {
+		return nil, err
+	}
+	// The coefficients only change if they are computed from the normalized scores
+	if run.ScoreSource == "normalized" {
+		for reviewID := range reviewUpdates {
+			reevaluateResults(s.improvementPlans, reviewID)
+		}
+	}
+	return run, nil
+}
+
+// ListRuns retrieves the normalization runs, optionally filtered by period.
+func (s *normalizationService) ListRuns(period string) ([]models.NormalizationRun, error) {
+	return s.repo.ListByPeriod(period)
+}
+
+// normalizableStatuses are the statuses of scored reviews whose results can still be normalized.
+var normalizableStatuses = []string{"已完成", "待人事确认"}
+
+// normalizationModel holds the distributions a run mapped scores with. It is stored on the run,
+// so a review whose score is recomputed later is normalized the same way as the rest of its period.
+type normalizationModel struct {
+	Method        string                           `json:"method"`
+	Company       ScoreStats                       `json:"company"`
+	CompanyScores []float64                        `json:"companyScores"` // Sorted ascending
+	Departments   map[uint]normalizationGroupModel `json:"departments"`   // Keyed by department ID; 0 is reviews without a department
+}
+
+// normalizationGroupModel is the raw score distribution of one department.
+type normalizationGroupModel struct {
+	Stats   ScoreStats `json:"stats"`
+	Scores  []float64  `json:"scores"`
+	Skipped bool       `json:"skipped"` // True if the department was too small to normalize
+}
+
+// normalize maps a raw score of a department onto the company-wide distribution.
+// Departments the run skipped or did not know keep their raw scores.
+func (m *normalizationModel) normalize(departmentID uint, rawScore float64) float64 {
+	group, ok := m.Departments[departmentID]
+	normalized := rawScore
+	switch {
+	case !ok || group.Skipped:
+	case m.Method == "zscore":
+		normalized = m.Company.Mean + zScore(rawScore, group.Stats)*m.Company.StdDev
+	default:
+		normalized = quantile(m.CompanyScores, percentileRank(group.Scores, rawScore))
+	}
+	return math.Round(math.Max(0, math.Min(120, normalized))*100) / 100
+}
+
+// compute maps each department's scores onto the company-wide distribution of the period.
+func (s *normalizationService) compute(input *NormalizationInput) (*NormalizationPreview, []models.PerformanceReview, *normalizationModel, error) {
+	if input.Period == "" {
+		return nil, nil, nil, errors.New("标准化周期不能为空")
+	}
+	if input.Method != "zscore" && input.Method != "percentile" {
+		return nil, nil, nil, errors.New("标准化方法必须是 zscore 或 percentile")
+	}
+
+	allReviews, err := s.reviewRepo.ListScoredReviews(input.Period)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	// Appealed and archived results are final for now and are left out
+	var reviews []models.PerformanceReview
+	for _, review := range allReviews {
+		if review.TotalScore != nil && containsString(normalizableStatuses, review.Status) {
+			reviews = append(reviews, review)
+		}
+	}
+	if len(reviews) == 0 {
+		return nil, nil, nil, errors.New("该周期没有可标准化的绩效评估")
+	}
+
+	// 1. Group the raw scores by department; reviews without a department form their own group
+	companyScores := make([]float64, 0, len(reviews))
+	groups := make(map[uint][]int)
+	var groupOrder []uint
+	for i := range reviews {
+		companyScores = append(companyScores, *reviews[i].TotalScore)
+		departmentID := reviewDepartmentID(&reviews[i])
+		if _, ok := groups[departmentID]; !ok {
+			groupOrder = append(groupOrder, departmentID)
+		}
+		groups[departmentID] = append(groups[departmentID], i)
+	}
+	sort.Float64s(companyScores)
+	model := &normalizationModel{
+		Method:        input.Method,
+		Company:       scoreStats(companyScores),
+		CompanyScores: companyScores,
+		Departments:   make(map[uint]normalizationGroupModel),
+	}
+	minGroupSize := settingInt(s.systemSettingService, SettingNormalizationMinGroupSize, 3)
+
+	scoreSource := "raw"
+	if setting, err := s.systemSettingService.GetSetting(SettingCoefficientScoreSource); err == nil && setting.Value == "normalized" {
+		scoreSource = "normalized"
+	}
+	preview := &NormalizationPreview{Period: input.Period, Method: input.Method, ScoreSource: scoreSource, Company: model.Company}
+
+	// 2. Normalize each department
+	for _, departmentID := range groupOrder {
+		indexes := groups[departmentID]
+		rawScores := make([]float64, len(indexes))
+		for j, index := range indexes {
+			rawScores[j] = *reviews[index].TotalScore
+		}
+		model.Departments[departmentID] = normalizationGroupModel{
+			Stats:   scoreStats(rawScores),
+			Scores:  rawScores,
+			Skipped: len(indexes) < minGroupSize || departmentID == 0,
+		}
+
+		normalizedScores := make([]float64, len(indexes))
+		for j, rawScore := range rawScores {
+			normalizedScores[j] = model.normalize(departmentID, rawScore)
+		}
+
+		first := &reviews[indexes[0]]
+		preview.Departments = append(preview.Departments, DepartmentNormalizationStats{
+			DepartmentID:   first.User.DepartmentID,
+			DepartmentName: first.User.Department.Name,
+			Raw:            model.Departments[departmentID].Stats,
+			Normalized:     scoreStats(normalizedScores),
+			Skipped:        model.Departments[departmentID].Skipped,
+		})
+		for j, index := range indexes {
+			review := &reviews[index]
+			preview.Reviews = append(preview.Reviews, NormalizedReview{
+				ReviewSummary:   newReviewSummary(review),
+				DepartmentID:    review.User.DepartmentID,
+				RawScore:        rawScores[j],
+				NormalizedScore: normalizedScores[j],
+				RawBand:         gradeBand(rawScores[j]),
+				NormalizedBand:  gradeBand(normalizedScores[j]),
+			})
+		}
+	}
+	return preview, reviews, model, nil
+}
+
+// reviewDepartmentID returns the department a review is normalized with, or 0 if its owner has none.
+func reviewDepartmentID(review *models.PerformanceReview) uint {
+	if review.User.DepartmentID == nil {
+		return 0
+	}
+	return *review.User.DepartmentID
+}
+
+// renormalize re-applies a review's normalization run to a recomputed raw score; the review's User
+// must be loaded. It returns the
+// new normalized score, and the grade point if the run computed coefficients from normalized scores.
+func renormalize(db *gorm.DB, review *models.PerformanceReview, rawScore float64) (float64, *float64, error) {
+	var run models.NormalizationRun
+	if err := db.First(&run, *review.NormalizationRunID).Error; err != nil {
+		return 0, nil, err
+	}
+	var model normalizationModel
+	if err := json.Unmarshal([]byte(run.Parameters), &model); err != nil {
+		return 0, nil, errors.New("标准化记录缺少映射参数，请重新执行该周期的标准化")
+	}
+	normalized := model.normalize(reviewDepartmentID(review), rawScore)
+	if run.ScoreSource != "normalized" {
+		return normalized, nil, nil
+	}
+	gradePoint := calculateGradePoint(normalized)
+	return normalized, &gradePoint, nil
+}
+
+// coefficientScore returns the score a review's coefficient n was computed from: the normalized score
+// when its normalization run used it, otherwise the total score. The review must have a total score.
+func coefficientScore(db *gorm.DB, review *models.PerformanceReview) float64 {
+	score := *review.TotalScore
+	if review.NormalizationRunID != nil && review.NormalizedScore != nil {
+		var run models.NormalizationRun
+		if err := db.First(&run, *review.NormalizationRunID).Error; err == nil && run.ScoreSource == "normalized" {
+			score = *review.NormalizedScore
+		}
+	}
+	return score
+}
+
+// percentileRank returns the mid-rank percentile (0..1) of value within scores; ties share a rank.
+func percentileRank(scores []float64, value float64) float64 {
+	below, equal := 0, 0
+	for _, score := range scores {
+		if score < value {
+			below++
+		} else if score == value {
+			equal++
+		}
+	}
+	return (float64(below) + float64(equal)/2) / float64(len(scores))
+}
+
+// quantile returns the value at percentile p (0..1) of sorted scores, interpolating linearly.
+func quantile(sorted []float64, p float64) float64 {
+	if len(sorted) == 1 {
+		return sorted[0]
+	}
+	position := p * float64(len(sorted)-1)
+	lower := int(math.Floor(position))
+	upper := int(math.Ceil(position))
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(position-float64(lower))
+}
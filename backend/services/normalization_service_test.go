@@ -0,0 +1,54 @@
+package services
+
+import "testing"
+
+func TestNormalizationModelNormalize(t *testing.T) {
+	zscore := &normalizationModel{
+		Method:  "zscore",
+		Company: ScoreStats{Count: 20, Mean: 90, StdDev: 10},
+		Departments: map[uint]normalizationGroupModel{
+			1: {Stats: ScoreStats{Count: 5, Mean: 80, StdDev: 5}},
+			2: {Stats: ScoreStats{Count: 5, Mean: 95, StdDev: 0}},
+			3: {Stats: ScoreStats{Count: 2, Mean: 70, StdDev: 3}, Skipped: true},
+		},
+	}
+	percentile := &normalizationModel{
+		Method:        "percentile",
+		CompanyScores: []float64{60, 70, 80, 90, 100},
+		Departments: map[uint]normalizationGroupModel{
+			1: {Scores: []float64{50, 60, 70}},
+			3: {Scores: []float64{40}, Skipped: true},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		model      *normalizationModel
+		department uint
+		raw        float64
+		want       float64
+	}{
+		{"zscore department mean maps to company mean", zscore, 1, 80, 90},
+		{"zscore one deviation above", zscore, 1, 85, 100},
+		{"zscore one deviation below", zscore, 1, 75, 80},
+		{"zscore clamped to 120", zscore, 1, 120, 120},
+		{"zscore clamped to 0", zscore, 1, 30, 0},
+		{"zscore department without spread maps to company mean", zscore, 2, 97, 90},
+		{"zscore skipped department keeps raw score", zscore, 3, 77.5, 77.5},
+		{"zscore unknown department keeps raw score", zscore, 9, 64.25, 64.25},
+		{"percentile lowest", percentile, 1, 50, 66.67},
+		{"percentile median", percentile, 1, 60, 80},
+		{"percentile highest", percentile, 1, 70, 93.33},
+		{"percentile above every department score", percentile, 1, 75, 100},
+		{"percentile skipped department keeps raw score", percentile, 3, 40, 40},
+		{"percentile unknown department keeps raw score", percentile, 9, 88, 88},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.model.normalize(tt.department, tt.raw); got != tt.want {
+				t.Errorf("normalize(%d, %v) = %v, want %v", tt.department, tt.raw, got, tt.want)
+			}
+		})
+	}
+}
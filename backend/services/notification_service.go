@@ -0,0 +1,38 @@
+package services
+
+import (
+	"errors"
+
+	"cepm-backend/database"
+	"cepm-backend/models"
+	"cepm-backend/wechat"
+
+	"gorm.io/gorm"
+)
+
+// NotificationService defines the interface for sending notifications to users.
+type NotificationService interface {
+	Notify(userID uint, content string) error
+}
+
+type wechatNotificationService struct {
+	wechatClient *wechat.WechatClient
+	db           *gorm.DB
+}
+
+// NewNotificationService creates a NotificationService that delivers messages through WeChat Work.
+func NewNotificationService(wechatClient *wechat.WechatClient) NotificationService {
+	return &wechatNotificationService{wechatClient: wechatClient, db: database.DB}
+}
+
+// Notify sends a text message to the user's WeChat Work account.
+func (s *wechatNotificationService) Notify(userID uint, content string) error {
+	var user models.User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		return err
+	}
+	if user.WechatUserid == "" {
+		return errors.New("用户未绑定企业微信")
+	}
+	return s.wechatClient.SendTextMessage([]string{user.WechatUserid}, content)
+}
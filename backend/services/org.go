@@ -0,0 +1,75 @@
+package services
+
+import (
+	"errors"
+
+	"cepm-backend/models"
+
+	"gorm.io/gorm"
+)
+
+// isHRUser reports whether the user has the HR role. The user's Role must be preloaded.
+func isHRUser(user *models.User) bool {
+	return user.Role.Name == "人事" || user.Role.Name == "HR"
+}
+
+// findSkipLevelManager returns the manager of the user's direct manager.
+func findSkipLevelManager(db *gorm.DB, user *models.User) (*models.User, error) {
+	if user.ManagerID == nil {
+		return nil, errors.New("用户没有直属上级")
+	}
+	var manager models.User
+	if err := db.First(&manager, *user.ManagerID).Error; err != nil {
+		return nil, err
+	}
+	if manager.ManagerID == nil {
+		return nil, errors.New("用户没有隔级上级")
+	}
+	var skipLevel models.User
+	if err := db.First(&skipLevel, *manager.ManagerID).Error; err != nil {
+		return nil, err
+	}
+	return &skipLevel, nil
+}
+
+// findHRUser returns the first active user with the HR role.
+func findHRUser(db *gorm.DB) (*models.User, error) {
+	var hr models.User
+	err := db.Joins("JOIN roles ON roles.id = users.role_id").
+		Where("roles.name IN ? AND users.is_active = ?", []string{"人事", "HR"}, true).
+		Order("users.id asc").First(&hr).Error
+	if err != nil {
+		return nil, err
+	}
+	return &hr, nil
+}
+
+// departmentSubtreeIDs returns the ID of the root department and all of its descendants.
+func departmentSubtreeIDs(db *gorm.DB, rootID uint) ([]uint, error) {
+	var departments []models.Department
+	if err := db.Find(&departments).Error; err != nil {
+		return nil, err
+	}
+	children := make(map[uint][]uint)
+	for _, department := range departments {
+		if department.ParentID != nil {
+			children[*department.ParentID] = append(children[*department.ParentID], department.ID)
+		}
+	}
+
+	ids := []uint{rootID}
+	for i := 0; i < len(ids); i++ {
+		ids = append(ids, children[ids[i]]...)
+	}
+	return ids, nil
+}
+
+// containsUint reports whether ids contains id.
+func containsUint(ids []uint, id uint) bool {
+	for _, candidate := range ids {
+		if candidate == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,364 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"cepm-backend/database"
+	"cepm-backend/models"
+	"cepm-backend/repositories"
+	"cepm-backend/xlsx"
+
+	"gorm.io/gorm"
+)
+
+// SettingPayrollExportColumns is the SystemSetting key holding the payroll export columns as a JSON array of column keys.
+const SettingPayrollExportColumns = "payroll_export_columns"
+
+// payrollTimeZone is the zone times are written in, so a batch renders the same on every server (China Standard Time).
+var payrollTimeZone = time.FixedZone("CST", 8*60*60)
+
+// payrollColumn is a column of the payroll export.
+type payrollColumn struct {
+	key     string
+	heading string
+	value   func(line *models.PayrollExportLine) xlsx.Cell
+}
+
+// payrollColumns are the columns available to the payroll export.
+var payrollColumns = []payrollColumn{
+	{"employeeId", "员工ID", func(l *models.PayrollExportLine) xlsx.Cell {
+		return xlsx.TextCell(strconv.FormatUint(uint64(l.UserID), 10))
+	}},
+	{"wechatUserId", "企业微信账号", func(l *models.PayrollExportLine) xlsx.Cell { return xlsx.TextCell(l.WechatUserid) }},
+	{"department", "部门", func(l *models.PayrollExportLine) xlsx.Cell { return xlsx.TextCell(l.DepartmentName) }},
+	{"period", "考核周期", func(l *models.PayrollExportLine) xlsx.Cell { return xlsx.TextCell(l.Period) }},
+	{"totalScore", "月度绩效得分M", func(l *models.PayrollExportLine) xlsx.Cell { return xlsx.NumberCell(l.TotalScore) }},
+	{"gradeBand", "考核等级", func(l *models.PayrollExportLine) xlsx.Cell { return xlsx.TextCell(l.GradeBand) }},
+	{"gradePoint", "月度考核系数n", func(l *models.PayrollExportLine) xlsx.Cell { return xlsx.NumberCell(l.GradePoint) }},
+	{"confirmedAt", "人事确认时间", func(l *models.PayrollExportLine) xlsx.Cell {
+		return xlsx.TextCell(l.HRConfirmedAt.In(payrollTimeZone).Format("2006-01-02 15:04:05"))
+	}},
+	{"employeeName", "姓名", func(l *models.PayrollExportLine) xlsx.Cell { return xlsx.TextCell(l.EmployeeName) }},
+}
+
+// defaultPayrollColumns are exported unless the payroll_export_columns setting says otherwise.
+var defaultPayrollColumns = []string{"employeeId", "wechatUserId", "department", "period", "totalScore", "gradeBand", "gradePoint", "confirmedAt"}
+
+// PayrollExportInput defines the structure for exporting the coefficients of a period.
+type PayrollExportInput struct {
+	Period       string   `json:"period"`
+	DepartmentID uint     `json:"departmentId"` // Optional; defaults to the whole company
+	Format       string   `json:"format"`       // csv (default), xlsx
+	Columns      []string `json:"columns"`      // Optional; defaults to the payroll_export_columns setting
+}
+
+// PayrollExport is the file of a new export and the batch recording it.
+type PayrollExport struct {
+	Batch *models.PayrollExportBatch
+	File  *ExportFile
+}
+
+// PayrollLineChange is a difference between an exported line and the current result of its review.
+type PayrollLineChange struct {
+	ReviewID     uint                      `json:"reviewId"`
+	EmployeeName string                    `json:"employeeName"`
+	Change       string                    `json:"change"` // changed, added, removed
+	Exported     *models.PayrollExportLine `json:"exported,omitempty"`
+	Current      *models.PayrollExportLine `json:"current,omitempty"`
+}
+
+// PayrollReconciliation compares a batch with the current HR-confirmed results of its scope.
+type PayrollReconciliation struct {
+	Batch     *models.PayrollExportBatch `json:"batch"`
+	Unchanged int                        `json:"unchanged"`
+	Changes   []PayrollLineChange        `json:"changes"`
+}
+
+// PayrollService defines the interface for exporting the 月度考核系数 to payroll.
+type PayrollService interface {
+	Export(hrID uint, input *PayrollExportInput) (*PayrollExport, error)
+	ListBatches(period string) ([]models.PayrollExportBatch, error)
+	GetBatch(id uint) (*models.PayrollExportBatch, error)
+	DownloadBatch(id uint) (*ExportFile, error)
+	ReconcileBatch(id uint) (*PayrollReconciliation, error)
+}
+
+type payrollService struct {
+	repo                 repositories.PayrollRepository
+	systemSettingService *SystemSettingService
+	db                   *gorm.DB
+}
+
+// NewPayrollService creates a new instance of PayrollService.
+func NewPayrollService(repo repositories.PayrollRepository, systemSettingService *SystemSettingService) PayrollService {
+	return &payrollService{repo: repo, systemSettingService: systemSettingService, db: database.DB}
+}
+
+// Export writes the coefficients of a period's HR-confirmed reviews as CSV or XLSX and records the export
+// as a batch. Every non-draft review in scope must be confirmed by HR first.
+func (s *payrollService) Export(hrID uint, input *PayrollExportInput) (*PayrollExport, error) {
+	if input.Period == "" {
+		return nil, errors.New("考核周期不能为空")
+	}
+	format := input.Format
+	if format == "" {
+		format = "csv"
+	}
+	if format != "csv" && format != "xlsx" {
+		return nil, errors.New("导出格式只能是csv或xlsx")
+	}
+	columns := input.Columns
+	if len(columns) == 0 {
+		columns = s.defaultColumns()
+	}
+	if err := validatePayrollColumns(columns); err != nil {
+		return nil, err
+	}
+
+	var departmentID *uint
+	if input.DepartmentID != 0 {
+		departmentID = &input.DepartmentID
+	}
+	lines, err := s.currentLines(input.Period, departmentID)
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 {
+		return nil, errors.New("该周期内没有可导出的绩效评估")
+	}
+
+	columnsJSON, err := json.Marshal(columns)
+	if err != nil {
+		return nil, err
+	}
+	batch := &models.PayrollExportBatch{
+		Period:       input.Period,
+		DepartmentID: departmentID,
+		Format:       format,
+		Columns:      string(columnsJSON),
+		RowCount:     len(lines),
+		ExportedByID: hrID,
+		Lines:        lines,
+	}
+	file, err := renderPayrollFile(batch, columns)
+	if err != nil {
+		return nil, err
+	}
+	batch.Checksum = payrollChecksum(file.Data)
+	batch.Content = file.Data
+	if err := s.repo.CreateBatch(batch); err != nil {
+		return nil, err
+	}
+	file.FileName = payrollFileName(batch)
+	return &PayrollExport{Batch: batch, File: file}, nil
+}
+
+// ListBatches retrieves the export batches, optionally filtered by period.
+func (s *payrollService) ListBatches(period string) ([]models.PayrollExportBatch, error) {
+	return s.repo.ListBatches(period)
+}
+
+// GetBatch retrieves a batch with its exported lines.
+func (s *payrollService) GetBatch(id uint) (*models.PayrollExportBatch, error) {
+	batch, err := s.repo.GetBatch(id)
+	if err != nil {
+		return nil, errors.New("导出批次不存在")
+	}
+	return batch, nil
+}
+
+// DownloadBatch returns the file that was exported, after checking it against the recorded checksum.
+func (s *payrollService) DownloadBatch(id uint) (*ExportFile, error) {
+	batch, err := s.GetBatch(id)
+	if err != nil {
+		return nil, err
+	}
+	file := &ExportFile{ContentType: "text/csv; charset=utf-8", Data: batch.Content}
+	if batch.Format == "xlsx" {
+		file.ContentType = xlsxContentType
+	}
+	if payrollChecksum(file.Data) != batch.Checksum {
+		return nil, errors.New("导出文件与批次记录的校验和不一致")
+	}
+	file.FileName = payrollFileName(batch)
+	return file, nil
+}
+
+// ReconcileBatch compares a batch with the current results of its period and scope: reviews whose
+// score, coefficient or confirmation changed since the export, reviews confirmed since, and exported
+// reviews that are no longer confirmed.
+func (s *payrollService) ReconcileBatch(id uint) (*PayrollReconciliation, error) {
+	batch, err := s.GetBatch(id)
+	if err != nil {
+		return nil, err
+	}
+	current, err := s.confirmedLines(batch.Period, batch.DepartmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	currentByReview := make(map[uint]*models.PayrollExportLine, len(current))
+	for i := range current {
+		currentByReview[current[i].ReviewID] = &current[i]
+	}
+	result := &PayrollReconciliation{Batch: batch, Changes: []PayrollLineChange{}}
+	exported := make(map[uint]bool, len(batch.Lines))
+	for i := range batch.Lines {
+		line := &batch.Lines[i]
+		exported[line.ReviewID] = true
+		now, ok := currentByReview[line.ReviewID]
+		switch {
+		case !ok:
+			result.Changes = append(result.Changes, PayrollLineChange{ReviewID: line.ReviewID, EmployeeName: line.EmployeeName, Change: "removed", Exported: line})
+		case now.TotalScore != line.TotalScore || now.GradePoint != line.GradePoint || !now.HRConfirmedAt.Equal(line.HRConfirmedAt):
+			result.Changes = append(result.Changes, PayrollLineChange{ReviewID: line.ReviewID, EmployeeName: line.EmployeeName, Change: "changed", Exported: line, Current: now})
+		default:
+			result.Unchanged++
+		}
+	}
+	for i := range current {
+		if !exported[current[i].ReviewID] {
+			result.Changes = append(result.Changes, PayrollLineChange{ReviewID: current[i].ReviewID, EmployeeName: current[i].EmployeeName, Change: "added", Current: &current[i]})
+		}
+	}
+	return result, nil
+}
+
+// currentLines builds the export lines of a period and scope, refusing while any scored review is still
+// waiting for HR confirmation; the blocking employees are named. Reviews without a result yet (rejected,
+// awaiting approval or scoring) are left out and show up as added when the batch is reconciled later.
+func (s *payrollService) currentLines(period string, departmentID *uint) ([]models.PayrollExportLine, error) {
+	reviews, err := s.scopeReviews(period, departmentID)
+	if err != nil {
+		return nil, err
+	}
+	var pending []string
+	for _, review := range reviews {
+		if review.TotalScore != nil && review.HRConfirmedAt == nil {
+			pending = append(pending, fmt.Sprintf("%s（%s）", review.User.Name, review.Status))
+		}
+	}
+	if len(pending) > 0 {
+		return nil, fmt.Errorf("该周期仍有%d份已评分的绩效评估未经人事确认，无法导出：%s", len(pending), strings.Join(pending, "、"))
+	}
+	return newPayrollLines(s.db, reviews), nil
+}
+
+// confirmedLines builds the lines of the HR-confirmed reviews of a period and scope.
+func (s *payrollService) confirmedLines(period string, departmentID *uint) ([]models.PayrollExportLine, error) {
+	reviews, err := s.scopeReviews(period, departmentID)
+	if err != nil {
+		return nil, err
+	}
+	return newPayrollLines(s.db, reviews), nil
+}
+
+func (s *payrollService) scopeReviews(period string, departmentID *uint) ([]models.PerformanceReview, error) {
+	var departmentIDs []uint
+	if departmentID != nil {
+		var err error
+		if departmentIDs, err = departmentSubtreeIDs(s.db, *departmentID); err != nil {
+			return nil, err
+		}
+	}
+	return s.repo.ListReviews(period, departmentIDs)
+}
+
+// defaultColumns reads the columns from settings, falling back to defaultPayrollColumns.
+func (s *payrollService) defaultColumns() []string {
+	if setting, err := s.systemSettingService.GetSetting(SettingPayrollExportColumns); err == nil && setting.Value != "" {
+		var columns []string
+		if err := json.Unmarshal([]byte(setting.Value), &columns); err == nil && len(columns) > 0 {
+			return columns
+		}
+	}
+	return defaultPayrollColumns
+}
+
+// newPayrollLines snapshots the HR-confirmed reviews with a score as export lines. The grade band is the
+// band of the score the coefficient was computed from.
+func newPayrollLines(db *gorm.DB, reviews []models.PerformanceReview) []models.PayrollExportLine {
+	lines := make([]models.PayrollExportLine, 0, len(reviews))
+	for _, review := range reviews {
+		if review.HRConfirmedAt == nil || review.TotalScore == nil || review.GradePoint == nil {
+			continue
+		}
+		lines = append(lines, models.PayrollExportLine{
+			ReviewID:       review.ID,
+			UserID:         review.UserID,
+			EmployeeName:   review.User.Name,
+			WechatUserid:   review.User.WechatUserid,
+			DepartmentID:   review.User.DepartmentID,
+			DepartmentName: review.User.Department.Name,
+			Period:         review.Period,
+			TotalScore:     *review.TotalScore,
+			GradeBand:      gradeBand(coefficientScore(db, &review)),
+			GradePoint:     *review.GradePoint,
+			HRConfirmedAt:  *review.HRConfirmedAt,
+		})
+	}
+	return lines
+}
+
+func payrollChecksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func validatePayrollColumns(columns []string) error {
+	seen := make(map[string]bool)
+	for _, key := range columns {
+		if findPayrollColumn(key) == nil {
+			return fmt.Errorf("无效的导出列：%s", key)
+		}
+		if seen[key] {
+			return fmt.Errorf("导出列重复：%s", key)
+		}
+		seen[key] = true
+	}
+	return nil
+}
+
+func findPayrollColumn(key string) *payrollColumn {
+	for i := range payrollColumns {
+		if payrollColumns[i].key == key {
+			return &payrollColumns[i]
+		}
+	}
+	return nil
+}
+
+// renderPayrollFile writes the lines of a batch in its format and columns.
+func renderPayrollFile(batch *models.PayrollExportBatch, keys []string) (*ExportFile, error) {
+	columns := make([]*payrollColumn, 0, len(keys))
+	header := make([]string, 0, len(keys))
+	for _, key := range keys {
+		column := findPayrollColumn(key)
+		if column == nil {
+			return nil, fmt.Errorf("无效的导出列：%s", key)
+		}
+		columns = append(columns, column)
+		header = append(header, column.heading)
+	}
+	rows := make([][]xlsx.Cell, 0, len(batch.Lines))
+	for i := range batch.Lines {
+		row := make([]xlsx.Cell, 0, len(columns))
+		for _, column := range columns {
+			row = append(row, column.value(&batch.Lines[i]))
+		}
+		rows = append(rows, row)
+	}
+	return renderTableFile(batch.Format, batch.Period, header, rows)
+}
+
+// payrollFileName names the file of a batch after its period and number, e.g. 月度考核系数_2025-07_批次12.csv.
+func payrollFileName(batch *models.PayrollExportBatch) string {
+	return fmt.Sprintf("月度考核系数_%s_批次%d.%s", batch.Period, batch.ID, batch.Format)
+}
@@ -0,0 +1,365 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"cepm-backend/database"
+	"cepm-backend/models"
+	"cepm-backend/repositories"
+
+	"gorm.io/gorm"
+)
+
+// Settings for 360 peer feedback.
+const (
+	SettingPeerFeedbackQuestions    = "peer_feedback_questions"     // JSON array of questions asked for each 价值观 item
+	SettingPeerFeedbackAnonymous    = "peer_feedback_anonymous"     // "false" shows peer names to the manager and HR; anonymous by default
+	SettingPeerFeedbackMinResponses = "peer_feedback_min_responses" // Responses needed before a score is suggested, default 2
+)
+
+// defaultPeerFeedbackQuestions are asked for each 价值观 item when the setting is missing or invalid.
+var defaultPeerFeedbackQuestions = []string{
+	"该同事在日常工作中如何体现这一价值观？",
+	"该同事在这一价值观上对团队的影响如何？",
+}
+
+// peerRatingToScore converts an average 1-5 rating to an item score out of 100.
+const peerRatingToScore = 20.0
+
+// PeerFeedbackAnswerInput is a peer's rating of one 价值观 item on one question.
+type PeerFeedbackAnswerInput struct {
+	ItemID   uint   `json:"itemId"`
+	Question string `json:"question"`
+	Rating   int    `json:"rating"` // 1-5
+	Comment  string `json:"comment"`
+}
+
+// PeerFeedbackQuestionSummary is the average rating of one question on one item.
+type PeerFeedbackQuestionSummary struct {
+	Question      string  `json:"question"`
+	AverageRating float64 `json:"averageRating"`
+}
+
+// PeerFeedbackComment is a peer's comment; PeerName is empty when feedback is anonymized.
+type PeerFeedbackComment struct {
+	PeerName string `json:"peerName,omitempty"`
+	Question string `json:"question"`
+	Comment  string `json:"comment"`
+}
+
+// PeerFeedbackItemSummary aggregates the peer feedback on one 价值观 item.
+type PeerFeedbackItemSummary struct {
+	ItemID         uint                          `json:"itemId"`
+	Title          string                        `json:"title"`
+	ResponseCount  int                           `json:"responseCount"`
+	AverageRating  *float64                      `json:"averageRating"`
+	SuggestedScore *float64                      `json:"suggestedScore"`
+	Questions      []PeerFeedbackQuestionSummary `json:"questions"`
+	Comments       []PeerFeedbackComment         `json:"comments"`
+}
+
+// PeerFeedbackSummary aggregates the peer feedback of a review.
+type PeerFeedbackSummary struct {
+	ReviewID  uint                      `json:"reviewId"`
+	Anonymous bool                      `json:"anonymous"`
+	Requested int                       `json:"requested"`
+	Submitted int                       `json:"submitted"`
+	Items     []PeerFeedbackItemSummary `json:"items"`
+}
+
+// PeerFeedbackRequestStatus is the status of one feedback request, without the peer or their answers.
+type PeerFeedbackRequestStatus struct {
+	ID     uint   `json:"id"`
+	Status string `json:"status"`
+}
+
+// PeerFeedbackNomination reports the feedback requests of a review after a nomination. It never names
+// the peers or shows their answers, since the employee under review may nominate.
+type PeerFeedbackNomination struct {
+	ReviewID  uint                        `json:"reviewId"`
+	Invited   int                         `json:"invited"` // Requests created by this nomination
+	Requested int                         `json:"requested"`
+	Submitted int                         `json:"submitted"`
+	Requests  []PeerFeedbackRequestStatus `json:"requests"`
+}
+
+// PeerFeedbackService defines the interface for 360 peer feedback on 价值观 items.
+type PeerFeedbackService interface {
+	NominatePeers(reviewID uint, actor *models.User, peerIDs []uint) (*PeerFeedbackNomination, error)
+	ListPendingRequests(peerID uint) ([]models.PeerFeedbackRequest, error)
+	GetQuestions() []string
+	SubmitFeedback(requestID uint, peerID uint, answers []PeerFeedbackAnswerInput) error
+	DeclineRequest(requestID uint, peerID uint) error
+	GetSummary(reviewID uint, viewer *models.User) (*PeerFeedbackSummary, error)
+}
+
+type peerFeedbackService struct {
+	repo                 repositories.PeerFeedbackRepository
+	reviewRepo           repositories.PerformanceReviewRepository
+	systemSettingService *SystemSettingService
+	db                   *gorm.DB
+}
+
+// NewPeerFeedbackService creates a new instance of PeerFeedbackService.
+func NewPeerFeedbackService(repo repositories.PeerFeedbackRepository, reviewRepo repositories.PerformanceReviewRepository, systemSettingService *SystemSettingService) PeerFeedbackService {
+	return &peerFeedbackService{repo: repo, reviewRepo: reviewRepo, systemSettingService: systemSettingService, db: database.DB}
+}
+
+// NominatePeers invites peers to give feedback on a review's 价值观 items. The employee or their
+// direct manager can nominate, while the review is awaiting scoring so its items are fixed. Peers already
+// invited are skipped.
+func (s *peerFeedbackService) NominatePeers(reviewID uint, actor *models.User, peerIDs []uint) (*PeerFeedbackNomination, error) {
+	review, err := s.reviewRepo.GetByID(reviewID)
+	if err != nil {
+		return nil, errors.New("绩效评估不存在")
+	}
+	if review.UserID != actor.ID && (review.User.ManagerID == nil || *review.User.ManagerID != actor.ID) {
+		return nil, errors.New("只有本人或直属上级可以邀请同事反馈")
+	}
+	if review.Status != "待打分" {
+		return nil, errors.New("只有待打分状态的绩效评估才能邀请同事反馈")
+	}
+	if len(peerIDs) == 0 {
+		return nil, errors.New("请至少选择一名同事")
+	}
+
+	existing, err := s.repo.ListByReviewID(reviewID)
+	if err != nil {
+		return nil, err
+	}
+	invited := make(map[uint]bool)
+	for _, request := range existing {
+		invited[request.PeerID] = true
+	}
+
+	var requests []models.PeerFeedbackRequest
+	for _, peerID := range peerIDs {
+		if peerID == review.UserID || (review.User.ManagerID != nil && peerID == *review.User.ManagerID) {
+			return nil, errors.New("不能邀请本人或直属上级作为同事反馈人")
+		}
+		if invited[peerID] {
+			continue
+		}
+		invited[peerID] = true
+		var peer models.User
+		if err := s.db.First(&peer, peerID).Error; err != nil {
+			return nil, errors.New("同事不存在")
+		}
+		requests = append(requests, models.PeerFeedbackRequest{ReviewID: reviewID, PeerID: peerID, NominatedByID: actor.ID, Status: "待填写"})
+	}
+	if len(requests) > 0 {
+		if err := s.repo.CreateRequests(requests); err != nil {
+			return nil, err
+		}
+	}
+
+	all, err := s.repo.ListByReviewID(reviewID)
+	if err != nil {
+		return nil, err
+	}
+	nomination := &PeerFeedbackNomination{ReviewID: reviewID, Invited: len(requests), Requested: len(all), Requests: []PeerFeedbackRequestStatus{}}
+	for _, request := range all {
+		if request.Status == "已提交" {
+			nomination.Submitted++
+		}
+		nomination.Requests = append(nomination.Requests, PeerFeedbackRequestStatus{ID: request.ID, Status: request.Status})
+	}
+	return nomination, nil
+}
+
+// ListPendingRequests retrieves the feedback requests a peer has not answered yet.
+func (s *peerFeedbackService) ListPendingRequests(peerID uint) ([]models.PeerFeedbackRequest, error) {
+	return s.repo.ListPendingByPeerID(peerID)
+}
+
+// GetQuestions returns the questions asked for each 价值观 item.
+func (s *peerFeedbackService) GetQuestions() []string {
+	if setting, err := s.systemSettingService.GetSetting(SettingPeerFeedbackQuestions); err == nil && setting.Value != "" {
+		var questions []string
+		if err := json.Unmarshal([]byte(setting.Value), &questions); err == nil && len(questions) > 0 {
+			return questions
+		}
+	}
+	return defaultPeerFeedbackQuestions
+}
+
+// SubmitFeedback records a peer's answers, which must cover every question for every 价值观 item,
+// and refreshes the suggested scores of the review's 价值观 items.
+func (s *peerFeedbackService) SubmitFeedback(requestID uint, peerID uint, answers []PeerFeedbackAnswerInput) error {
+	request, err := s.repo.GetByID(requestID)
+	if err != nil {
+		return errors.New("反馈邀请不存在")
+	}
+	if request.PeerID != peerID {
+		return errors.New("您无权填写此反馈")
+	}
+	if request.Status != "待填写" {
+		return errors.New("此反馈已提交或已拒绝")
+	}
+	if request.Review.Status != "待打分" {
+		return errors.New("绩效评估已打分，反馈已关闭")
+	}
+
+	// 1. Validate the answers against the 价值观 items and questions
+	questions := s.GetQuestions()
+	answered := make(map[uint]map[string]bool)
+	valueItems := make(map[uint]bool)
+	for _, item := range request.Review.Items {
+		if item.Category == "价值观" {
+			valueItems[item.ID] = true
+			answered[item.ID] = make(map[string]bool)
+		}
+	}
+	records := make([]models.PeerFeedbackAnswer, 0, len(answers))
+	for _, answer := range answers {
+		if !valueItems[answer.ItemID] {
+			return errors.New("只能对价值观绩效项进行反馈")
+		}
+		if !containsString(questions, answer.Question) {
+			return errors.New("无效的反馈问题：" + answer.Question)
+		}
+		if answered[answer.ItemID][answer.Question] {
+			return errors.New("同一问题只能回答一次")
+		}
+		if answer.Rating < 1 || answer.Rating > 5 {
+			return errors.New("评分必须在1到5之间")
+		}
+		answered[answer.ItemID][answer.Question] = true
+		records = append(records, models.PeerFeedbackAnswer{ItemID: answer.ItemID, Question: answer.Question, Rating: answer.Rating, Comment: answer.Comment})
+	}
+	for itemID := range valueItems {
+		for _, question := range questions {
+			if !answered[itemID][question] {
+				return errors.New("请回答所有问题")
+			}
+		}
+	}
+
+	// 2. Save and refresh the suggestions
+	now := time.Now()
+	request.Status = "已提交"
+	request.SubmittedAt = &now
+	if err := s.repo.Submit(request, records); err != nil {
+		return err
+	}
+	return s.refreshSuggestions(request.ReviewID, request.Review.Items)
+}
+
+// DeclineRequest lets a peer decline a feedback request.
+func (s *peerFeedbackService) DeclineRequest(requestID uint, peerID uint) error {
+	request, err := s.repo.GetByID(requestID)
+	if err != nil {
+		return errors.New("反馈邀请不存在")
+	}
+	if request.PeerID != peerID {
+		return errors.New("您无权处理此反馈")
+	}
+	if request.Status != "待填写" {
+		return errors.New("此反馈已提交或已拒绝")
+	}
+	return s.repo.Decline(requestID)
+}
+
+// GetSummary aggregates a review's peer feedback. HR and the direct manager can view it, and so can
+// the employee, who always sees it anonymized.
+func (s *peerFeedbackService) GetSummary(reviewID uint, viewer *models.User) (*PeerFeedbackSummary, error) {
+	review, err := s.reviewRepo.GetByID(reviewID)
+	if err != nil {
+		return nil, errors.New("绩效评估不存在")
+	}
+	isManager := review.User.ManagerID != nil && *review.User.ManagerID == viewer.ID
+	if !isHRUser(viewer) && !isManager && review.UserID != viewer.ID {
+		return nil, errors.New("您无权查看此同事反馈")
+	}
+	requests, err := s.repo.ListByReviewID(reviewID)
+	if err != nil {
+		return nil, err
+	}
+
+	anonymous := s.anonymous() || review.UserID == viewer.ID
+	summary := &PeerFeedbackSummary{ReviewID: reviewID, Anonymous: anonymous, Requested: len(requests)}
+	for _, request := range requests {
+		if request.Status == "已提交" {
+			summary.Submitted++
+		}
+	}
+	minResponses := settingInt(s.systemSettingService, SettingPeerFeedbackMinResponses, 2)
+	for _, item := range review.Items {
+		if item.Category == "价值观" {
+			summary.Items = append(summary.Items, summarizePeerFeedback(item, requests, s.GetQuestions(), anonymous, minResponses))
+		}
+	}
+	return summary, nil
+}
+
+// refreshSuggestions recomputes the peer-suggested score of each 价值观 item of a review.
+func (s *peerFeedbackService) refreshSuggestions(reviewID uint, items []models.PerformanceItem) error {
+	requests, err := s.repo.ListByReviewID(reviewID)
+	if err != nil {
+		return err
+	}
+	minResponses := settingInt(s.systemSettingService, SettingPeerFeedbackMinResponses, 2)
+	suggestions := make(map[uint]*float64)
+	for _, item := range items {
+		if item.Category == "价值观" {
+			suggestions[item.ID] = summarizePeerFeedback(item, requests, s.GetQuestions(), true, minResponses).SuggestedScore
+		}
+	}
+	return s.repo.UpdateSuggestedScores(suggestions)
+}
+
+// anonymous reports whether peer names are hidden from the manager and HR.
+func (s *peerFeedbackService) anonymous() bool {
+	setting, err := s.systemSettingService.GetSetting(SettingPeerFeedbackAnonymous)
+	return err != nil || setting.Value != "false"
+}
+
+// summarizePeerFeedback aggregates the submitted answers on one item. Averages and comments are only
+// shown, and a score only suggested, once at least minResponses peers have answered, so individual
+// ratings and comments cannot be singled out.
+func summarizePeerFeedback(item models.PerformanceItem, requests []models.PeerFeedbackRequest, questions []string, anonymous bool, minResponses int) PeerFeedbackItemSummary {
+	summary := PeerFeedbackItemSummary{ItemID: item.ID, Title: item.Title, Comments: []PeerFeedbackComment{}}
+	questionRatings := make(map[string][]float64)
+	var ratings []float64
+	for _, request := range requests {
+		if request.Status != "已提交" {
+			continue
+		}
+		responded := false
+		for _, answer := range request.Answers {
+			if answer.ItemID != item.ID {
+				continue
+			}
+			responded = true
+			ratings = append(ratings, float64(answer.Rating))
+			questionRatings[answer.Question] = append(questionRatings[answer.Question], float64(answer.Rating))
+			if answer.Comment != "" {
+				comment := PeerFeedbackComment{Question: answer.Question, Comment: answer.Comment}
+				if !anonymous {
+					comment.PeerName = request.Peer.Name
+				}
+				summary.Comments = append(summary.Comments, comment)
+			}
+		}
+		if responded {
+			summary.ResponseCount++
+		}
+	}
+
+	if len(ratings) == 0 || summary.ResponseCount < minResponses {
+		summary.Comments = []PeerFeedbackComment{}
+		return summary
+	}
+	for _, question := range questions {
+		if questionRatings[question] != nil {
+			summary.Questions = append(summary.Questions, PeerFeedbackQuestionSummary{Question: question, AverageRating: round2(scoreStats(questionRatings[question]).Mean)})
+		}
+	}
+	average := round2(scoreStats(ratings).Mean)
+	summary.AverageRating = &average
+	suggested := round2(average * peerRatingToScore)
+	summary.SuggestedScore = &suggested
+	return summary
+}
@@ -0,0 +1,219 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"cepm-backend/database"
+	"cepm-backend/models"
+	"cepm-backend/repositories"
+
+	"gorm.io/gorm"
+)
+
+// Review cycle types.
+const (
+	CycleTypeMonthly   = "monthly"
+	CycleTypeQuarterly = "quarterly"
+	CycleTypeHalfYear  = "half_year"
+	CycleTypeProbation = "probation"
+	CycleTypeCustom    = "custom"
+)
+
+// monthlyPeriodPattern matches the legacy YYYY-MM period format.
+var monthlyPeriodPattern = regexp.MustCompile(`^\d{4}-(0[1-9]|1[0-2])$`)
+
+// ReviewCycleInput defines the structure for creating a review cycle from the API.
+type ReviewCycleInput struct {
+	Name         string `json:"name"`
+	Code         string `json:"code"`      // Optional for monthly, quarterly and half-year cycles
+	CycleType    string `json:"cycleType"` // monthly, quarterly, half_year, probation, custom
+	StartDate    string `json:"startDate"` // Format: YYYY-MM-DD
+	EndDate      string `json:"endDate"`   // Format: YYYY-MM-DD, inclusive
+	ScopeType    string `json:"scopeType"` // all, department, users
+	DepartmentID *uint  `json:"departmentId"`
+	UserIDs      []uint `json:"userIds"`
+}
+
+// ReviewCycleService defines the interface for review cycle services.
+type ReviewCycleService interface {
+	CreateCycle(creatorID uint, input *ReviewCycleInput) (*models.ReviewCycle, error)
+	ListCycles(includeClosed bool) ([]models.ReviewCycle, error)
+	GetCycle(id uint) (*models.ReviewCycle, error)
+	CloseCycle(id uint) error
+	ListCyclesForUser(userID uint) ([]models.ReviewCycle, error)
+}
+
+type reviewCycleService struct {
+	repo repositories.ReviewCycleRepository
+	db   *gorm.DB
+}
+
+// NewReviewCycleService creates a new instance of ReviewCycleService.
+func NewReviewCycleService(repo repositories.ReviewCycleRepository) ReviewCycleService {
+	return &reviewCycleService{repo: repo, db: database.DB}
+}
+
+// CreateCycle validates and stores a new review cycle.
+func (s *reviewCycleService) CreateCycle(creatorID uint, input *ReviewCycleInput) (*models.ReviewCycle, error) {
+	// 1. Dates
+	startDate, err := time.ParseInLocation("2006-01-02", input.StartDate, time.Local)
+	if err != nil {
+		return nil, errors.New("开始日期格式错误，应为YYYY-MM-DD")
+	}
+	endDate, err := time.ParseInLocation("2006-01-02", input.EndDate, time.Local)
+	if err != nil {
+		return nil, errors.New("结束日期格式错误，应为YYYY-MM-DD")
+	}
+	if endDate.Before(startDate) {
+		return nil, errors.New("结束日期不能早于开始日期")
+	}
+
+	// 2. Type and code
+	code := input.Code
+	switch input.CycleType {
+	case CycleTypeMonthly:
+		if code == "" {
+			code = startDate.Format("2006-01")
+		}
+	case CycleTypeQuarterly:
+		if code == "" {
+			code = fmt.Sprintf("%d-Q%d", startDate.Year(), (int(startDate.Month())-1)/3+1)
+		}
+	case CycleTypeHalfYear:
+		if code == "" {
+			code = fmt.Sprintf("%d-H%d", startDate.Year(), (int(startDate.Month())-1)/6+1)
+		}
+	case CycleTypeProbation, CycleTypeCustom:
+		if code == "" {
+			return nil, errors.New("转正考核和自定义周期必须指定周期编码")
+		}
+	default:
+		return nil, errors.New("无效的周期类型")
+	}
+	if len(code) > 32 {
+		return nil, errors.New("周期编码不能超过32个字符")
+	}
+	if _, err := s.repo.GetByCode(code); err == nil {
+		return nil, errors.New("周期编码已存在")
+	}
+
+	cycle := &models.ReviewCycle{
+		Name:        input.Name,
+		Code:        code,
+		CycleType:   input.CycleType,
+		StartDate:   startDate,
+		EndDate:     endDate,
+		ScopeType:   input.ScopeType,
+		CreatedByID: &creatorID,
+	}
+	if cycle.Name == "" {
+		cycle.Name = code
+	}
+
+	// 3. Scope
+	switch input.ScopeType {
+	case "", "all":
+		cycle.ScopeType = "all"
+	case "department":
+		if input.DepartmentID == nil {
+			return nil, errors.New("按部门分配时必须指定部门")
+		}
+		cycle.DepartmentID = input.DepartmentID
+	case "users":
+		if len(input.UserIDs) == 0 {
+			return nil, errors.New("按人员分配时必须指定至少一名员工")
+		}
+		var users []models.User
+		if err := s.db.Where("id IN ?", input.UserIDs).Find(&users).Error; err != nil {
+			return nil, err
+		}
+		if len(users) != len(input.UserIDs) {
+			return nil, errors.New("部分员工不存在")
+		}
+		cycle.Users = users
+	default:
+		return nil, errors.New("无效的分配范围")
+	}
+
+	if err := s.repo.Create(cycle); err != nil {
+		return nil, err
+	}
+	return cycle, nil
+}
+
+// ListCycles retrieves review cycles, optionally including closed ones.
+func (s *reviewCycleService) ListCycles(includeClosed bool) ([]models.ReviewCycle, error) {
+	return s.repo.List(includeClosed)
+}
+
+// GetCycle retrieves a single review cycle.
+func (s *reviewCycleService) GetCycle(id uint) (*models.ReviewCycle, error) {
+	cycle, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, errors.New("考核周期不存在")
+	}
+	return cycle, nil
+}
+
+// CloseCycle closes a review cycle. Existing reviews are unaffected.
+func (s *reviewCycleService) CloseCycle(id uint) error {
+	if _, err := s.GetCycle(id); err != nil {
+		return err
+	}
+	return s.repo.Close(id)
+}
+
+// ListCyclesForUser retrieves the open review cycles assigned to a user.
+func (s *reviewCycleService) ListCyclesForUser(userID uint) ([]models.ReviewCycle, error) {
+	var user models.User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		return nil, errors.New("用户不存在")
+	}
+	cycles, err := s.repo.List(false)
+	if err != nil {
+		return nil, err
+	}
+	assigned := []models.ReviewCycle{}
+	for _, cycle := range cycles {
+		covers, err := cycleCovers(s.db, cycle, &user)
+		if err != nil {
+			return nil, err
+		}
+		if covers {
+			assigned = append(assigned, cycle)
+		}
+	}
+	return assigned, nil
+}
+
+// cycleCovers reports whether a review cycle is assigned to the given user.
+func cycleCovers(db *gorm.DB, cycle models.ReviewCycle, user *models.User) (bool, error) {
+	switch cycle.ScopeType {
+	case "department":
+		if cycle.DepartmentID == nil || user.DepartmentID == nil {
+			return false, nil
+		}
+		departmentIDs, err := departmentSubtreeIDs(db, *cycle.DepartmentID)
+		if err != nil {
+			return false, err
+		}
+		for _, id := range departmentIDs {
+			if id == *user.DepartmentID {
+				return true, nil
+			}
+		}
+		return false, nil
+	case "users":
+		for _, assignee := range cycle.Users {
+			if assignee.ID == user.ID {
+				return true, nil
+			}
+		}
+		return false, nil
+	default:
+		return true, nil
+	}
+}
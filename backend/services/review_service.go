@@ -2,6 +2,11 @@ package services
 
 import (
 	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
 
 	"cepm-backend/database"
 	"cepm-backend/models"
@@ -12,9 +17,10 @@ import (
 
 // ScoreItemInput defines the structure for a single item's score data from the API.
 type ScoreItemInput struct {
-	ID                uint     `json:"id"`
-	CompletionDetails string   `json:"completionDetails"`
-	Score             *float64 `json:"score"`
+	ID                   uint     `json:"id"`
+	CompletionDetails    string   `json:"completionDetails"`
+	Score                *float64 `json:"score"`
+	AcceptPeerSuggestion bool     `json:"acceptPeerSuggestion"` // Use the item's peer-suggested score instead of Score
 }
 
 // ScoreInput defines the structure for the entire scoring request.
@@ -23,6 +29,30 @@ type ScoreInput struct {
 	FinalComment string           `json:"finalComment"`
 }
 
+// BatchReviewInput defines the structure for a batch approve or reject request.
+type BatchReviewInput struct {
+	ReviewIDs []uint `json:"reviewIds"`
+	Comment   string `json:"comment"`
+}
+
+// BatchScoreReviewInput defines the score data for one review in a batch score request.
+type BatchScoreReviewInput struct {
+	ReviewID uint `json:"reviewId"`
+	ScoreInput
+}
+
+// BatchScoreInput defines the structure for a batch score request.
+type BatchScoreInput struct {
+	Reviews []BatchScoreReviewInput `json:"reviews"`
+}
+
+// BatchResult reports the outcome of a batch operation for a single review.
+type BatchResult struct {
+	ReviewID uint   `json:"reviewId"`
+	Success  bool   `json:"success"`
+	Error    string `json:"error,omitempty"`
+}
+
 // PerformanceReviewService defines the interface for performance review services.
 type PerformanceReviewService interface {
 	CreatePerformanceReview(review *models.PerformanceReview) error
@@ -33,20 +63,30 @@ type PerformanceReviewService interface {
 	SubmitPerformanceReview(reviewID uint, userID uint) error
 	ApprovePerformanceReview(reviewID uint, approverID uint, comment string) error
 	RejectPerformanceReview(reviewID uint, approverID uint, comment string) error
-	ScorePerformanceReview(reviewID uint, input *ScoreInput) error
+	ScorePerformanceReview(reviewID uint, scorerID uint, input *ScoreInput) error
 	GetPerformanceReviewByPeriod(userID uint, period string) (*models.PerformanceReview, error)
 	UpdatePerformanceReview(review *models.PerformanceReview) error
 	GetAllReviewsByPeriod(period string) ([]models.PerformanceReview, error)
+	BatchApprovePerformanceReviews(input *BatchReviewInput, approverID uint) []BatchResult
+	BatchRejectPerformanceReviews(input *BatchReviewInput, approverID uint) []BatchResult
+	BatchScorePerformanceReviews(input *BatchScoreInput, scorerID uint) []BatchResult
+	AcknowledgePerformanceReview(reviewID uint, userID uint, ip string) error
+	HRConfirmPerformanceReview(reviewID uint, hrID uint, comment string) error
 }
 
 type performanceReviewService struct {
-	repo repositories.PerformanceReviewRepository
-	db   *gorm.DB // Add gorm.DB dependency for user role check
+	repo                 repositories.PerformanceReviewRepository
+	delegationRepo       repositories.DelegationRepository
+	cycleRepo            repositories.ReviewCycleRepository
+	evaluatorRepo        repositories.EvaluatorRepository
+	improvementPlans     ImprovementPlanService
+	systemSettingService *SystemSettingService
+	db                   *gorm.DB // Add gorm.DB dependency for user role check
 }
 
 // NewPerformanceReviewService creates a new instance of PerformanceReviewService.
-func NewPerformanceReviewService(repo repositories.PerformanceReviewRepository) PerformanceReviewService {
-	return &performanceReviewService{repo: repo, db: database.DB} // Inject database.DB
+func NewPerformanceReviewService(repo repositories.PerformanceReviewRepository, delegationRepo repositories.DelegationRepository, cycleRepo repositories.ReviewCycleRepository, evaluatorRepo repositories.EvaluatorRepository, improvementPlans ImprovementPlanService, systemSettingService *SystemSettingService) PerformanceReviewService {
+	return &performanceReviewService{repo: repo, delegationRepo: delegationRepo, cycleRepo: cycleRepo, evaluatorRepo: evaluatorRepo, improvementPlans: improvementPlans, systemSettingService: systemSettingService, db: database.DB} // Inject database.DB
 }
 
 // ListAllSubmittedReviews retrieves all performance reviews for HR role.
@@ -68,10 +108,95 @@ func (s *performanceReviewService) ListAllSubmittedReviews(userID uint) ([]model
 
 // CreatePerformanceReview handles the business logic for creating a performance review.
 func (s *performanceReviewService) CreatePerformanceReview(review *models.PerformanceReview) error {
-	// Business logic for creation goes here...
+	if err := s.assignCycle(review); err != nil {
+		return err
+	}
+	if _, err := s.repo.GetByUserIDAndPeriod(review.UserID, review.Period); err == nil {
+		return errors.New("该员工在此考核周期已有绩效评估")
+	}
 	return s.repo.Create(review)
 }
 
+// assignCycle resolves the review cycle of a review from its CycleID or Period and sets both.
+// A YYYY-MM period without a cycle gets a company-wide monthly cycle created on demand,
+// so clients that only send a period keep working.
+func (s *performanceReviewService) assignCycle(review *models.PerformanceReview) error {
+	var cycle *models.ReviewCycle
+	var err error
+	switch {
+	case review.CycleID != nil:
+		if cycle, err = s.cycleRepo.GetByID(*review.CycleID); err != nil {
+			return errors.New("考核周期不存在")
+		}
+	case review.Period != "":
+		if cycle, err = findCycleByCode(s.cycleRepo, review.Period, true); err != nil {
+			return err
+		}
+	default:
+		return errors.New("请选择考核周期")
+	}
+
+	if err := checkCycleOpenFor(s.db, cycle, review.UserID); err != nil {
+		return err
+	}
+	review.CycleID = &cycle.ID
+	review.Period = cycle.Code
+	return nil
+}
+
+// findCycleByCode retrieves the cycle with the given code. A missing YYYY-MM cycle is created as a
+// company-wide monthly cycle, or only built without saving it when create is false.
+func findCycleByCode(cycleRepo repositories.ReviewCycleRepository, code string, create bool) (*models.ReviewCycle, error) {
+	cycle, err := cycleRepo.GetByCode(code)
+	if errors.Is(err, gorm.ErrRecordNotFound) && monthlyPeriodPattern.MatchString(code) {
+		if cycle, err = newMonthlyCycle(code); err == nil && create {
+			if err = cycleRepo.Create(cycle); err != nil {
+				// Another request may have created it concurrently
+				cycle, err = cycleRepo.GetByCode(code)
+			}
+		}
+	}
+	if err != nil {
+		return nil, errors.New("考核周期不存在")
+	}
+	return cycle, nil
+}
+
+// checkCycleOpenFor checks that a cycle is still open and covers the user.
+func checkCycleOpenFor(db *gorm.DB, cycle *models.ReviewCycle, userID uint) error {
+	if cycle.IsClosed {
+		return errors.New("该考核周期已关闭")
+	}
+	var user models.User
+	if err := db.First(&user, userID).Error; err != nil {
+		return errors.New("用户不存在")
+	}
+	covers, err := cycleCovers(db, *cycle, &user)
+	if err != nil {
+		return err
+	}
+	if !covers {
+		return errors.New("该考核周期不适用于此员工")
+	}
+	return nil
+}
+
+// newMonthlyCycle builds the company-wide monthly cycle for a YYYY-MM period.
+func newMonthlyCycle(period string) (*models.ReviewCycle, error) {
+	startDate, err := time.ParseInLocation("2006-01", period, time.Local)
+	if err != nil {
+		return nil, err
+	}
+	return &models.ReviewCycle{
+		Name:      period,
+		Code:      period,
+		CycleType: CycleTypeMonthly,
+		StartDate: startDate,
+		EndDate:   startDate.AddDate(0, 1, -1),
+		ScopeType: "all",
+	}, nil
+}
+
 // GetPerformanceReview retrieves a single performance review.
 func (s *performanceReviewService) GetPerformanceReview(reviewID uint) (*models.PerformanceReview, error) {
 	return s.repo.GetByID(reviewID)
@@ -100,6 +225,10 @@ func (s *performanceReviewService) SubmitPerformanceReview(reviewID uint, userID
 		return errors.New("您无权提交此绩效评估")
 	}
 
+	if err := ensureNotCalibrating(review); err != nil {
+		return err
+	}
+
 	// 3. Status check: Only '草稿' reviews can be submitted
 	if review.Status != "草稿" {
 		return errors.New("只有草稿状态的绩效评估才能提交")
@@ -117,11 +246,15 @@ func (s *performanceReviewService) ApprovePerformanceReview(reviewID uint, appro
 		return errors.New("绩效评估不存在")
 	}
 
-	// 2. Permission check: Ensure approver is the correct manager in the chain
-	// This is a simplified check. In a real app, you'd check roles and hierarchy more strictly.
-	// For now, we assume approverID is the direct manager of review.User
-	if review.User.ManagerID == nil || *review.User.ManagerID != approverID {
-		return errors.New("您无权审批此绩效评估")
+	// 2. Permission check: Ensure approver is the direct manager of review.User,
+	// or a delegate acting for that manager through an active delegation.
+	onBehalfOfID, err := s.resolveApprover(review, approverID)
+	if err != nil {
+		return err
+	}
+
+	if err := ensureNotCalibrating(review); err != nil {
+		return err
 	}
 
 	// 3. Status check: Only '待审批' or '待人事确认' can be approved
@@ -145,7 +278,7 @@ func (s *performanceReviewService) ApprovePerformanceReview(reviewID uint, appro
 	}
 
 	// 5. Update status and add approval history
-	return s.repo.UpdateStatusAndAddApproval(reviewID, newStatus, approverID, comment)
+	return s.repo.UpdateStatusAndAddApprovalOnBehalf(reviewID, newStatus, approverID, onBehalfOfID, comment)
 }
 
 // RejectPerformanceReview handles the business logic for rejecting a performance review.
@@ -156,12 +289,91 @@ func (s *performanceReviewService) RejectPerformanceReview(reviewID uint, approv
 		return errors.New("绩效评估不存在")
 	}
 
-	// 2. Permission check (simplified: any manager can reject for now)
-	// In a real app, check if approverID is in the approval chain for this review
+	if err := ensureNotCalibrating(review); err != nil {
+		return err
+	}
 
-	// 3. Update status to '已驳回' and add approval history
-	_ = review // Dummy use to satisfy compiler
-	return s.repo.UpdateStatusAndAddApproval(reviewID, "已驳回", approverID, comment)
+	// 2. Permission check: the approver must be able to act for the owner's manager.
+	// If the approver is acting as a delegate, record whom they acted for.
+	onBehalfOfID, err := s.resolveApprover(review, approverID)
+	if err != nil {
+		return err
+	}
+
+	// 3. Status check: only plans awaiting approval can be rejected; results are changed through appeals
+	if review.Status != "待审批" {
+		return errors.New("只有待审批状态的绩效评估才能驳回")
+	}
+
+	// 4. Update status to '已驳回' and add approval history
+	return s.repo.UpdateStatusAndAddApprovalOnBehalf(reviewID, "已驳回", approverID, onBehalfOfID, comment)
+}
+
+// resolveApprover checks that approverID may act on the review as its owner's manager,
+// either directly, through an active delegation covering the review's owner, or because
+// the review was escalated to them by the SLA scheduler.
+// It returns the manager's ID when the approver is acting for them, or nil otherwise.
+func (s *performanceReviewService) resolveApprover(review *models.PerformanceReview, approverID uint) (*uint, error) {
+	// Nobody may act on their own review, whether through a delegation or an escalation.
+	if approverID == review.UserID {
+		return nil, errors.New("不能审批自己的绩效评估")
+	}
+	managerID := review.User.ManagerID
+	if managerID != nil && *managerID == approverID {
+		return nil, nil
+	}
+
+	if managerID != nil {
+		delegations, err := s.delegationRepo.FindActive(*managerID, approverID, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		for _, delegation := range delegations {
+			if delegationCovers(delegation, &review.User) {
+				return managerID, nil
+			}
+		}
+	}
+
+	// Only an escalation of the current status counts; an old escalation to approve
+	// the review does not let the same user score it later.
+	approvals, err := s.repo.ListApprovals(review.ID)
+	if err != nil {
+		return nil, err
+	}
+	withHistory := *review
+	withHistory.Approvals = approvals
+	if escalatedTo(&withHistory, approverID) {
+		return managerID, nil
+	}
+	return nil, errors.New("您无权审批此绩效评估")
+}
+
+// gradeBands lists the 考核结果 bands from the official form, best first.
+var gradeBands = []string{"优秀", "良好", "一般", "合格", "不合格"}
+
+// gradeBand returns the 考核结果 band for a monthly score M, as defined on the official form.
+func gradeBand(totalScore float64) string {
+	switch {
+	case totalScore > 100:
+		return "优秀"
+	case totalScore >= 90:
+		return "良好"
+	case totalScore >= 80:
+		return "一般"
+	case totalScore >= 60:
+		return "合格"
+	default:
+		return "不合格"
+	}
+}
+
+// ensureNotCalibrating returns an error if the review is locked by an open calibration session.
+func ensureNotCalibrating(review *models.PerformanceReview) error {
+	if review.CalibrationSessionID != nil {
+		return errors.New("该绩效评估正在人事校准中，暂不可操作")
+	}
+	return nil
 }
 
 func calculateGradePoint(totalScore float64) float64 {
@@ -177,14 +389,113 @@ func calculateGradePoint(totalScore float64) float64 {
 	return 0 // Default case
 }
 
+// calculateTotalScore sums each item's score weighted by its percentage weight. Unscored items count as 0.
+func calculateTotalScore(items []models.PerformanceItem) float64 {
+	var totalScore float64 = 0
+	for _, item := range items {
+		if item.Score != nil {
+			totalScore += (item.Weight / 100.0) * (*item.Score)
+		}
+	}
+	return totalScore
+}
+
+// blendDefenseScore folds the 述职答辩 score into an items total using the defense_score_weight
+// setting, a percentage that defaults to 0 (the defense score is recorded but not counted).
+func blendDefenseScore(systemSettingService *SystemSettingService, itemsTotal float64, defenseScore *float64) float64 {
+	weight := settingInt(systemSettingService, SettingDefenseScoreWeight, 0)
+	if defenseScore == nil || weight <= 0 || weight > 100 {
+		return itemsTotal
+	}
+	return itemsTotal*float64(100-weight)/100.0 + (*defenseScore)*float64(weight)/100.0
+}
+
+// reviewResult is a review's total score and grade point, recomputed from its item scores.
+type reviewResult struct {
+	TotalScore      float64
+	GradePoint      float64
+	NormalizedScore *float64 // Set if the review was normalized
+}
+
+// updates returns the review columns that store the result.
+func (r reviewResult) updates() map[string]interface{} {
+	updates := map[string]interface{}{"total_score": r.TotalScore, "grade_point": r.GradePoint}
+	if r.NormalizedScore != nil {
+		updates["normalized_score"] = *r.NormalizedScore
+	}
+	return updates
+}
+
+// recomputeResult derives a review's result from its items total. Every path that changes item or
+// defense scores goes through here, so the 述职答辩 blend, any HR calibration adjustment and any
+// normalization run are applied the same way each time.
+func recomputeResult(db *gorm.DB, systemSettingService *SystemSettingService, review *models.PerformanceReview, itemsTotal float64) (reviewResult, error) {
+	totalScore := blendDefenseScore(systemSettingService, itemsTotal, review.DefenseScore)
+	if review.CalibrationDelta != nil {
+		totalScore = math.Max(0, math.Min(120, totalScore+*review.CalibrationDelta))
+	}
+	result := reviewResult{TotalScore: totalScore, GradePoint: calculateGradePoint(totalScore)}
+	if review.NormalizationRunID != nil {
+		normalized, gradePoint, err := renormalize(db, review, totalScore)
+		if err != nil {
+			return result, err
+		}
+		result.NormalizedScore = &normalized
+		if gradePoint != nil {
+			result.GradePoint = *gradePoint
+		}
+	}
+	return result, nil
+}
+
 // ScorePerformanceReview handles the business logic for scoring a performance review.
-func (s *performanceReviewService) ScorePerformanceReview(reviewID uint, input *ScoreInput) error {
+func (s *performanceReviewService) ScorePerformanceReview(reviewID uint, scorerID uint, input *ScoreInput) error {
 	// 1. Get the existing review with its items
 	review, err := s.repo.GetByID(reviewID)
 	if err != nil {
 		return errors.New("绩效评估不存在")
 	}
 
+	if err := ensureNotCalibrating(review); err != nil {
+		return err
+	}
+
+	// Only approved plans can be scored; completed, appealed and archived results are changed
+	// through their own workflows.
+	if review.Status != "待打分" {
+		return errors.New("只有待打分状态的绩效评估才能打分")
+	}
+
+	// Accepted peer feedback suggestions replace the submitted scores of 价值观 items
+	for i, itemInput := range input.Items {
+		for _, item := range review.Items {
+			if item.ID == itemInput.ID && itemInput.AcceptPeerSuggestion && item.PeerSuggestedScore != nil {
+				input.Items[i].Score = item.PeerSuggestedScore
+			}
+		}
+	}
+
+	// Reviews with assigned evaluators are scored by each evaluator independently and
+	// completed with the weighted scores once everyone has submitted
+	evaluators, err := s.evaluatorRepo.ListByReviewID(reviewID)
+	if err != nil {
+		return err
+	}
+	var onBehalfOfID *uint
+	if len(evaluators) > 0 {
+		combined, err := s.submitEvaluation(review, evaluators, scorerID, input)
+		if err != nil || combined == nil {
+			return err
+		}
+		input = combined
+	} else {
+		// Otherwise the scorer must be able to act for the owner's manager, as when approving
+		onBehalfOfID, err = s.resolveApprover(review, scorerID)
+		if err != nil {
+			return errors.New("您无权为此绩效评估打分")
+		}
+	}
+
 	// Create a map of existing items by their ID for easy lookup
 	itemMap := make(map[uint]*models.PerformanceItem)
 	for i := range review.Items {
@@ -218,17 +529,122 @@ func (s *performanceReviewService) ScorePerformanceReview(reviewID uint, input *
 		})
 	}
 
-	// 3. Calculate grade point
-	gradePoint := calculateGradePoint(totalScore)
+	// 3. Calculate grade point, counting the 述职答辩 score and any calibration adjustment
+	result, err := recomputeResult(s.db, s.systemSettingService, review, totalScore)
+	if err != nil {
+		return err
+	}
 
 	// 4. Update the parent review object
-	review.TotalScore = &totalScore
-	review.GradePoint = &gradePoint
+	scoredAt := time.Now()
+	review.TotalScore = &result.TotalScore
+	review.GradePoint = &result.GradePoint
+	review.NormalizedScore = result.NormalizedScore
 	review.FinalComment = input.FinalComment
 	review.Status = "已完成" // Or another appropriate status
+	review.ScoredAt = &scoredAt
+
+	// 5. Persist changes to the database, recording the scorer in the approval history
+	if err := s.repo.UpdateWithItems(review, itemsToUpdate, &models.ApprovalHistory{
+		ApproverID:   scorerID,
+		OnBehalfOfID: onBehalfOfID,
+		Status:       "已完成",
+		Comment:      "完成打分",
+	}); err != nil {
+		return err
+	}
 
-	// 5. Persist changes to the database
-	return s.repo.UpdateWithItems(review, itemsToUpdate)
+	// 6. Apply the PIP trigger rule to the new result; a failure here must not undo the scoring
+	if _, err := s.improvementPlans.EvaluateReview(reviewID); err != nil {
+		log.Printf("PIP trigger check failed for review %d: %v", reviewID, err)
+	}
+	return nil
+}
+
+// submitEvaluation stores one evaluator's scores for a multi-rater review. Once every evaluator has
+// submitted, it returns the weighted combination as the review's score input; until then it returns nil.
+func (s *performanceReviewService) submitEvaluation(review *models.PerformanceReview, evaluators []models.ReviewEvaluator, scorerID uint, input *ScoreInput) (*ScoreInput, error) {
+	var current *models.ReviewEvaluator
+	for i := range evaluators {
+		if evaluators[i].EvaluatorID == scorerID {
+			current = &evaluators[i]
+		}
+	}
+	if current == nil {
+		return nil, errors.New("您不是此绩效评估的考核人")
+	}
+
+	// 1. Every item must be scored by every evaluator
+	inputMap := make(map[uint]ScoreItemInput)
+	for _, itemInput := range input.Items {
+		inputMap[itemInput.ID] = itemInput
+	}
+	scores := make([]models.EvaluatorItemScore, 0, len(review.Items))
+	for _, item := range review.Items {
+		itemInput, ok := inputMap[item.ID]
+		if !ok || itemInput.Score == nil {
+			return nil, errors.New("请为所有绩效项打分")
+		}
+		if *itemInput.Score < 0 || *itemInput.Score > 120 {
+			return nil, errors.New("单项分数必须在0到120之间")
+		}
+		scores = append(scores, models.EvaluatorItemScore{ItemID: item.ID, Score: *itemInput.Score, CompletionDetails: itemInput.CompletionDetails})
+	}
+
+	// 2. Save this evaluator's submission
+	now := time.Now()
+	current.FinalComment = input.FinalComment
+	current.SubmittedAt = &now
+	current.Scores = scores
+	if err := s.evaluatorRepo.SaveEvaluation(current, scores, &models.ApprovalHistory{
+		ApproverID: scorerID,
+		Status:     "已提交评分", // Not a status of the review, so the time it entered 待打分 is unaffected
+		Comment:    fmt.Sprintf("提交评分（%s，权重%g%%）", current.Role, current.Weight),
+	}); err != nil {
+		return nil, err
+	}
+
+	// 3. Combine once everyone has submitted
+	return combineEvaluations(review.Items, evaluators), nil
+}
+
+// combineEvaluations returns the weighted combination of the evaluators' item scores as a score input,
+// or nil while an evaluator has not submitted. Evaluators are ordered by weight, so the completion
+// details come from the highest-weighted evaluator.
+func combineEvaluations(items []models.PerformanceItem, evaluators []models.ReviewEvaluator) *ScoreInput {
+	var totalWeight float64
+	for _, evaluator := range evaluators {
+		if evaluator.SubmittedAt == nil {
+			return nil
+		}
+		totalWeight += evaluator.Weight
+	}
+	combined := &ScoreInput{}
+	var comments []string
+	for _, evaluator := range evaluators {
+		if evaluator.FinalComment != "" {
+			comments = append(comments, fmt.Sprintf("%s（%s）：%s", evaluator.Evaluator.Name, evaluator.Role, evaluator.FinalComment))
+		}
+	}
+	combined.FinalComment = strings.Join(comments, "\n")
+	for _, item := range items {
+		var weighted float64
+		var completionDetails string
+		for _, evaluator := range evaluators {
+			for _, score := range evaluator.Scores {
+				if score.ItemID != item.ID {
+					continue
+				}
+				weighted += evaluator.Weight * score.Score
+				if completionDetails == "" {
+					completionDetails = score.CompletionDetails
+				}
+			}
+		}
+		itemScore := round2(weighted / totalWeight)
+		combined.Items = append(combined.Items, ScoreItemInput{ID: item.ID, CompletionDetails: completionDetails, Score: &itemScore})
+	}
+	return combined
 }
 
 // GetPerformanceReviewByPeriod retrieves a single performance review for a user and period.
@@ -265,7 +681,14 @@ func (s *performanceReviewService) UpdatePerformanceReview(review *models.Perfor
 		return errors.New("只有“草稿”或“已驳回”状态的绩效评估才能被修改")
 	}
 
-	// 3. Validation for the items
+	// 3. Keep the review in its cycle unless the period was changed
+	if review.CycleID == nil && review.Period == existingReview.Period {
+		review.CycleID = existingReview.CycleID
+	} else if err := s.assignCycle(review); err != nil {
+		return err
+	}
+
+	// 4. Validation for the items
 	var workTotalWeight float64 = 0
 	for _, item := range review.Items {
 		// Not-null validation
@@ -282,7 +705,123 @@ func (s *performanceReviewService) UpdatePerformanceReview(review *models.Perfor
 		return errors.New("“工作业绩”部分的总权重必须等于80%")
 	}
 
-	// 4. Call the repository to update
+	// 5. Call the repository to update
 	return s.repo.Update(review)
 }
 
+// BatchApprovePerformanceReviews approves several reviews. Each review is approved in its own
+// transaction, so one failure does not affect the others.
+func (s *performanceReviewService) BatchApprovePerformanceReviews(input *BatchReviewInput, approverID uint) []BatchResult {
+	results := make([]BatchResult, 0, len(input.ReviewIDs))
+	for _, reviewID := range input.ReviewIDs {
+		results = append(results, newBatchResult(reviewID, s.ApprovePerformanceReview(reviewID, approverID, input.Comment)))
+	}
+	return results
+}
+
+// BatchRejectPerformanceReviews rejects several reviews, each in its own transaction.
+func (s *performanceReviewService) BatchRejectPerformanceReviews(input *BatchReviewInput, approverID uint) []BatchResult {
+	results := make([]BatchResult, 0, len(input.ReviewIDs))
+	for _, reviewID := range input.ReviewIDs {
+		results = append(results, newBatchResult(reviewID, s.RejectPerformanceReview(reviewID, approverID, input.Comment)))
+	}
+	return results
+}
+
+// BatchScorePerformanceReviews scores several reviews, each in its own transaction, with the same
+// checks as scoring a single review.
+func (s *performanceReviewService) BatchScorePerformanceReviews(input *BatchScoreInput, scorerID uint) []BatchResult {
+	results := make([]BatchResult, 0, len(input.Reviews))
+	for i := range input.Reviews {
+		reviewInput := &input.Reviews[i]
+		results = append(results, newBatchResult(reviewInput.ReviewID, s.ScorePerformanceReview(reviewInput.ReviewID, scorerID, &reviewInput.ScoreInput)))
+	}
+	return results
+}
+
+func newBatchResult(reviewID uint, err error) BatchResult {
+	if err != nil {
+		return BatchResult{ReviewID: reviewID, Success: false, Error: err.Error()}
+	}
+	return BatchResult{ReviewID: reviewID, Success: true}
+}
+
+// SettingAutoAcknowledgeDays is the SystemSetting key for the number of days after scoring
+// after which HR may confirm a result the employee has not acknowledged.
+const SettingAutoAcknowledgeDays = "auto_acknowledge_days"
+
+// AcknowledgePerformanceReview records the employee's acceptance of their scored result
+// and moves the review on to HR confirmation.
+func (s *performanceReviewService) AcknowledgePerformanceReview(reviewID uint, userID uint, ip string) error {
+	review, err := s.repo.GetByID(reviewID)
+	if err != nil {
+		return errors.New("绩效评估不存在")
+	}
+	if review.UserID != userID {
+		return errors.New("只能确认自己的考核结果")
+	}
+	if err := ensureNotCalibrating(review); err != nil {
+		return err
+	}
+	if review.Status != "已完成" {
+		return errors.New("只有已完成打分的绩效评估才能确认考核结果")
+	}
+
+	now := time.Now()
+	return s.repo.UpdateFieldsAndAddApproval(reviewID, map[string]interface{}{
+		"status":          "待人事确认",
+		"acknowledged_at": now,
+		"acknowledged_ip": ip,
+	}, &models.ApprovalHistory{
+		ApproverID: userID,
+		Status:     "待人事确认",
+		Comment:    "员工已确认考核结果",
+	})
+}
+
+// HRConfirmPerformanceReview archives a review after HR confirmation. The employee must have
+// acknowledged the result, unless the auto-acknowledge timeout has passed since scoring.
+func (s *performanceReviewService) HRConfirmPerformanceReview(reviewID uint, hrID uint, comment string) error {
+	review, err := s.repo.GetByID(reviewID)
+	if err != nil {
+		return errors.New("绩效评估不存在")
+	}
+
+	if err := ensureNotCalibrating(review); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{
+		"status":             "已归档",
+		"hr_confirmed_at":    now,
+		"hr_confirmed_by_id": hrID,
+	}
+
+	switch review.Status {
+	case "待人事确认":
+		// The employee has acknowledged the result.
+	case "已完成":
+		scoredAt := review.UpdatedAt
+		if review.ScoredAt != nil {
+			scoredAt = *review.ScoredAt
+		}
+		timeoutDays := settingInt(s.systemSettingService, SettingAutoAcknowledgeDays, 7)
+		if now.Before(scoredAt.AddDate(0, 0, timeoutDays)) {
+			return errors.New("员工尚未确认考核结果，且未超过自动确认期限")
+		}
+		updates["acknowledged_at"] = now
+		updates["auto_acknowledged"] = true
+	default:
+		return errors.New("只有待人事确认的绩效评估才能由人事确认")
+	}
+
+	if comment == "" {
+		comment = "人事已确认"
+	}
+	return s.repo.UpdateFieldsAndAddApproval(reviewID, updates, &models.ApprovalHistory{
+		ApproverID: hrID,
+		Status:     "已归档",
+		Comment:    comment,
+	})
+}
@@ -0,0 +1,58 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"cepm-backend/models"
+)
+
+func TestCombineEvaluations(t *testing.T) {
+	submittedAt := time.Date(2025, 8, 1, 9, 0, 0, 0, time.UTC)
+	items := []models.PerformanceItem{{ID: 1}, {ID: 2}}
+	evaluators := []models.ReviewEvaluator{
+		{
+			Evaluator: models.User{Name: "王经理"}, Role: "直属上级", Weight: 70, FinalComment: "按时交付", SubmittedAt: &submittedAt,
+			Scores: []models.EvaluatorItemScore{{ItemID: 1, Score: 100, CompletionDetails: "已上线"}, {ItemID: 2, Score: 80}},
+		},
+		{
+			Evaluator: models.User{Name: "李组长"}, Role: "项目负责人", Weight: 30, SubmittedAt: &submittedAt,
+			Scores: []models.EvaluatorItemScore{{ItemID: 1, Score: 90, CompletionDetails: "项目验收通过"}, {ItemID: 2, Score: 95, CompletionDetails: "协作良好"}},
+		},
+	}
+
+	combined := combineEvaluations(items, evaluators)
+	if combined == nil {
+		t.Fatal("combineEvaluations = nil, want a combination once every evaluator has submitted")
+	}
+	want := []struct {
+		score      float64
+		completion string
+	}{
+		{97, "已上线"},    // 0.7 × 100 + 0.3 × 90
+		{84.5, "协作良好"}, // 0.7 × 80 + 0.3 × 95; the top evaluator left it empty
+	}
+	if len(combined.Items) != len(want) {
+		t.Fatalf("got %d items, want %d", len(combined.Items), len(want))
+	}
+	for i, w := range want {
+		item := combined.Items[i]
+		if item.ID != items[i].ID || item.Score == nil || *item.Score != w.score || item.CompletionDetails != w.completion {
+			t.Errorf("item %d = %+v (score %v), want score %v and completion %q", i, item, item.Score, w.score, w.completion)
+		}
+	}
+	if combined.FinalComment != "王经理（直属上级）：按时交付" {
+		t.Errorf("final comment = %q", combined.FinalComment)
+	}
+
+	// Weights that do not sum to 100 are normalized by their total
+	evaluators[0].Weight, evaluators[1].Weight = 1, 1
+	if got := combineEvaluations(items, evaluators); *got.Items[0].Score != 95 || *got.Items[1].Score != 87.5 {
+		t.Errorf("equal weights = %v, %v, want 95, 87.5", *got.Items[0].Score, *got.Items[1].Score)
+	}
+
+	evaluators[1].SubmittedAt = nil
+	if got := combineEvaluations(items, evaluators); got != nil {
+		t.Errorf("combineEvaluations = %+v, want nil while an evaluator has not submitted", got)
+	}
+}
@@ -0,0 +1,244 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"cepm-backend/database"
+	"cepm-backend/models"
+	"cepm-backend/repositories"
+
+	"gorm.io/gorm"
+)
+
+// SystemSetting keys for the per-state SLAs, in days.
+const (
+	SettingSLAPendingApprovalRemindDays   = "sla_pending_approval_remind_days"
+	SettingSLAPendingApprovalEscalateDays = "sla_pending_approval_escalate_days"
+	SettingSLAPendingScoreRemindDays      = "sla_pending_score_remind_days"
+	SettingSLAPendingScoreEscalateDays    = "sla_pending_score_escalate_days"
+	// SettingSLAEscalationTarget is either "skip_level" (default) or "hr".
+	SettingSLAEscalationTarget = "sla_escalation_target"
+)
+
+// slaRule describes the reminder and escalation thresholds for one review status.
+type slaRule struct {
+	status              string
+	action              string
+	remindKey           string
+	escalateKey         string
+	defaultRemindDays   int
+	defaultEscalateDays int
+}
+
+var slaRules = []slaRule{
+	{status: "待审批", action: "审批", remindKey: SettingSLAPendingApprovalRemindDays, escalateKey: SettingSLAPendingApprovalEscalateDays, defaultRemindDays: 3, defaultEscalateDays: 7},
+	{status: "待打分", action: "打分", remindKey: SettingSLAPendingScoreRemindDays, escalateKey: SettingSLAPendingScoreEscalateDays, defaultRemindDays: 5, defaultEscalateDays: 10},
+}
+
+// SLAService defines the interface for checking pending reviews against their SLAs.
+type SLAService interface {
+	CheckPendingReviews(now time.Time) error
+}
+
+type slaService struct {
+	repo                 repositories.PerformanceReviewRepository
+	reminderRepo         repositories.ReminderRepository
+	systemSettingService *SystemSettingService
+	notificationService  NotificationService
+	db                   *gorm.DB
+}
+
+// NewSLAService creates a new instance of SLAService.
+func NewSLAService(repo repositories.PerformanceReviewRepository, reminderRepo repositories.ReminderRepository, systemSettingService *SystemSettingService, notificationService NotificationService) SLAService {
+	return &slaService{
+		repo:                 repo,
+		reminderRepo:         reminderRepo,
+		systemSettingService: systemSettingService,
+		notificationService:  notificationService,
+		db:                   database.DB,
+	}
+}
+
+// CheckPendingReviews reminds the responsible manager of reviews that have waited longer than
+// the reminder SLA, and escalates reviews that have waited longer than the escalation SLA.
+func (s *slaService) CheckPendingReviews(now time.Time) error {
+	for _, rule := range slaRules {
+		remindDays := settingInt(s.systemSettingService, rule.remindKey, rule.defaultRemindDays)
+		escalateDays := settingInt(s.systemSettingService, rule.escalateKey, rule.defaultEscalateDays)
+
+		reviews, err := s.repo.ListByStatuses([]string{rule.status})
+		if err != nil {
+			return err
+		}
+
+		for i := range reviews {
+			review := &reviews[i]
+			enteredAt := statusEnteredAt(review)
+			waitedDays := int(now.Sub(enteredAt).Hours() / 24)
+
+			var err error
+			switch slaStep(waitedDays, remindDays, escalateDays) {
+			case "escalation":
+				err = s.escalate(review, rule, enteredAt, waitedDays)
+			case "reminder":
+				err = s.remind(review, rule, enteredAt, waitedDays)
+			}
+			if err != nil {
+				log.Printf("SLA check failed for review %d: %v", review.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// remind notifies the review owner's manager once per status entry.
+func (s *slaService) remind(review *models.PerformanceReview, rule slaRule, enteredAt time.Time, waitedDays int) error {
+	if review.User.ManagerID == nil {
+		return nil // Nobody to remind; the review will be escalated once the escalation SLA passes.
+	}
+	sent, err := s.reminderRepo.ExistsSince(review.ID, rule.status, "reminder", enteredAt)
+	if err != nil || sent {
+		return err
+	}
+
+	managerID := *review.User.ManagerID
+	content := fmt.Sprintf("【绩效提醒】%s 的 %s 绩效已等待您%s %d 天，请尽快处理。", review.User.Name, cycleName(review), rule.action, waitedDays)
+	if err := s.notificationService.Notify(managerID, content); err != nil {
+		return err // Not recorded, so the reminder is retried on the next scan.
+	}
+	return s.reminderRepo.Create(&models.ReviewReminder{
+		ReviewID:     review.ID,
+		ReviewStatus: rule.status,
+		Type:         "reminder",
+		RecipientID:  managerID,
+	})
+}
+
+// escalate hands the review to the skip-level manager or HR once per status entry,
+// recording the escalation in the review's approval history.
+func (s *slaService) escalate(review *models.PerformanceReview, rule slaRule, enteredAt time.Time, waitedDays int) error {
+	escalated, err := s.reminderRepo.ExistsSince(review.ID, rule.status, "escalation", enteredAt)
+	if err != nil || escalated {
+		return err
+	}
+
+	target, err := s.escalationTarget(review)
+	if err != nil {
+		return err
+	}
+
+	comment := fmt.Sprintf("%s已超过 %d 天未处理，系统自动升级至 %s", rule.status, waitedDays, target.Name)
+	if err := s.repo.AddApprovalHistory(&models.ApprovalHistory{
+		ReviewID:   review.ID,
+		ApproverID: target.ID,
+		Status:     "已升级",
+		Comment:    comment,
+	}); err != nil {
+		return err
+	}
+	if err := s.reminderRepo.Create(&models.ReviewReminder{
+		ReviewID:     review.ID,
+		ReviewStatus: rule.status,
+		Type:         "escalation",
+		RecipientID:  target.ID,
+	}); err != nil {
+		return err
+	}
+
+	content := fmt.Sprintf("【绩效升级】%s 的 %s 绩效%s已超过 %d 天未处理，已升级由您处理。", review.User.Name, cycleName(review), rule.action, waitedDays)
+	if err := s.notificationService.Notify(target.ID, content); err != nil {
+		log.Printf("Failed to notify escalation target %d for review %d: %v", target.ID, review.ID, err)
+	}
+	return nil
+}
+
+// cycleName returns the name of the review's cycle for notifications, falling back to its period code.
+func cycleName(review *models.PerformanceReview) string {
+	if review.Cycle != nil && review.Cycle.Name != "" {
+		return review.Cycle.Name
+	}
+	return review.Period
+}
+
+// escalationTarget returns the skip-level manager of the review owner, falling back to an HR user.
+func (s *slaService) escalationTarget(review *models.PerformanceReview) (*models.User, error) {
+	target := "skip_level"
+	if setting, err := s.systemSettingService.GetSetting(SettingSLAEscalationTarget); err == nil && setting.Value != "" {
+		target = setting.Value
+	}
+
+	if target == "skip_level" {
+		if skipLevel, err := findSkipLevelManager(s.db, &review.User); err == nil {
+			return skipLevel, nil
+		}
+	}
+
+	hr, err := findHRUser(s.db)
+	if err != nil {
+		return nil, errors.New("找不到可升级的处理人")
+	}
+	return hr, nil
+}
+
+// settingInt reads a positive integer (such as a day count) from SystemSetting,
+// falling back to the default if unset or invalid.
+func settingInt(systemSettingService *SystemSettingService, key string, defaultValue int) int {
+	setting, err := systemSettingService.GetSetting(key)
+	if err != nil {
+		return defaultValue
+	}
+	value, err := strconv.Atoi(setting.Value)
+	if err != nil || value <= 0 {
+		return defaultValue
+	}
+	return value
+}
+
+// slaStep returns the SLA step due after waiting the given number of days: "escalation", "reminder" or "".
+func slaStep(waitedDays, remindDays, escalateDays int) string {
+	switch {
+	case waitedDays >= escalateDays:
+		return "escalation"
+	case waitedDays >= remindDays:
+		return "reminder"
+	default:
+		return ""
+	}
+}
+
+// slaRuleFor returns the SLA rule for a review status, if there is one.
+func slaRuleFor(status string) (slaRule, bool) {
+	for _, rule := range slaRules {
+		if rule.status == status {
+			return rule, true
+		}
+	}
+	return slaRule{}, false
+}
+
+// statusEnteredAt returns when the review entered its current status, based on the approval history.
+// Approvals must be ordered newest first.
+func statusEnteredAt(review *models.PerformanceReview) time.Time {
+	for _, approval := range review.Approvals {
+		if approval.Status == review.Status {
+			return approval.CreatedAt
+		}
+	}
+	return review.UpdatedAt
+}
+
+// escalatedTo reports whether the review was escalated to the user since it entered its current status.
+// Approvals must be ordered newest first.
+func escalatedTo(review *models.PerformanceReview, userID uint) bool {
+	enteredAt := statusEnteredAt(review)
+	for _, approval := range review.Approvals {
+		if approval.Status == "已升级" && approval.ApproverID == userID && approval.CreatedAt.After(enteredAt) {
+			return true
+		}
+	}
+	return false
+}
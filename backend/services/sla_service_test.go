@@ -0,0 +1,75 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"cepm-backend/models"
+)
+
+func TestSLAStep(t *testing.T) {
+	tests := []struct {
+		waitedDays int
+		want       string
+	}{
+		{0, ""},
+		{4, ""},
+		{5, "reminder"},
+		{9, "reminder"},
+		{10, "escalation"},
+		{30, "escalation"},
+	}
+	for _, tt := range tests {
+		if got := slaStep(tt.waitedDays, 5, 10); got != tt.want {
+			t.Errorf("slaStep(%d, 5, 10) = %q, want %q", tt.waitedDays, got, tt.want)
+		}
+	}
+	// An escalation SLA no longer than the reminder SLA escalates straight away
+	if got := slaStep(3, 3, 3); got != "escalation" {
+		t.Errorf("slaStep(3, 3, 3) = %q, want escalation", got)
+	}
+}
+
+func TestStatusEnteredAtAndEscalatedTo(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2025, 7, d, 9, 0, 0, 0, time.UTC) }
+	// Approvals are ordered newest first, as the repository loads them
+	review := &models.PerformanceReview{
+		Status:    "待打分",
+		UpdatedAt: day(20),
+		Approvals: []models.ApprovalHistory{
+			{Status: "已升级", ApproverID: 7, CreatedAt: day(15)},
+			{Status: "待打分", ApproverID: 3, CreatedAt: day(5)},
+			{Status: "已升级", ApproverID: 8, CreatedAt: day(4)},
+			{Status: "待审批", ApproverID: 2, CreatedAt: day(1)},
+		},
+	}
+
+	if got := statusEnteredAt(review); !got.Equal(day(5)) {
+		t.Errorf("statusEnteredAt = %v, want %v", got, day(5))
+	}
+	if !escalatedTo(review, 7) {
+		t.Error("escalatedTo(7) = false, want true for an escalation in the current status")
+	}
+	if escalatedTo(review, 8) {
+		t.Error("escalatedTo(8) = true, want false for an escalation before the review entered its status")
+	}
+	if escalatedTo(review, 3) {
+		t.Error("escalatedTo(3) = true, want false for an approver that was not escalated to")
+	}
+
+	review.Status = "已完成"
+	if got := statusEnteredAt(review); !got.Equal(day(20)) {
+		t.Errorf("statusEnteredAt without a matching approval = %v, want UpdatedAt %v", got, day(20))
+	}
+}
+
+func TestCycleName(t *testing.T) {
+	review := &models.PerformanceReview{Period: "2025-Q3"}
+	if got := cycleName(review); got != "2025-Q3" {
+		t.Errorf("cycleName without a cycle = %q, want the period", got)
+	}
+	review.Cycle = &models.ReviewCycle{Name: "2025年第三季度"}
+	if got := cycleName(review); got != "2025年第三季度" {
+		t.Errorf("cycleName = %q, want the cycle name", got)
+	}
+}
@@ -0,0 +1,344 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	mathrand "math/rand"
+
+	"cepm-backend/database"
+	"cepm-backend/models"
+	"cepm-backend/repositories"
+
+	"gorm.io/gorm"
+)
+
+// Settings for anonymous upward feedback.
+const (
+	SettingUpwardFeedbackQuestions    = "upward_feedback_questions"     // JSON array of questions, each rated 1-5
+	SettingUpwardFeedbackMinResponses = "upward_feedback_min_responses" // Responses needed before results are shown, default 3
+)
+
+// defaultUpwardFeedbackQuestions are used when the setting is missing or invalid.
+var defaultUpwardFeedbackQuestions = []string{
+	"上级为我设定了清晰的工作目标",
+	"上级及时给予我有帮助的反馈",
+	"上级支持我的成长与发展",
+	"上级公平公正地评价团队成员",
+	"上级营造了开放、信任的团队氛围",
+}
+
+// UpwardFeedbackAnswerInput is the rating of one question.
+type UpwardFeedbackAnswerInput struct {
+	Question string `json:"question"`
+	Rating   int    `json:"rating"` // 1-5
+}
+
+// UpwardFeedbackInput defines the structure for rating one's direct manager.
+type UpwardFeedbackInput struct {
+	Period  string                      `json:"period"`
+	Answers []UpwardFeedbackAnswerInput `json:"answers"`
+	Comment string                      `json:"comment"`
+}
+
+// UpwardQuestionnaire is what an employee needs to rate their manager for a period.
+type UpwardQuestionnaire struct {
+	Period      string   `json:"period"`
+	ManagerID   uint     `json:"managerId"`
+	ManagerName string   `json:"managerName"`
+	Questions   []string `json:"questions"`
+	Submitted   bool     `json:"submitted"`
+}
+
+// ManagerFeedbackResult aggregates the upward feedback on one manager for a period. Averages and
+// comments are withheld (Suppressed) until enough responses exist that no answer can be identified.
+type ManagerFeedbackResult struct {
+	ManagerID       uint                          `json:"managerId"`
+	ManagerName     string                        `json:"managerName"`
+	Period          string                        `json:"period"`
+	TeamSize        int                           `json:"teamSize"`
+	ResponseCount   int                           `json:"responseCount"`
+	ResponseRate    float64                       `json:"responseRate"`
+	Suppressed      bool                          `json:"suppressed"`
+	OverallAverage  *float64                      `json:"overallAverage"`
+	QuestionResults []PeerFeedbackQuestionSummary `json:"questionResults"`
+	Comments        []string                      `json:"comments"`
+}
+
+// UpwardFeedbackService defines the interface for anonymous upward feedback.
+type UpwardFeedbackService interface {
+	GetQuestionnaire(userID uint, period string) (*UpwardQuestionnaire, error)
+	SubmitFeedback(userID uint, input *UpwardFeedbackInput) error
+	GetManagerResult(managerID uint, period string) (*ManagerFeedbackResult, error)
+	GetEffectivenessReport(period string) ([]ManagerFeedbackResult, error)
+}
+
+type upwardFeedbackService struct {
+	repo                 repositories.UpwardFeedbackRepository
+	cycleRepo            repositories.ReviewCycleRepository
+	systemSettingService *SystemSettingService
+	secret               []byte // HMAC key for submission tokens; kept out of the database so tokens cannot be recomputed from it
+	db                   *gorm.DB
+}
+
+// NewUpwardFeedbackService creates a new instance of UpwardFeedbackService.
+// The secret is the HMAC key for submission tokens and must not change while periods are open.
+func NewUpwardFeedbackService(repo repositories.UpwardFeedbackRepository, cycleRepo repositories.ReviewCycleRepository, systemSettingService *SystemSettingService, secret []byte) (UpwardFeedbackService, error) {
+	if len(secret) < 32 {
+		return nil, errors.New("upward feedback token secret must be at least 32 bytes")
+	}
+	return &upwardFeedbackService{repo: repo, cycleRepo: cycleRepo, systemSettingService: systemSettingService, secret: secret, db: database.DB}, nil
+}
+
+// GetQuestionnaire returns the questionnaire for rating the user's direct manager and whether it was already answered.
+func (s *upwardFeedbackService) GetQuestionnaire(userID uint, period string) (*UpwardQuestionnaire, error) {
+	if _, err := findCycleByCode(s.cycleRepo, period, false); err != nil {
+		return nil, err
+	}
+	manager, err := s.directManager(userID)
+	if err != nil {
+		return nil, err
+	}
+	token := s.token(userID, manager.ID, period)
+	submitted, err := s.repo.TokenExists(token)
+	if err != nil {
+		return nil, err
+	}
+	return &UpwardQuestionnaire{Period: period, ManagerID: manager.ID, ManagerName: manager.Name, Questions: s.questions(), Submitted: submitted}, nil
+}
+
+// SubmitFeedback stores an anonymous rating of the user's direct manager. Each employee can rate
+// their manager once per period, while the period's cycle is open; the response itself records
+// neither the employee nor the time.
+func (s *upwardFeedbackService) SubmitFeedback(userID uint, input *UpwardFeedbackInput) error {
+	cycle, err := findCycleByCode(s.cycleRepo, input.Period, false)
+	if err != nil {
+		return err
+	}
+	if cycle.IsClosed {
+		return errors.New("该考核周期已关闭，不能再提交评价")
+	}
+	manager, err := s.directManager(userID)
+	if err != nil {
+		return err
+	}
+
+	// 1. Every question must be rated
+	ratings := make(map[string]int)
+	for _, answer := range input.Answers {
+		if answer.Rating < 1 || answer.Rating > 5 {
+			return errors.New("评分必须在1到5之间")
+		}
+		ratings[answer.Question] = answer.Rating
+	}
+	questions := s.questions()
+	answers := make([]models.UpwardFeedbackAnswer, 0, len(questions))
+	for _, question := range questions {
+		rating, ok := ratings[question]
+		if !ok {
+			return errors.New("请回答所有问题")
+		}
+		answers = append(answers, models.UpwardFeedbackAnswer{Question: question, Rating: rating})
+	}
+
+	// 2. One submission per employee, manager and period
+	token := s.token(userID, manager.ID, input.Period)
+	submitted, err := s.repo.TokenExists(token)
+	if err != nil {
+		return err
+	}
+	if submitted {
+		return errors.New("您已评价过本周期的上级")
+	}
+
+	return s.repo.CreateWithToken(
+		&models.UpwardFeedbackToken{Token: token, ManagerID: manager.ID, Period: input.Period},
+		&models.UpwardFeedbackResponse{ManagerID: manager.ID, Period: input.Period, Comment: input.Comment, Answers: answers},
+	)
+}
+
+// GetManagerResult returns the released feedback on a manager for a closed period.
+func (s *upwardFeedbackService) GetManagerResult(managerID uint, period string) (*ManagerFeedbackResult, error) {
+	var manager models.User
+	if err := s.db.First(&manager, managerID).Error; err != nil {
+		return nil, errors.New("用户不存在")
+	}
+	results, err := s.releasedResults(period, &managerID)
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		result := s.aggregate(manager, period, 0, nil) // Nobody rated this manager in the period
+		return &result, nil
+	}
+	return &results[0], nil
+}
+
+// GetEffectivenessReport returns the released feedback on every manager for a closed period, for HR.
+func (s *upwardFeedbackService) GetEffectivenessReport(period string) ([]ManagerFeedbackResult, error) {
+	return s.releasedResults(period, nil)
+}
+
+// releasedResults returns the frozen results of a period, optionally for a single manager. Results
+// are only released once the period's cycle is closed, and are frozen on first request.
+func (s *upwardFeedbackService) releasedResults(period string, managerID *uint) ([]ManagerFeedbackResult, error) {
+	cycle, err := findCycleByCode(s.cycleRepo, period, false)
+	if err != nil {
+		return nil, err
+	}
+	if !cycle.IsClosed {
+		return nil, errors.New("考核周期结束后才能查看上级评价结果")
+	}
+
+	frozen, err := s.repo.ListResults(period, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(frozen) == 0 {
+		if err := s.freeze(period); err != nil {
+			return nil, err
+		}
+	}
+	if frozen, err = s.repo.ListResults(period, managerID); err != nil {
+		return nil, err
+	}
+	results := make([]ManagerFeedbackResult, 0, len(frozen))
+	for _, record := range frozen {
+		var result ManagerFeedbackResult
+		if err := json.Unmarshal([]byte(record.Result), &result); err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// freeze aggregates the responses of a period for every manager with a team or with responses, and stores the results.
+func (s *upwardFeedbackService) freeze(period string) error {
+	var teamSizes []struct {
+		ManagerID uint
+		Count     int
+	}
+	if err := s.db.Model(&models.User{}).Select("manager_id, count(*) as count").
+		Where("manager_id IS NOT NULL AND is_active = ?", true).Group("manager_id").Scan(&teamSizes).Error; err != nil {
+		return err
+	}
+	responses, err := s.repo.ListResponses(period, nil)
+	if err != nil {
+		return err
+	}
+	sizes := make(map[uint]int)
+	var managerIDs []uint
+	for _, teamSize := range teamSizes {
+		sizes[teamSize.ManagerID] = teamSize.Count
+		managerIDs = append(managerIDs, teamSize.ManagerID)
+	}
+	byManager := make(map[uint][]models.UpwardFeedbackResponse)
+	for _, response := range responses {
+		if _, ok := sizes[response.ManagerID]; !ok {
+			sizes[response.ManagerID] = 0 // The manager no longer has an active team
+			managerIDs = append(managerIDs, response.ManagerID)
+		}
+		byManager[response.ManagerID] = append(byManager[response.ManagerID], response)
+	}
+
+	records := make([]models.UpwardFeedbackResult, 0, len(managerIDs))
+	for _, managerID := range managerIDs {
+		var manager models.User
+		if err := s.db.First(&manager, managerID).Error; err != nil {
+			continue
+		}
+		result, err := json.Marshal(s.aggregate(manager, period, sizes[managerID], byManager[managerID]))
+		if err != nil {
+			return err
+		}
+		records = append(records, models.UpwardFeedbackResult{ManagerID: managerID, Period: period, Result: string(result)})
+	}
+	return s.repo.SaveResults(records)
+}
+
+// aggregate summarizes a manager's responses, withholding averages and comments below the minimum.
+func (s *upwardFeedbackService) aggregate(manager models.User, period string, teamSize int, responses []models.UpwardFeedbackResponse) ManagerFeedbackResult {
+	result := ManagerFeedbackResult{
+		ManagerID:       manager.ID,
+		ManagerName:     manager.Name,
+		Period:          period,
+		TeamSize:        teamSize,
+		ResponseCount:   len(responses),
+		QuestionResults: []PeerFeedbackQuestionSummary{},
+		Comments:        []string{},
+	}
+	if teamSize > 0 {
+		result.ResponseRate = round2(float64(len(responses)) / float64(teamSize) * 100)
+	}
+	if len(responses) < settingInt(s.systemSettingService, SettingUpwardFeedbackMinResponses, 3) {
+		result.Suppressed = true
+		return result
+	}
+
+	questionRatings := make(map[string][]float64)
+	var questionOrder []string
+	var all []float64
+	for _, response := range responses {
+		for _, answer := range response.Answers {
+			if questionRatings[answer.Question] == nil {
+				questionOrder = append(questionOrder, answer.Question)
+			}
+			questionRatings[answer.Question] = append(questionRatings[answer.Question], float64(answer.Rating))
+			all = append(all, float64(answer.Rating))
+		}
+		if response.Comment != "" {
+			result.Comments = append(result.Comments, response.Comment)
+		}
+	}
+	for _, question := range questionOrder {
+		result.QuestionResults = append(result.QuestionResults, PeerFeedbackQuestionSummary{Question: question, AverageRating: round2(scoreStats(questionRatings[question]).Mean)})
+	}
+	if len(all) > 0 {
+		overall := round2(scoreStats(all).Mean)
+		result.OverallAverage = &overall
+	}
+	// Shuffle comments so their order does not hint at who wrote them
+	mathrand.Shuffle(len(result.Comments), func(i, j int) {
+		result.Comments[i], result.Comments[j] = result.Comments[j], result.Comments[i]
+	})
+	return result
+}
+
+// directManager returns the user's direct manager.
+func (s *upwardFeedbackService) directManager(userID uint) (*models.User, error) {
+	var user models.User
+	if err := s.db.Preload("Manager").First(&user, userID).Error; err != nil {
+		return nil, errors.New("用户不存在")
+	}
+	if user.Manager == nil {
+		return nil, errors.New("您没有直属上级")
+	}
+	return user.Manager, nil
+}
+
+// questions reads the questionnaire from settings, falling back to the default.
+func (s *upwardFeedbackService) questions() []string {
+	if setting, err := s.systemSettingService.GetSetting(SettingUpwardFeedbackQuestions); err == nil && setting.Value != "" {
+		var questions []string
+		if err := json.Unmarshal([]byte(setting.Value), &questions); err == nil && len(questions) > 0 {
+			return questions
+		}
+	}
+	return defaultUpwardFeedbackQuestions
+}
+
+// token derives the one-way submission token of a respondent, manager and period.
+func (s *upwardFeedbackService) token(userID uint, managerID uint, period string) string {
+	return upwardFeedbackToken(s.secret, userID, managerID, period)
+}
+
+// upwardFeedbackToken computes HMAC-SHA256(secret, "userID:managerID:period") as hex.
+func upwardFeedbackToken(secret []byte, userID uint, managerID uint, period string) string {
+	mac := hmac.New(sha256.New, secret)
+	fmt.Fprintf(mac, "%d:%d:%s", userID, managerID, period)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,38 @@
+package services
+
+import "testing"
+
+func TestUpwardFeedbackToken(t *testing.T) {
+	secret := []byte("0123456789abcdef0123456789abcdef")
+	base := upwardFeedbackToken(secret, 7, 3, "2025-Q3")
+
+	// HMAC-SHA256 of "7:3:2025-Q3"
+	if want := "5273618bd310fe8db35dd2b47421912b34bd5680ad21db9ebea83030cb7f21d7"; base != want {
+		t.Fatalf("token = %s, want %s", base, want)
+	}
+	if again := upwardFeedbackToken(secret, 7, 3, "2025-Q3"); again != base {
+		t.Fatalf("token is not deterministic: %s != %s", again, base)
+	}
+
+	tests := []struct {
+		name      string
+		secret    []byte
+		userID    uint
+		managerID uint
+		period    string
+	}{
+		{"other secret", []byte("fedcba9876543210fedcba9876543210"), 7, 3, "2025-Q3"},
+		{"other user", secret, 8, 3, "2025-Q3"},
+		{"other manager", secret, 7, 4, "2025-Q3"},
+		{"other period", secret, 7, 3, "2025-Q4"},
+		{"user and manager swapped", secret, 3, 7, "2025-Q3"},
+		{"digits moved across the separator", secret, 73, 0, "2025-Q3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := upwardFeedbackToken(tt.secret, tt.userID, tt.managerID, tt.period); got == base {
+				t.Errorf("token collides with the base token: %s", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package storage
+
+import (
+	"fmt"
+	"io"
+
+	"cepm-backend/config"
+)
+
+// Storage stores attachment files by key.
+type Storage interface {
+	Put(key string, data []byte, contentType string) error
+	Get(key string) (io.ReadCloser, error)
+	Delete(key string) error
+}
+
+// New creates the Storage selected by the configured driver: "local" (the default) or "s3".
+func New(cfg *config.StorageConfig) (Storage, error) {
+	switch cfg.Driver {
+	case "", "local":
+		return NewLocalStorage(cfg.LocalPath), nil
+	case "s3":
+		return NewS3Storage(&cfg.S3), nil
+	default:
+		return nil, fmt.Errorf("unknown storage driver: %s", cfg.Driver)
+	}
+}
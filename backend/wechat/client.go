@@ -1,10 +1,12 @@
 package wechat
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
 
@@ -16,6 +18,7 @@ const (
 	accessTokenURL    = wechatWorkAPIHost + "/gettoken?corpid=%s&corpsecret=%s"
 	userInfoURL       = wechatWorkAPIHost + "/user/getuserinfo?access_token=%s&code=%s"
 	userDetailURL     = wechatWorkAPIHost + "/user/get?access_token=%s&userid=%s"
+	messageSendURL    = wechatWorkAPIHost + "/message/send?access_token=%s"
 )
 
 // AccessTokenResponse defines the structure of the access token API response.
@@ -47,6 +50,26 @@ type UserDetailResponse struct {
 	// Add other fields you might need
 }
 
+// TextMessageRequest defines the request body for sending an application text message.
+type TextMessageRequest struct {
+	ToUser  string      `json:"touser"`
+	MsgType string      `json:"msgtype"`
+	AgentID int64       `json:"agentid"`
+	Text    TextContent `json:"text"`
+}
+
+// TextContent defines the content of a text message.
+type TextContent struct {
+	Content string `json:"content"`
+}
+
+// MessageSendResponse defines the structure of the message send API response.
+type MessageSendResponse struct {
+	ErrCode     int    `json:"errcode"`
+	ErrMsg      string `json:"errmsg"`
+	InvalidUser string `json:"invaliduser"`
+}
+
 // WechatClient manages interactions with the WeChat Work API.
 type WechatClient struct {
 	corpID      string
@@ -164,3 +187,44 @@ func (c *WechatClient) GetUserDetail(userid string) (*UserDetailResponse, error)
 
 	return &userDetailResp, nil
 }
+
+// SendTextMessage sends an application text message to the given WeChat Work userids.
+func (c *WechatClient) SendTextMessage(userids []string, content string) error {
+	accessToken, err := c.GetAccessToken()
+	if err != nil {
+		return err
+	}
+
+	reqBody, err := json.Marshal(TextMessageRequest{
+		ToUser:  strings.Join(userids, "|"),
+		MsgType: "text",
+		AgentID: c.agentID,
+		Text:    TextContent{Content: content},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal message request: %w", err)
+	}
+
+	url := fmt.Sprintf(messageSendURL, accessToken)
+	resp, err := http.Post(url, "application/json", bytes.NewReader(reqBody))
+	if err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read message send response: %w", err)
+	}
+
+	var sendResp MessageSendResponse
+	if err := json.Unmarshal(body, &sendResp); err != nil {
+		return fmt.Errorf("failed to unmarshal message send response: %w", err)
+	}
+
+	if sendResp.ErrCode != 0 {
+		return fmt.Errorf("wechat work API error (%d): %s", sendResp.ErrCode, sendResp.ErrMsg)
+	}
+
+	return nil
+}
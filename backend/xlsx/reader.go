@@ -0,0 +1,233 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// maxPartSize limits the uncompressed size of a workbook part, guarding against zip bombs.
+const maxPartSize = 64 << 20
+
+// Sheet is a worksheet read as a grid of cell texts. Rows[0][0] is cell A1. The covered cells of
+// a merged range repeat the value of its top-left cell.
+type Sheet struct {
+	Name string
+	Rows [][]string
+}
+
+// Cell returns the text of a cell by zero-based row and column, or "" outside the grid.
+func (s *Sheet) Cell(row int, col int) string {
+	if row < 0 || row >= len(s.Rows) || col < 0 || col >= len(s.Rows[row]) {
+		return ""
+	}
+	return s.Rows[row][col]
+}
+
+// ReadWorkbook reads the sheets of an .xlsx workbook. Numbers are returned as written in the file,
+// booleans as TRUE/FALSE and formulas as their cached values.
+func ReadWorkbook(data []byte) ([]Sheet, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, errors.New("无法读取Excel文件，请上传.xlsx格式的工作簿")
+	}
+	files := make(map[string]*zip.File)
+	for _, f := range zr.File {
+		files[strings.TrimPrefix(f.Name, "/")] = f
+	}
+
+	var workbook struct {
+		Sheets []struct {
+			Name string `xml:"name,attr"`
+			RID  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err := decodePart(files, "xl/workbook.xml", &workbook); err != nil {
+		return nil, err
+	}
+	var rels struct {
+		Relationships []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if err := decodePart(files, "xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return nil, err
+	}
+	targets := make(map[string]string)
+	for _, rel := range rels.Relationships {
+		if strings.HasPrefix(rel.Target, "/") {
+			targets[rel.ID] = strings.TrimPrefix(rel.Target, "/")
+		} else {
+			targets[rel.ID] = path.Join("xl", rel.Target)
+		}
+	}
+
+	var sharedStrings []string
+	if _, ok := files["xl/sharedStrings.xml"]; ok {
+		var sst struct {
+			Items []richString `xml:"si"`
+		}
+		if err := decodePart(files, "xl/sharedStrings.xml", &sst); err != nil {
+			return nil, err
+		}
+		for _, item := range sst.Items {
+			sharedStrings = append(sharedStrings, item.text())
+		}
+	}
+
+	sheets := make([]Sheet, 0, len(workbook.Sheets))
+	for _, entry := range workbook.Sheets {
+		target, ok := targets[entry.RID]
+		if !ok {
+			return nil, fmt.Errorf("工作表%s不存在", entry.Name)
+		}
+		rows, err := readSheet(files, target, sharedStrings)
+		if err != nil {
+			return nil, err
+		}
+		sheets = append(sheets, Sheet{Name: entry.Name, Rows: rows})
+	}
+	return sheets, nil
+}
+
+// richString is a shared or inline string, either plain or made of formatted runs.
+type richString struct {
+	T    string `xml:"t"`
+	Runs []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (s richString) text() string {
+	if len(s.Runs) == 0 {
+		return s.T
+	}
+	var b strings.Builder
+	for _, run := range s.Runs {
+		b.WriteString(run.T)
+	}
+	return b.String()
+}
+
+func readSheet(files map[string]*zip.File, name string, sharedStrings []string) ([][]string, error) {
+	var worksheet struct {
+		Rows []struct {
+			R     int `xml:"r,attr"`
+			Cells []struct {
+				R  string     `xml:"r,attr"`
+				T  string     `xml:"t,attr"`
+				V  string     `xml:"v"`
+				Is richString `xml:"is"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+		MergeCells []struct {
+			Ref string `xml:"ref,attr"`
+		} `xml:"mergeCells>mergeCell"`
+	}
+	if err := decodePart(files, name, &worksheet); err != nil {
+		return nil, err
+	}
+
+	var rows [][]string
+	set := func(row int, col int, value string) {
+		for len(rows) <= row {
+			rows = append(rows, nil)
+		}
+		for len(rows[row]) <= col {
+			rows[row] = append(rows[row], "")
+		}
+		rows[row][col] = value
+	}
+
+	nextRow := 0
+	for _, r := range worksheet.Rows {
+		row := nextRow
+		if r.R > 0 {
+			row = r.R - 1
+		}
+		nextRow = row + 1
+		nextCol := 0
+		for _, c := range r.Cells {
+			col := nextCol
+			if c.R != "" {
+				var err error
+				if col, _, err = parseRef(c.R); err != nil {
+					return nil, err
+				}
+			}
+			nextCol = col + 1
+
+			var value string
+			switch c.T {
+			case "s":
+				index, err := strconv.Atoi(strings.TrimSpace(c.V))
+				if err != nil || index < 0 || index >= len(sharedStrings) {
+					return nil, fmt.Errorf("单元格%s的共享字符串无效", c.R)
+				}
+				value = sharedStrings[index]
+			case "inlineStr":
+				value = c.Is.text()
+			case "b":
+				value = map[string]string{"1": "TRUE", "0": "FALSE"}[c.V]
+			default:
+				value = c.V
+			}
+			if value != "" {
+				set(row, col, value)
+			}
+		}
+	}
+
+	// Repeat the top-left value of merged ranges across the covered cells
+	for _, merge := range worksheet.MergeCells {
+		bounds := strings.Split(merge.Ref, ":")
+		if len(bounds) != 2 {
+			continue
+		}
+		firstCol, firstRow, err1 := parseRef(bounds[0])
+		lastCol, lastRow, err2 := parseRef(bounds[1])
+		if err1 != nil || err2 != nil || firstRow >= len(rows) || firstCol >= len(rows[firstRow]) {
+			continue
+		}
+		value := rows[firstRow][firstCol]
+		for row := firstRow; row <= lastRow; row++ {
+			for col := firstCol; col <= lastCol; col++ {
+				set(row, col, value)
+			}
+		}
+	}
+	return rows, nil
+}
+
+// parseRef parses a cell reference such as "B12" into zero-based column and row indexes.
+func parseRef(ref string) (int, int, error) {
+	col, i := 0, 0
+	for ; i < len(ref) && ref[i] >= 'A' && ref[i] <= 'Z'; i++ {
+		col = col*26 + int(ref[i]-'A'+1)
+	}
+	row, err := strconv.Atoi(ref[i:])
+	if col == 0 || err != nil || row < 1 {
+		return 0, 0, fmt.Errorf("无效的单元格引用: %s", ref)
+	}
+	return col - 1, row - 1, nil
+}
+
+func decodePart(files map[string]*zip.File, name string, v interface{}) error {
+	f, ok := files[name]
+	if !ok {
+		return fmt.Errorf("Excel文件缺少%s", name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return xml.NewDecoder(io.LimitReader(rc, maxPartSize)).Decode(v)
+}
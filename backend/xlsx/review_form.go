@@ -0,0 +1,289 @@
+package xlsx
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ReviewForm is a review rendered in the 附件1 月度绩效考核表 layout.
+type ReviewForm struct {
+	SheetName          string
+	Title              string // e.g. 2025年 7 月    月度绩效考核表
+	EmployeeName       string
+	EmployeeDepartment string
+	EmployeePosition   string
+	HireDate           *time.Time
+	DefenseScore       *float64
+	ReviewerName       string
+	ReviewerDepartment string
+	ReviewerPosition   string
+	Categories         []FormCategory
+	TotalScore         *float64 // 月度绩效得分M
+	GradePoint         *float64 // 月度考核系数n
+}
+
+// FormCategory is a 考核指标类别 with its items, e.g. 工作业绩.
+type FormCategory struct {
+	Label string // e.g. "工作业绩\n80%"
+	Items []FormItem
+}
+
+// FormItem is a single row of the form.
+type FormItem struct {
+	Title      string   // 考核指标
+	Standard   string   // 考核标准
+	Weight     float64  // 权重 as a fraction, e.g. 0.1
+	Completion string   // 完成情况
+	Score      *float64 // 考核人评分: the item's contribution to M
+}
+
+// Column widths of the template, in characters.
+var formColumns = []float64{11.625, 19.75, 12.125, 21.625, 11.25, 15.625, 16.5583333333333, 13.625}
+
+// The result and explanation runs of the template, in the template's fonts.
+const (
+	resultRunProps      = `<rPr><sz val="9"/><color theme="1"/><rFont val="微软雅黑"/><charset val="134"/></rPr>`
+	resultUnderlineRuns = `<rPr><u/><sz val="9"/><color theme="1"/><rFont val="微软雅黑"/><charset val="134"/></rPr>`
+	explanationRuns     = `<r><t xml:space="preserve">① 工作业绩：主要参照部门月度工作计划、业绩指标进行考核。工作业绩考核须包含工作结果指标及工作过程指标；指标权重 80%；&#10;② </t></r>` +
+		`<r><rPr><sz val="9"/><rFont val="微软雅黑"/><charset val="134"/></rPr><t>大模型：根据员工实际使用大模型的场景描述，评估其应用效能；指标权重 10%；</t></r>` +
+		`<r><rPr><sz val="9"/><color theme="1"/><rFont val="微软雅黑"/><charset val="134"/></rPr><t xml:space="preserve">&#10;③ 价值观：通过员工的工作行为，分析其是否符合公司价值观导向；指标权重 10%；&#10;④ 月度绩效得分M=工作业绩得分+大模型得分+价值观得分&#10;</t></r>` +
+		`<r><rPr><sz val="9"/><color theme="1"/><rFont val="Microsoft YaHei"/><charset val="134"/></rPr><t>⑤</t></r>` +
+		`<r><rPr><sz val="9"/><color theme="1"/><rFont val="微软雅黑"/><charset val="134"/></rPr><t xml:space="preserve"> 月度考核系数n：&#10;     ￭ 优秀（M＞100） n=M%     ￭ 良好（90≤M≤100）n=1.0     ￭ 一般（80≤M＜90）n=0.8     ￭ 合格 （60≤M＜80）n=0.5     ￭ 不合格 （M＜60）n=0</t></r>`
+)
+
+// WriteReviewForms writes a workbook with one 月度绩效考核表 sheet per form.
+func WriteReviewForms(w io.Writer, forms []ReviewForm) error {
+	sheets := make([]sheet, 0, len(forms))
+	for i := range forms {
+		sheets = append(sheets, sheet{name: forms[i].SheetName, xml: renderReviewForm(&forms[i], i == 0)})
+	}
+	return writeWorkbook(w, sheets)
+}
+
+// renderReviewForm renders the worksheet part of a form. The item rows grow with the number of items;
+// every other row keeps the template's cells, styles and merges.
+func renderReviewForm(form *ReviewForm, selected bool) []byte {
+	var b cellBuilder
+	var merges []string
+
+	// Rows 1-4: title, the employee and reviewer header block and the column headings
+	b.startRow(1, 45)
+	b.text("A1", 5, form.Title)
+	for _, col := range "BCDEFGH" {
+		b.blank(fmt.Sprintf("%c1", col), 6)
+	}
+	b.endRow()
+	merges = append(merges, "A1:H1")
+
+	b.startRow(2, 22)
+	b.text("A2", 7, "被考核人姓名")
+	b.text("B2", 8, form.EmployeeName)
+	b.text("C2", 9, "被考核人部门")
+	b.text("D2", 10, form.EmployeeDepartment)
+	b.text("E2", 9, "被考核人职位")
+	b.text("F2", 11, form.EmployeePosition)
+	b.text("G2", 9, "被考核人述职答辩成绩")
+	b.number("H2", 12, form.DefenseScore)
+	b.endRow()
+
+	b.startRow(3, 22)
+	b.text("A3", 13, "考核人姓名")
+	b.text("B3", 14, form.ReviewerName)
+	b.text("C3", 15, "考核人部门")
+	b.text("D3", 16, form.ReviewerDepartment)
+	b.text("E3", 15, "考核人职位")
+	b.text("F3", 17, form.ReviewerPosition)
+	b.text("G3", 18, "被考核人入职日期")
+	b.date("H3", 19, form.HireDate)
+	b.endRow()
+
+	b.startRow(4, 22)
+	b.text("A4", 20, "考核指标类别")
+	b.text("B4", 18, "考核指标")
+	b.text("C4", 18, "考核标准")
+	b.blank("D4", 18)
+	b.text("E4", 18, "权重")
+	b.text("F4", 18, "完成情况")
+	b.blank("G4", 18)
+	b.text("H4", 21, "考核人评分")
+	b.endRow()
+	merges = append(merges, "C4:D4", "F4:G4")
+
+	// Item rows, grouped by category; a category without items keeps one empty row
+	row := 5
+	var weightTotal, scoreTotal float64
+	for _, category := range form.Categories {
+		items := category.Items
+		if len(items) == 0 {
+			items = []FormItem{{}}
+		}
+		first := row
+		for i, item := range items {
+			height := rowHeight(22, map[int]string{1: item.Title, 2: item.Standard, 5: item.Completion}, map[int]int{2: 2, 5: 2})
+			if i == 0 {
+				height = max(height, rowHeight(22, map[int]string{0: category.Label}, nil)/float64(len(items)))
+			}
+			b.startRow(row, height)
+			if i == 0 {
+				b.text(ref("A", row), 22, category.Label)
+			} else {
+				b.blank(ref("A", row), 22)
+			}
+			b.text(ref("B", row), 28, item.Title)
+			b.text(ref("C", row), 24, item.Standard)
+			b.blank(ref("D", row), 24)
+			if item.Title != "" {
+				weight := item.Weight
+				b.number(ref("E", row), 25, &weight)
+				weightTotal += weight
+			} else {
+				b.blank(ref("E", row), 25)
+			}
+			b.text(ref("F", row), 24, item.Completion)
+			b.blank(ref("G", row), 24)
+			b.number(ref("H", row), 26, item.Score)
+			if item.Score != nil {
+				scoreTotal += *item.Score
+			}
+			b.endRow()
+			merges = append(merges, fmt.Sprintf("C%d:D%d", row, row), fmt.Sprintf("F%d:G%d", row, row))
+			row++
+		}
+		if row-1 > first {
+			merges = append(merges, fmt.Sprintf("A%d:A%d", first, row-1))
+		}
+	}
+	lastItemRow := row - 1
+
+	// 合计 row
+	b.startRow(row, 22)
+	b.text(ref("A", row), 30, "合计")
+	for _, col := range []string{"B", "C", "D"} {
+		b.blank(ref(col, row), 31)
+	}
+	b.formula(ref("E", row), 32, fmt.Sprintf("SUM(E5:E%d)", lastItemRow), round2(weightTotal))
+	b.blank(ref("F", row), 33)
+	b.blank(ref("G", row), 33)
+	b.formula(ref("H", row), 34, fmt.Sprintf("SUM(H5:H%d)", lastItemRow), round2(scoreTotal))
+	b.endRow()
+	merges = append(merges, fmt.Sprintf("A%d:D%d", row, row), fmt.Sprintf("F%d:G%d", row, row))
+	row++
+
+	// 考核结果 row with M and n filled into the underlined blanks
+	b.startRow(row, 32)
+	b.text(ref("A", row), 30, "考核结果")
+	b.richText(ref("B", row), 35, resultRuns(form.TotalScore, form.GradePoint))
+	for _, col := range []string{"C", "D", "E", "F", "G"} {
+		b.blank(ref(col, row), 35)
+	}
+	b.blank(ref("H", row), 36)
+	b.endRow()
+	merges = append(merges, fmt.Sprintf("B%d:H%d", row, row))
+	row++
+
+	// 考核说明 row
+	b.startRow(row, 110)
+	b.text(ref("A", row), 37, "考核说明")
+	b.richText(ref("B", row), 38, explanationRuns)
+	for _, col := range []string{"C", "D", "E", "F", "G"} {
+		b.blank(ref(col, row), 38)
+	}
+	b.blank(ref("H", row), 39)
+	b.endRow()
+	merges = append(merges, fmt.Sprintf("B%d:H%d", row, row))
+
+	var out strings.Builder
+	out.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	out.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">`)
+	fmt.Fprintf(&out, `<dimension ref="A1:H%d"/>`, row)
+	if selected {
+		out.WriteString(`<sheetViews><sheetView tabSelected="1" workbookViewId="0"/></sheetViews>`)
+	} else {
+		out.WriteString(`<sheetViews><sheetView workbookViewId="0"/></sheetViews>`)
+	}
+	out.WriteString(`<sheetFormatPr defaultColWidth="9" defaultRowHeight="14.25"/><cols>`)
+	for i, width := range formColumns {
+		style := 1
+		if i == 1 || i == 7 {
+			style = 2
+		}
+		fmt.Fprintf(&out, `<col min="%d" max="%d" width="%s" style="%d" customWidth="1"/>`, i+1, i+1, strconv.FormatFloat(width, 'f', -1, 64), style)
+	}
+	out.WriteString(`</cols><sheetData>`)
+	out.Write(b.buf.Bytes())
+	fmt.Fprintf(&out, `</sheetData><mergeCells count="%d">`, len(merges))
+	for _, merge := range merges {
+		fmt.Fprintf(&out, `<mergeCell ref="%s"/>`, merge)
+	}
+	out.WriteString(`</mergeCells><pageMargins left="0.75" right="0.75" top="1" bottom="1" header="0.5" footer="0.5"/>`)
+	out.WriteString(`<pageSetup paperSize="9" orientation="landscape"/></worksheet>`)
+	return []byte(out.String())
+}
+
+// resultRuns renders "月度绩效得分M：___ ， 月度考核系数n：___， 注：…" with the values in the underlined blanks.
+func resultRuns(totalScore *float64, gradePoint *float64) string {
+	blank := func(value *float64) string {
+		if value == nil {
+			return strings.Repeat(" ", 23)
+		}
+		return "    " + strconv.FormatFloat(round2(*value), 'f', -1, 64) + "    "
+	}
+	run := func(props string, text string) string {
+		return `<r>` + props + `<t xml:space="preserve">` + escape(text) + `</t></r>`
+	}
+	return run(resultRunProps, "月度绩效得分M：") +
+		run(resultUnderlineRuns, blank(totalScore)) +
+		run(resultRunProps, " ， 月度考核系数n：") +
+		run(resultUnderlineRuns, blank(gradePoint)) +
+		run(resultRunProps, "， 注：该系数由月度绩效得分结合年度考核结果进行核算，详见《绩效考核管理规定》")
+}
+
+// rowHeight estimates the height a row needs to show its wrapped text, in points. texts maps column
+// indexes to cell values and spans the number of columns a merged cell covers.
+func rowHeight(minimum float64, texts map[int]string, spans map[int]int) float64 {
+	lines := 1
+	for col, text := range texts {
+		width := formColumns[col]
+		if span, ok := spans[col]; ok {
+			for i := 1; i < span; i++ {
+				width += formColumns[col+i]
+			}
+		}
+		// A 9pt 微软雅黑 CJK character (2 display units) takes about 1.5 characters of column width
+		units := max(int(width/0.75), 1)
+		cellLines := 0
+		for _, line := range strings.Split(text, "\n") {
+			cellLines += max((displayWidth(line)+units-1)/units, 1)
+		}
+		if cellLines > lines {
+			lines = cellLines
+		}
+	}
+	return max(minimum, float64(lines)*13+6)
+}
+
+// displayWidth counts wide characters as 2 and others as 1.
+func displayWidth(s string) int {
+	width := 0
+	for _, r := range s {
+		if utf8.RuneLen(r) > 1 {
+			width += 2
+		} else {
+			width++
+		}
+	}
+	return width
+}
+
+func ref(col string, row int) string {
+	return col + strconv.Itoa(row)
+}
+
+func round2(value float64) float64 {
+	return math.Round(value*100) / 100
+}
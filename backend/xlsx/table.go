@@ -0,0 +1,90 @@
+package xlsx
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Template styles used by plain tables: bold shaded headings and bordered 9pt cells.
+const (
+	tableHeaderStyle = 31
+	tableCellStyle   = 28
+)
+
+// Cell is a value of a plain table, either text or a number.
+type Cell struct {
+	Text   string
+	Number *float64
+}
+
+// TextCell creates a text cell.
+func TextCell(text string) Cell {
+	return Cell{Text: text}
+}
+
+// NumberCell creates a numeric cell.
+func NumberCell(value float64) Cell {
+	return Cell{Number: &value}
+}
+
+// WriteTable writes a workbook with a single sheet holding a heading row and the data rows,
+// with the heading frozen and the columns sized to their content.
+func WriteTable(w io.Writer, sheetName string, header []string, rows [][]Cell) error {
+	widths := make([]int, len(header))
+	for i, heading := range header {
+		widths[i] = displayWidth(heading)
+	}
+
+	var b cellBuilder
+	b.startRow(1, 22)
+	for i, heading := range header {
+		b.text(ref(columnName(i), 1), tableHeaderStyle, heading)
+	}
+	b.endRow()
+	for r, cells := range rows {
+		row := r + 2
+		b.startRow(row, 18)
+		for i, cell := range cells {
+			if cell.Number != nil {
+				b.number(ref(columnName(i), row), tableCellStyle, cell.Number)
+				cell.Text = strconv.FormatFloat(*cell.Number, 'f', -1, 64)
+			} else {
+				b.text(ref(columnName(i), row), tableCellStyle, cell.Text)
+			}
+			if i < len(widths) && displayWidth(cell.Text) > widths[i] {
+				widths[i] = displayWidth(cell.Text)
+			}
+		}
+		b.endRow()
+	}
+
+	var out strings.Builder
+	out.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	out.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">`)
+	fmt.Fprintf(&out, `<dimension ref="A1:%s%d"/>`, columnName(max(len(header), 1)-1), len(rows)+1)
+	out.WriteString(`<sheetViews><sheetView tabSelected="1" workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>`)
+	out.WriteString(`<sheetFormatPr defaultColWidth="9" defaultRowHeight="14.25"/>`)
+	if len(widths) > 0 {
+		out.WriteString(`<cols>`)
+		for i, width := range widths {
+			fmt.Fprintf(&out, `<col min="%d" max="%d" width="%d" customWidth="1"/>`, i+1, i+1, min(width+4, 60))
+		}
+		out.WriteString(`</cols>`)
+	}
+	out.WriteString(`<sheetData>`)
+	out.Write(b.buf.Bytes())
+	out.WriteString(`</sheetData><pageMargins left="0.75" right="0.75" top="1" bottom="1" header="0.5" footer="0.5"/></worksheet>`)
+
+	return writeWorkbook(w, []sheet{{name: sheetName, xml: []byte(out.String())}})
+}
+
+// columnName converts a zero-based column index to its letters, e.g. 0 to "A" and 27 to "AB".
+func columnName(index int) string {
+	name := ""
+	for index++; index > 0; index = (index - 1) / 26 {
+		name = string(rune('A'+(index-1)%26)) + name
+	}
+	return name
+}
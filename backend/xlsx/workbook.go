@@ -0,0 +1,182 @@
+// Package xlsx writes and reads Office Open XML workbooks with the standard library only.
+// Written workbooks reuse the styles of the 附件1 月度绩效考核表 template.
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	_ "embed"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+//go:embed template/styles.xml
+var stylesXML []byte
+
+//go:embed template/theme1.xml
+var themeXML []byte
+
+// sheet is a rendered worksheet part.
+type sheet struct {
+	name string
+	xml  []byte
+}
+
+// writeWorkbook packages the worksheets into a workbook with the template styles.
+func writeWorkbook(w io.Writer, sheets []sheet) error {
+	var contentTypes, workbook, workbookRels strings.Builder
+	contentTypes.WriteString(xml.Header)
+	contentTypes.WriteString(`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+		`<Override PartName="/xl/theme/theme1.xml" ContentType="application/vnd.openxmlformats-officedocument.theme+xml"/>`)
+	workbook.WriteString(xml.Header)
+	workbook.WriteString(`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+	workbookRels.WriteString(xml.Header)
+	workbookRels.WriteString(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+
+	used := make(map[string]bool)
+	for i, s := range sheets {
+		n := i + 1
+		fmt.Fprintf(&contentTypes, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, n)
+		fmt.Fprintf(&workbook, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, escape(uniqueSheetName(s.name, used)), n, n)
+		fmt.Fprintf(&workbookRels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, n, n)
+	}
+	n := len(sheets)
+	fmt.Fprintf(&workbookRels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`, n+1)
+	fmt.Fprintf(&workbookRels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/theme" Target="theme/theme1.xml"/>`, n+2)
+	contentTypes.WriteString(`</Types>`)
+	workbook.WriteString(`</sheets></workbook>`)
+	workbookRels.WriteString(`</Relationships>`)
+
+	parts := []struct {
+		name string
+		data []byte
+	}{
+		{"[Content_Types].xml", []byte(contentTypes.String())},
+		{"_rels/.rels", []byte(xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`)},
+		{"xl/workbook.xml", []byte(workbook.String())},
+		{"xl/_rels/workbook.xml.rels", []byte(workbookRels.String())},
+		{"xl/styles.xml", stylesXML},
+		{"xl/theme/theme1.xml", themeXML},
+	}
+	for i, s := range sheets {
+		parts = append(parts, struct {
+			name string
+			data []byte
+		}{fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1), s.xml})
+	}
+
+	zw := zip.NewWriter(w)
+	for _, part := range parts {
+		f, err := zw.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := f.Write(part.data); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// uniqueSheetName strips the characters Excel does not allow in sheet names, truncates the name
+// to 31 characters and adds a suffix if it is already used.
+func uniqueSheetName(name string, used map[string]bool) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(name))
+	if name == "" {
+		name = "Sheet"
+	}
+	candidate := truncateRunes(name, 31)
+	for i := 2; used[strings.ToLower(candidate)]; i++ {
+		suffix := fmt.Sprintf(" (%d)", i)
+		candidate = truncateRunes(name, 31-len(suffix)) + suffix
+	}
+	used[strings.ToLower(candidate)] = true
+	return candidate
+}
+
+func truncateRunes(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
+
+// cellBuilder renders the cells of a worksheet row by row.
+type cellBuilder struct {
+	buf bytes.Buffer
+}
+
+func (b *cellBuilder) startRow(row int, height float64) {
+	fmt.Fprintf(&b.buf, `<row r="%d" ht="%s" customHeight="1">`, row, strconv.FormatFloat(height, 'f', -1, 64))
+}
+
+func (b *cellBuilder) endRow() {
+	b.buf.WriteString(`</row>`)
+}
+
+// blank writes an empty styled cell, used for the covered cells of merged ranges.
+func (b *cellBuilder) blank(ref string, style int) {
+	fmt.Fprintf(&b.buf, `<c r="%s" s="%d"/>`, ref, style)
+}
+
+func (b *cellBuilder) text(ref string, style int, value string) {
+	if value == "" {
+		b.blank(ref, style)
+		return
+	}
+	fmt.Fprintf(&b.buf, `<c r="%s" s="%d" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, style, escape(value))
+}
+
+// richText writes a cell whose inline string is made of pre-rendered <r> runs.
+func (b *cellBuilder) richText(ref string, style int, runs string) {
+	fmt.Fprintf(&b.buf, `<c r="%s" s="%d" t="inlineStr"><is>%s</is></c>`, ref, style, runs)
+}
+
+func (b *cellBuilder) number(ref string, style int, value *float64) {
+	if value == nil {
+		b.blank(ref, style)
+		return
+	}
+	fmt.Fprintf(&b.buf, `<c r="%s" s="%d"><v>%s</v></c>`, ref, style, strconv.FormatFloat(*value, 'f', -1, 64))
+}
+
+func (b *cellBuilder) formula(ref string, style int, formula string, cached float64) {
+	fmt.Fprintf(&b.buf, `<c r="%s" s="%d"><f>%s</f><v>%s</v></c>`, ref, style, escape(formula), strconv.FormatFloat(cached, 'f', -1, 64))
+}
+
+func (b *cellBuilder) date(ref string, style int, value *time.Time) {
+	if value == nil {
+		b.blank(ref, style)
+		return
+	}
+	serial := excelSerial(*value)
+	b.number(ref, style, &serial)
+}
+
+// excelSerial converts a date to an Excel serial day number in the 1900 date system.
+func excelSerial(t time.Time) float64 {
+	epoch := time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	return float64(day.Sub(epoch).Hours() / 24)
+}
+
+func escape(s string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
@@ -44,21 +44,41 @@ COMMENT ON TABLE users IS '员工信息表';
 COMMENT ON COLUMN users.wechat_userid IS '企业微信的UserID，用于单点登录和身份识别';
 COMMENT ON COLUMN users.manager_id IS '直属上级的ID，用于构建汇报关系';
 
+-- 考核周期表 (Review Cycles)
+-- 月度、季度、半年度、试用期或自定义的考核周期
+CREATE TABLE review_cycles (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    code VARCHAR(32) NOT NULL UNIQUE, -- 周期编码，例如 "2025-07"、"2025-Q3"
+    cycle_type VARCHAR(32) NOT NULL, -- monthly, quarterly, half_year, probation, custom
+    start_date DATE NOT NULL,
+    end_date DATE NOT NULL,
+    scope_type VARCHAR(32) NOT NULL DEFAULT 'all', -- all, department, users
+    department_id INTEGER REFERENCES departments(id) ON DELETE SET NULL,
+    is_closed BOOLEAN NOT NULL DEFAULT FALSE,
+    created_by_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+COMMENT ON TABLE review_cycles IS '考核周期表';
+COMMENT ON COLUMN review_cycles.code IS '周期编码，绩效评估的 period 即为该编码';
+
 -- 月度绩效评估主表 (Performance Reviews)
 -- 每次绩效评估的核心记录
 CREATE TABLE performance_reviews (
     id SERIAL PRIMARY KEY,
     user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
-    period VARCHAR(7) NOT NULL, -- 绩效周期，格式 "YYYY-MM"
+    period VARCHAR(32) NOT NULL, -- 绩效周期编码，例如 "YYYY-MM" 或 "YYYY-Q3"
     status VARCHAR(50) NOT NULL DEFAULT 'Draft', -- Draft, PendingApproval, Approved, Evaluating, Completed, Rejected
     total_score NUMERIC(5, 2), -- 最终总分
     final_comment TEXT, -- 最终评语
     created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
     updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
-    UNIQUE(user_id, period) -- 每个员工每个月只能有一份绩效
+    cycle_id INTEGER NOT NULL REFERENCES review_cycles(id), -- 所属考核周期，period 为该周期的编码
+    UNIQUE(user_id, cycle_id) -- 每个员工每个考核周期只能有一份绩效
 );
 COMMENT ON TABLE performance_reviews IS '月度绩效评估主表';
-COMMENT ON COLUMN performance_reviews.period IS '绩效周期，格式 YYYY-MM';
+COMMENT ON COLUMN performance_reviews.period IS '绩效周期编码，例如 YYYY-MM、YYYY-Q3';
 COMMENT ON COLUMN performance_reviews.status IS '绩效状态：草稿、待审批、已批准、评分中、已完成、已驳回';
 
 -- 绩效评估项表 (Performance Items)
@@ -169,11 +189,16 @@ INSERT INTO users (name, email, role_id, department_id) VALUES
 ('陈独立', 'chenduli@example.com', (SELECT id FROM roles WHERE name = '组员'), (SELECT id FROM departments WHERE name = '产品部'));
 
 
+-- Insert Review Cycles for the periods used by the sample reviews
+INSERT INTO review_cycles (name, code, cycle_type, start_date, end_date) VALUES
+('2025-06', '2025-06', 'monthly', '2025-06-01', '2025-06-30'),
+('2025-07', '2025-07', 'monthly', '2025-07-01', '2025-07-31');
+
 -- Insert Performance Reviews and Items
 
 -- Review 1: 钱成员 (qianchengyuan@example.com) - Draft
-INSERT INTO performance_reviews (user_id, period, status, final_comment) VALUES
-((SELECT id FROM users WHERE email = 'qianchengyuan@example.com'), '2025-07', '草稿', NULL);
+INSERT INTO performance_reviews (user_id, period, cycle_id, status, final_comment) VALUES
+((SELECT id FROM users WHERE email = 'qianchengyuan@example.com'), '2025-07', (SELECT id FROM review_cycles WHERE code = '2025-07'), '草稿', NULL);
 
 INSERT INTO performance_items (review_id, category, title, description, weight, target) VALUES
 ((SELECT id FROM performance_reviews WHERE user_id = (SELECT id FROM users WHERE email = 'qianchengyuan@example.com') AND period = '2025-07'), '工作业绩', '完成项目A核心模块', '负责项目A的后端核心逻辑开发', 50, '模块功能通过所有单元测试'),
@@ -182,8 +207,8 @@ INSERT INTO performance_items (review_id, category, title, description, weight,
 ((SELECT id FROM performance_reviews WHERE user_id = (SELECT id FROM users WHERE email = 'qianchengyuan@example.com') AND period = '2025-07'), '价值观', '团队协作', '积极与团队成员沟通协作，共同解决问题', 10, '获得至少3位同事的正面反馈');
 
 -- Review 2: 孙成员 (sunchengyuan@example.com) - Completed
-INSERT INTO performance_reviews (user_id, period, status, total_score, final_comment) VALUES
-((SELECT id FROM users WHERE email = 'sunchengyuan@example.com'), '2025-06', '已完成', 85.5, '该员工表现优秀，超额完成任务。');
+INSERT INTO performance_reviews (user_id, period, cycle_id, status, total_score, final_comment) VALUES
+((SELECT id FROM users WHERE email = 'sunchengyuan@example.com'), '2025-06', (SELECT id FROM review_cycles WHERE code = '2025-06'), '已完成', 85.5, '该员工表现优秀，超额完成任务。');
 
 INSERT INTO performance_items (review_id, category, title, description, weight, target, completion_details, score) VALUES
 ((SELECT id FROM performance_reviews WHERE user_id = (SELECT id FROM users WHERE email = 'sunchengyuan@example.com') AND period = '2025-06'), '工作业绩', '完成项目B需求分析', '负责项目B的需求调研和文档编写', 40, '需求文档通过评审', '按时提交需求文档，并获得高层认可', 90),
@@ -192,8 +217,8 @@ INSERT INTO performance_items (review_id, category, title, description, weight,
 ((SELECT id FROM performance_reviews WHERE user_id = (SELECT id FROM users WHERE email = 'sunchengyuan@example.com') AND period = '2025-06'), '价值观', '客户导向', '积极响应客户需求，提供优质服务', 10, '客户满意度达到90%', '客户满意度达到95%，无客户投诉', 70);
 
 -- Review 3: 吴成员 (wuchengyuan@example.com) - Pending Score (待打分)
-INSERT INTO performance_reviews (user_id, period, status, final_comment) VALUES
-((SELECT id FROM users WHERE email = 'wuchengyuan@example.com'), '2025-07', '待打分', NULL);
+INSERT INTO performance_reviews (user_id, period, cycle_id, status, final_comment) VALUES
+((SELECT id FROM users WHERE email = 'wuchengyuan@example.com'), '2025-07', (SELECT id FROM review_cycles WHERE code = '2025-07'), '待打分', NULL);
 
 INSERT INTO performance_items (review_id, category, title, description, weight, target) VALUES
 ((SELECT id FROM performance_reviews WHERE user_id = (SELECT id FROM users WHERE email = 'wuchengyuan@example.com') AND period = '2025-07'), '工作业绩', '完成新功能开发', '负责新功能从设计到上线全流程', 60, '功能按时上线，无重大bug'),